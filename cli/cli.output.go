@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+)
+
+type reportFinding struct {
+	models.Finding
+	Severity string `json:"severity"`
+}
+
+type reportError struct {
+	Provider     string `json:"provider"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	Error        string `json:"error"`
+}
+
+type reportSummary struct {
+	Resources      int            `json:"resources"`
+	Files          int            `json:"files"`
+	SkippedFiles   int            `json:"skipped_files"`
+	TruncatedFiles int            `json:"truncated_files"`
+	FailedFiles    int            `json:"failed_files"`
+	Findings       int            `json:"findings"`
+	BySeverity     map[string]int `json:"by_severity"`
+	FailOn         string         `json:"fail_on"`
+	// complete / partial (files fail, truncate ya size pe skip hui); findings na ho to partial pe exit code 3
+	Coverage string `json:"coverage"`
+	// threshold ya usse upar ki finding mili, exit code 1
+	Failed bool          `json:"failed"`
+	Errors []reportError `json:"errors,omitempty"`
+}
+
+type scanReport struct {
+	Findings []reportFinding `json:"findings"`
+	Summary  reportSummary   `json:"summary"`
+	// SARIF fingerprints asli secret se bante hai, isliye unredacted findings bhi
+	raw         []models.Finding
+	showSecrets bool
+}
+
+type reportWriter func(io.Writer, scanReport) error
+
+var reportWriters = map[string]reportWriter{
+	"table": writeTable,
+	"json":  writeJSON,
+	"sarif": writeSARIF,
+}
+
+func reportFormats() []string {
+	formats := make([]string, 0, len(reportWriters))
+	for format := range reportWriters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// findings severity ke hisaab se (high pehle), phir resource / file / line
+func buildReport(results []targetResult, threshold int, showSecrets bool) scanReport {
+	report := scanReport{
+		Findings: []reportFinding{},
+		Summary: reportSummary{
+			BySeverity: map[string]int{util.SeverityHigh: 0, util.SeverityMedium: 0, util.SeverityLow: 0},
+			FailOn:     "none",
+			Coverage:   util.CoverageComplete,
+		},
+		showSecrets: showSecrets,
+	}
+	for severity := range report.Summary.BySeverity {
+		if util.SeverityRank(severity) == threshold {
+			report.Summary.FailOn = severity
+		}
+	}
+
+	for _, result := range results {
+		if result.err != nil {
+			report.Summary.Errors = append(report.Summary.Errors, reportError{
+				Provider:     result.target.source.Name(),
+				ResourceType: result.target.resourceType,
+				ResourceID:   result.target.resourceID,
+				Error:        result.err.Error(),
+			})
+			continue
+		}
+		report.Summary.Resources++
+		report.Summary.Files += len(result.request.Siblings)
+		report.Summary.SkippedFiles += len(result.request.Skipped)
+		report.Summary.FailedFiles += len(result.request.Failed)
+		coverage := util.CoverageFromRequest(result.request)
+		report.Summary.TruncatedFiles += len(coverage.Truncated)
+		if coverage.Status == util.CoveragePartial {
+			report.Summary.Coverage = util.CoveragePartial
+		}
+
+		report.raw = append(report.raw, result.findings...)
+		for _, finding := range result.findings {
+			severity := util.SeverityOf(finding)
+			// CI logs public hote hai, isliye default me redacted
+			if !showSecrets {
+				finding.Secret = util.RedactSecret(finding.Secret)
+			}
+			report.Findings = append(report.Findings, reportFinding{Finding: finding, Severity: severity})
+			report.Summary.BySeverity[severity]++
+			if threshold > 0 && util.SeverityRank(severity) >= threshold {
+				report.Summary.Failed = true
+			}
+		}
+	}
+	report.Summary.Findings = len(report.Findings)
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if util.SeverityRank(a.Severity) != util.SeverityRank(b.Severity) {
+			return util.SeverityRank(a.Severity) > util.SeverityRank(b.Severity)
+		}
+		if a.ResourceID != b.ResourceID {
+			return a.ResourceID < b.ResourceID
+		}
+		if a.FileName != b.FileName {
+			return a.FileName < b.FileName
+		}
+		return a.Line < b.Line
+	})
+	return report
+}
+
+// file:line (notebook cell), discussion / PR number, image config field ya history commit
+func findingLocation(finding models.Finding) string {
+	switch finding.SourceType {
+	case "discussion":
+		if finding.PRNum != 0 {
+			return fmt.Sprintf("PR #%d", finding.PRNum)
+		}
+		return fmt.Sprintf("discussion #%d", finding.DiscussionNum)
+	case "pr":
+		return fmt.Sprintf("PR #%d %s:%d", finding.PRNum, finding.FileName, finding.Line)
+	case "history":
+		return fmt.Sprintf("%s:%d @ %.12s", finding.FileName, finding.Line, finding.CommitSHA)
+	}
+	location := finding.FileName
+	if finding.Line > 0 {
+		location = fmt.Sprintf("%s:%d", finding.FileName, finding.Line)
+	}
+	if finding.Cell > 0 {
+		location = fmt.Sprintf("%s (cell %d)", location, finding.Cell)
+	}
+	if finding.LayerDigest != "" {
+		location = fmt.Sprintf("%s (layer %.19s)", location, finding.LayerDigest)
+	}
+	return location
+}
+
+func writeTable(w io.Writer, report scanReport) error {
+	if len(report.Findings) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SEVERITY\tSECRET TYPE\tRESOURCE\tLOCATION\tSECRET")
+		for _, finding := range report.Findings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				strings.ToUpper(finding.Severity), finding.SecretType, finding.ResourceID, findingLocation(finding.Finding), finding.Secret)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	summary := report.Summary
+	fmt.Fprintf(w, "%d findings (high: %d, medium: %d, low: %d) in %d resources, %d files scanned, %d skipped, %d truncated, %d failed\n",
+		summary.Findings, summary.BySeverity[util.SeverityHigh], summary.BySeverity[util.SeverityMedium], summary.BySeverity[util.SeverityLow],
+		summary.Resources, summary.Files, summary.SkippedFiles, summary.TruncatedFiles, summary.FailedFiles)
+	for _, scanErr := range summary.Errors {
+		fmt.Fprintf(w, "error: %s %s/%s: %s\n", scanErr.Provider, scanErr.ResourceType, scanErr.ResourceID, scanErr.Error)
+	}
+	if summary.Failed {
+		fmt.Fprintf(w, "FAILED: findings at or above %s severity\n", summary.FailOn)
+	}
+	if summary.Coverage == util.CoveragePartial {
+		fmt.Fprintln(w, "PARTIAL: some files were truncated, skipped for size or failed to fetch; not everything was scanned")
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, report scanReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func writeSARIF(w io.Writer, report scanReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	secretDisplay := util.SecretDisplayRedacted
+	if report.showSecrets {
+		secretDisplay = util.SecretDisplayFull
+	}
+	return encoder.Encode(util.BuildSARIF(report.raw, util.ActiveRuleSet().Patterns, "", secretDisplay))
+}
@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+)
+
+// exit codes: CI job inhi pe gate karta hai
+const (
+	ExitClean    = 0
+	ExitFindings = 1
+	ExitError    = 2
+	// findings nahi mili par sab kuch scan bhi nahi hua (files fail / truncate hui)
+	ExitPartial = 3
+)
+
+const usage = `Usage:
+  scanner                      start the API server
+  scanner scan [flags]         scan in-process and exit (no database needed)
+
+Scan targets (at least one):
+  --model org/name             Hugging Face model
+  --dataset org/name           Hugging Face dataset
+  --space org/name             Hugging Face space
+  --org name                   every model, dataset and space of a Hugging Face org / user
+  --path dir                   local directory (respects .gitignore)
+  --image file.tar             docker save / OCI layout tarball
+  --provider name --resource id   any registered source (github, gitlab, gitea, s3, ...)
+
+Custom rules: --rules file.toml (gitleaks config, or the same keys in YAML) adds to / replaces the built-in rules
+
+Exit codes: 0 no findings at or above --fail-on, 1 findings, 2 usage or scan errors,
+            3 no findings but coverage was partial (files failed, truncated or skipped for size)
+`
+
+// "scanner <subcommand> ..." ; args me subcommand se shuru hota hai
+func Run(args []string) int {
+	// server wale loadConfig tak CLI pahunchta hi nahi, .env (HF_TOKEN, RULES_FILE, ...) yahi padhna hai
+	util.LoadDotEnv()
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return ExitError
+	}
+	switch args[0] {
+	case "scan":
+		return runScan(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return ExitClean
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+	return ExitError
+}
+
+// kya scan karna hai: ek source ka ek item
+type scanTarget struct {
+	source       util.Source
+	resourceType string
+	resourceID   string
+}
+
+type targetResult struct {
+	target   scanTarget
+	request  models.AI_REQUEST
+	findings []models.Finding
+	err      error
+}
+
+func runScan(args []string) int {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage+"\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	model := fs.String("model", "", "Hugging Face model id (org/name)")
+	dataset := fs.String("dataset", "", "Hugging Face dataset id (org/name)")
+	space := fs.String("space", "", "Hugging Face space id (org/name)")
+	org := fs.String("org", "", "Hugging Face org or user; scans all its repos")
+	orgTypes := fs.String("types", "models,datasets,spaces", "resource types to scan with --org")
+	localPath := fs.String("path", "", "local directory to scan")
+	image := fs.String("image", "", "container image tarball (docker save or OCI layout)")
+	provider := fs.String("provider", "", "source provider for --resource")
+	resource := fs.String("resource", "", "resource id for --provider")
+	resourceType := fs.String("resource-type", "", "resource type for --provider (default: the provider's first type)")
+
+	includeDiscussions := fs.Bool("include-discussions", false, "scan discussions / issues")
+	includePRs := fs.Bool("include-prs", false, "scan pull requests")
+	scanPRDiffs := fs.Bool("scan-pr-diffs", false, "scan added lines of PR diffs")
+	scanHistory := fs.Bool("scan-history", false, "scan commit history")
+	ref := fs.String("ref", "", "branch / tag / commit (git forges) or image tag (--image)")
+	gitScope := fs.String("git-scope", "", "with --path: staged or modified files only")
+
+	format := fs.String("format", "table", "output format: table, json or sarif")
+	output := fs.String("output", "", "write the report to this file instead of stdout")
+	failOn := fs.String("fail-on", util.SeverityLow, "lowest severity that fails the run: low, medium, high or none")
+	showSecrets := fs.Bool("show-secrets", false, "print secrets in full instead of redacted")
+	verbose := fs.Bool("verbose", false, "print scan logs to stderr")
+	rulesFile := fs.String("rules", util.GetEnv("RULES_FILE", ""), "gitleaks-style rules file (.toml or .yaml) merged with the built-in rules")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitClean
+		}
+		return ExitError
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return ExitError
+	}
+
+	threshold := 0
+	if *failOn != "none" {
+		severity, err := util.ParseSeverity(*failOn)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitError
+		}
+		threshold = util.SeverityRank(severity)
+	}
+	writer, ok := reportWriters[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "invalid --format %q: use %s\n", *format, strings.Join(reportFormats(), ", "))
+		return ExitError
+	}
+
+	opts, err := util.FetchOptionsFromRequest(models.ScanRequestBody{
+		IncludeDiscussions: *includeDiscussions,
+		IncludePRs:         *includePRs,
+		ScanPRDiffs:        *scanPRDiffs,
+		ScanHistory:        *scanHistory,
+		Ref:                *ref,
+		GitScope:           *gitScope,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitError
+	}
+
+	// pipeline ke logs stdout ki report me na mile; --verbose pe stderr pe
+	if *verbose {
+		log.SetOutput(os.Stderr)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+	// CLI me Mongo nahi hai: na pichla scan state, na blob cache
+	os.Setenv("INCREMENTAL_RESCAN", "false")
+	os.Setenv("BLOB_CACHE_ENABLED", "false")
+
+	// CLI me saved rule sets nahi, file ke rules seedhe built-in set me
+	if *rulesFile != "" {
+		if err := util.LoadRulesFile(*rulesFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitError
+		}
+	}
+
+	targets, err := collectTargets(targetFlags{
+		model: *model, dataset: *dataset, space: *space,
+		org: *org, orgTypes: *orgTypes,
+		path: *localPath, image: *image,
+		provider: *provider, resource: *resource, resourceType: *resourceType,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitError
+	}
+
+	results := scanTargets(targets, opts)
+	report := buildReport(results, threshold, *showSecrets)
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitError
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := writer(out, report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitError
+	}
+
+	return exitCode(report.Summary)
+}
+
+// findings > errors > partial coverage
+func exitCode(summary reportSummary) int {
+	switch {
+	case summary.Failed:
+		return ExitFindings
+	case len(summary.Errors) > 0:
+		return ExitError
+	case summary.Coverage == util.CoveragePartial:
+		return ExitPartial
+	}
+	return ExitClean
+}
+
+type targetFlags struct {
+	model, dataset, space            string
+	org, orgTypes                    string
+	path, image                      string
+	provider, resource, resourceType string
+}
+
+func collectTargets(flags targetFlags) ([]scanTarget, error) {
+	hf, err := util.GetSource(util.ProviderHuggingFace)
+	if err != nil {
+		return nil, err
+	}
+	targets := []scanTarget{}
+	if flags.model != "" {
+		targets = append(targets, scanTarget{hf, string(util.ResourceTypeModel), flags.model})
+	}
+	if flags.dataset != "" {
+		targets = append(targets, scanTarget{hf, string(util.ResourceTypeDataset), flags.dataset})
+	}
+	if flags.space != "" {
+		targets = append(targets, scanTarget{hf, string(util.ResourceTypeSpace), flags.space})
+	}
+	if flags.org != "" {
+		for _, resourceType := range strings.Split(flags.orgTypes, ",") {
+			resourceType = strings.TrimSpace(resourceType)
+			if !util.SupportsResourceType(hf, resourceType) {
+				return nil, fmt.Errorf("invalid --types entry %q: use %s", resourceType, strings.Join(hf.ResourceTypes(), ", "))
+			}
+			items, err := hf.ListItems(resourceType, flags.org)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s of %s: %w", resourceType, flags.org, err)
+			}
+			for _, item := range items {
+				targets = append(targets, scanTarget{hf, resourceType, item.ResourceID})
+			}
+		}
+	}
+	// CLI user ki di hui local path padhta hai, API wala LOCAL_SCAN_ROOTS check yahan nahi
+	if flags.path != "" {
+		targets = append(targets, scanTarget{util.LocalSource{AllowAnyPath: true}, util.ResourceTypeDir, flags.path})
+	}
+	if flags.image != "" {
+		targets = append(targets, scanTarget{util.ContainerSource{AllowAnyPath: true}, util.ResourceTypeImage, flags.image})
+	}
+	if flags.provider != "" || flags.resource != "" {
+		if flags.provider == "" || flags.resource == "" {
+			return nil, fmt.Errorf("--provider and --resource must be used together")
+		}
+		source, err := util.GetSource(flags.provider)
+		if err != nil {
+			return nil, err
+		}
+		switch source.Name() {
+		case util.ProviderLocal:
+			source = util.LocalSource{AllowAnyPath: true}
+		case util.ProviderContainer:
+			source = util.ContainerSource{AllowAnyPath: true}
+		}
+		resourceType := flags.resourceType
+		if resourceType == "" {
+			resourceType = source.ResourceTypes()[0]
+		}
+		if !util.SupportsResourceType(source, resourceType) {
+			return nil, fmt.Errorf("provider %s does not support resource type %q: use %s", source.Name(), resourceType, strings.Join(source.ResourceTypes(), ", "))
+		}
+		targets = append(targets, scanTarget{source, resourceType, flags.resource})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("nothing to scan: pass --model, --dataset, --space, --org, --path, --image or --provider with --resource")
+	}
+	return targets, nil
+}
+
+// har target fetch + scan, thode concurrently (org scan me bahut saare repos hote hai)
+func scanTargets(targets []scanTarget, opts util.FetchOptions) []targetResult {
+	results := make([]targetResult, len(targets))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 4)
+
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target scanTarget) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := targetResult{target: target}
+			request := models.AI_REQUEST{Provider: target.source.Name()}
+			if _, err := target.source.Fetch(&request, target.resourceType, target.resourceID, opts); err != nil {
+				result.err = err
+			} else {
+				result.request = request
+				result.findings = util.ScanAIRequest(request, util.ActiveRuleSet().Patterns, request.ResourceType, request.ResourceID)
+			}
+			results[i] = result
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []targetResult
+		want    int
+	}{
+		{"clean", []targetResult{{request: models.AI_REQUEST{Siblings: []models.SIBLING{{RFilename: "a.py"}}}}}, ExitClean},
+		{"failed file", []targetResult{{request: models.AI_REQUEST{Failed: []models.FAILED_ITEM{{Name: "b.py"}}}}}, ExitPartial},
+		{"truncated file", []targetResult{{request: models.AI_REQUEST{Siblings: []models.SIBLING{{RFilename: "big.json", Truncated: true}}}}}, ExitPartial},
+		{"binary skip is not partial", []targetResult{{request: models.AI_REQUEST{Skipped: []models.SKIPPED_FILE{{RFilename: "x.bin", Reason: util.SkipReasonBinary}}}}}, ExitClean},
+		{"findings win over partial", []targetResult{{
+			request:  models.AI_REQUEST{Failed: []models.FAILED_ITEM{{Name: "b.py"}}},
+			findings: []models.Finding{{SecretType: "AWS Access Key", Secret: "AKIA0000000000000000"}},
+		}}, ExitFindings},
+	}
+	for _, tt := range tests {
+		report := buildReport(tt.results, util.SeverityRank(util.SeverityLow), false)
+		if got := exitCode(report.Summary); got != tt.want {
+			t.Errorf("%s: exit code = %d, want %d (summary %+v)", tt.name, got, tt.want, report.Summary)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	DiscoveryRunning   = "running"
+	DiscoveryCompleted = "completed"
+	DiscoveryFailed    = "failed"
+
+	DiscoveryHitScanned = "scanned"
+	DiscoveryHitDeduped = "deduped"
+	DiscoveryHitFailed  = "failed"
+	DiscoveryHitBudget  = "budget_exhausted"
+)
+
+// runDiscoveryJob ki Mongo calls; tests inhe memory wali se badal ke job bina database ke chalate hai
+var (
+	loadDiscoveryScanState = util.LoadScanState
+	// scan ke saath resource ki scan state bhi save hoti hai (agli discovery ka dedupe usi se)
+	scanDiscoveryRequest = util.ScanWithPlan
+	saveDiscoveryRequest = func(aiRequest *models.AI_REQUEST) error { return mgm.Coll(aiRequest).Create(aiRequest) }
+	saveDiscoveryJob     = func(job *models.DISCOVERY_JOB) error { return mgm.Coll(job).Update(job) }
+	saveDiscoveryResult  = func(scanResult *models.SCAN_RESULT) error { return mgm.Coll(scanResult).Create(scanResult) }
+)
+
+type discoveryBody struct {
+	models.DISCOVERY_QUERY
+	MaxRepos           int   `json:"max_repos"`
+	MaxBytes           int64 `json:"max_bytes"`
+	IncludeDiscussions bool  `json:"include_discussions"`
+	IncludePRs         bool  `json:"include_prs"`
+	FullRescan         bool  `json:"full_rescan"`
+}
+
+// HF search query chala ke jo repos mile unhe existing pipeline se scan karta hai (apne keys ke leak dhundhne ke liye).
+// job background me chalta hai, response me sirf job_id; progress GET /api/discovery/:job_id se
+func StartDiscovery(c *fiber.Ctx) error {
+	start := time.Now()
+	traceID := uuid.New().String()
+
+	var body discoveryBody
+	if err := c.BodyParser(&body); err != nil {
+		log.Printf(
+			"op=StartDiscovery stage=body_parse_error trace_id=%s error=%v elapsed=%s",
+			traceID, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, "Invalid request body", nil, "")
+	}
+	if body.ResourceType == "" {
+		body.ResourceType = "models"
+	}
+	if err := util.ValidateDiscoveryQuery(body.DISCOVERY_QUERY); err != nil {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	budget := models.DISCOVERY_BUDGET{
+		MaxRepos: int(discoveryEnvInt64("DISCOVERY_MAX_REPOS", 25)),
+		MaxBytes: discoveryEnvInt64("DISCOVERY_MAX_BYTES", 200*1024*1024),
+	}
+	// body se sirf server limit ke andar hi kam kar sakte hai, badha nahi sakte
+	if body.MaxRepos > 0 && body.MaxRepos < budget.MaxRepos {
+		budget.MaxRepos = body.MaxRepos
+	}
+	if body.MaxBytes > 0 && body.MaxBytes < budget.MaxBytes {
+		budget.MaxBytes = body.MaxBytes
+	}
+
+	job := &models.DISCOVERY_JOB{
+		JobID:          uuid.New().String(),
+		Query:          body.DISCOVERY_QUERY,
+		Budget:         budget,
+		Status:         DiscoveryRunning,
+		FindingsByType: map[string]int{},
+		Hits:           []models.DISCOVERY_HIT{},
+	}
+	if err := mgm.Coll(job).Create(job); err != nil {
+		log.Printf(
+			"op=StartDiscovery stage=db_create_error trace_id=%s error=%v elapsed=%s",
+			traceID, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to create discovery job", nil, "")
+	}
+
+	opts := util.FetchOptions{
+		IncludePRs:         body.IncludePRs,
+		IncludeDiscussions: body.IncludeDiscussions,
+		FullRescan:         body.FullRescan,
+		ScanKey:            job.JobID,
+		ByteBudget:         util.NewByteBudget(budget.MaxBytes),
+	}
+	go runDiscoveryJob(job, opts)
+
+	log.Printf(
+		"op=StartDiscovery stage=queued trace_id=%s job_id=%s resource_type=%s search=%q author=%q tags=%v sort=%s max_repos=%d max_bytes=%d",
+		traceID, job.JobID, job.Query.ResourceType, job.Query.Search, job.Query.Author, job.Query.Tags, job.Query.Sort, budget.MaxRepos, budget.MaxBytes,
+	)
+	return util.ResponseAPI(c, fiber.StatusAccepted, "Discovery job started", map[string]interface{}{
+		"job_id": job.JobID,
+		"status": job.Status,
+		"query":  job.Query,
+		"budget": job.Budget,
+	}, "")
+}
+
+func GetDiscoveryJob(c *fiber.Ctx) error {
+	jobID := c.Params("job_id")
+	job := &models.DISCOVERY_JOB{}
+	if err := mgm.Coll(job).First(bson.M{"job_id": jobID}, job); err != nil {
+		log.Printf("op=GetDiscoveryJob stage=not_found job_id=%s error=%v", jobID, err)
+		return util.ResponseAPI(c, fiber.StatusNotFound, "Discovery job not found", nil, "")
+	}
+	return util.ResponseAPI(c, fiber.StatusOK, "Discovery job retrieved successfully", job, "")
+}
+
+// saari queries ka summary, hits ke bina (wo job detail me hai)
+func GetAllDiscoveryJobs(c *fiber.Ctx) error {
+	jobs := []models.DISCOVERY_JOB{}
+	if err := mgm.Coll(&models.DISCOVERY_JOB{}).SimpleFind(&jobs, bson.M{}); err != nil {
+		log.Printf("op=GetAllDiscoveryJobs stage=db_query_error error=%v", err)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to fetch discovery jobs", nil, "")
+	}
+
+	summaries := []map[string]interface{}{}
+	for _, job := range jobs {
+		summaries = append(summaries, map[string]interface{}{
+			"job_id":           job.JobID,
+			"query":            job.Query,
+			"status":           job.Status,
+			"repos_listed":     job.ReposListed,
+			"repos_scanned":    job.ReposScanned,
+			"repos_deduped":    job.ReposDeduped,
+			"repos_failed":     job.ReposFailed,
+			"bytes_scanned":    job.BytesScanned,
+			"budget_exhausted": job.BudgetExhausted,
+			"total_findings":   job.TotalFindings,
+			"findings_by_type": job.FindingsByType,
+			"scan_result_id":   job.ScanResultID,
+			"created_at":       job.CreatedAt,
+			"finished_at":      job.FinishedAt,
+		})
+	}
+	return util.ResponseAPI(c, fiber.StatusOK, "Discovery jobs retrieved successfully", summaries, "")
+}
+
+// ek ek repo sequentially, taaki budget exact rahe aur dusro ke repos pe load na badhe;
+// file level concurrency fetch ke andar waise bhi hai
+func runDiscoveryJob(job *models.DISCOVERY_JOB, opts util.FetchOptions) {
+	start := time.Now()
+	resourceType := job.Query.ResourceType
+
+	// background goroutine hai, panic pe poora server na gire aur job "running" me na atka rahe
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("op=runDiscoveryJob stage=panic job_id=%s panic=%v stack=%s", job.JobID, r, debug.Stack())
+			job.Status = DiscoveryFailed
+			job.Error = fmt.Sprintf("discovery job crashed: %v", r)
+			finishDiscoveryJob(job, start)
+		}
+	}()
+
+	hits, err := util.SearchHub(job.Query)
+	if err != nil {
+		job.Status = DiscoveryFailed
+		job.Error = err.Error()
+		finishDiscoveryJob(job, start)
+		return
+	}
+	job.ReposListed = len(hits)
+
+	ruleSet := util.ActiveRuleSet()
+	coverage := util.NewScanCoverage()
+	scannedResources := []models.SCANNED_RESOURCE{}
+	seen := map[string]bool{}
+
+	for _, hit := range hits {
+		if seen[hit.ResourceID] {
+			continue
+		}
+		seen[hit.ResourceID] = true
+
+		result := models.DISCOVERY_HIT{
+			ResourceType: resourceType,
+			ResourceID:   hit.ResourceID,
+			CommitSHA:    hit.CommitSHA,
+		}
+
+		if job.BudgetExhausted != "" {
+			result.Status = DiscoveryHitBudget
+			job.Hits = append(job.Hits, result)
+			continue
+		}
+
+		// isi sha + rule set pe pehle scan ho chuka hai to dobara download nahi, purani findings hi result me
+		state := loadDiscoveryScanState(util.ProviderHuggingFace, resourceType, hit.ResourceID)
+		if !opts.FullRescan && state != nil && hit.CommitSHA != "" &&
+			state.CommitSHA == hit.CommitSHA && state.RuleSetVersion == ruleSet.Version {
+			result.Status = DiscoveryHitDeduped
+			result.ScanMode = string(util.RescanReused)
+			result.Findings = len(state.Findings)
+			job.ReposDeduped++
+			job.Hits = append(job.Hits, result)
+			scannedResources = append(scannedResources, discoveryResource(resourceType, hit.ResourceID, state.Findings, util.RescanReused, job))
+			continue
+		}
+
+		if job.ReposScanned >= job.Budget.MaxRepos {
+			job.BudgetExhausted = "repos"
+			result.Status = DiscoveryHitBudget
+			job.Hits = append(job.Hits, result)
+			continue
+		}
+
+		aiRequest := &models.AI_REQUEST{
+			RequestID:    uuid.New().String(),
+			ResourceType: resourceType,
+			ResourceID:   hit.ResourceID,
+			Siblings:     []models.SIBLING{},
+			Discussions:  []models.DISCUSSION{},
+		}
+		plan, err := fetchAndAddToRequest(aiRequest, hit.ResourceID, resourceType, opts)
+		if err != nil {
+			log.Printf(
+				"op=runDiscoveryJob stage=fetch_error job_id=%s resource_id=%s error=%v",
+				job.JobID, hit.ResourceID, err,
+			)
+			result.Status = DiscoveryHitFailed
+			result.Error = err.Error()
+			job.ReposFailed++
+			job.Hits = append(job.Hits, result)
+			util.AddFailedResource(coverage, resourceType+"/"+hit.ResourceID, err)
+			continue
+		}
+		if err := saveDiscoveryRequest(aiRequest); err != nil {
+			log.Printf(
+				"op=runDiscoveryJob stage=save_request_error job_id=%s resource_id=%s error=%v",
+				job.JobID, hit.ResourceID, err,
+			)
+		}
+
+		findings := scanDiscoveryRequest(*aiRequest, plan, ruleSet.Patterns, resourceType, hit.ResourceID)
+		if findings == nil {
+			findings = []models.Finding{}
+		}
+		util.MergeCoverage(coverage, util.CoverageFromRequest(*aiRequest), resourceType+"/"+hit.ResourceID)
+
+		result.CommitSHA = aiRequest.CommitSHA
+		result.ScanMode = string(plan.Mode)
+		result.Findings = len(findings)
+		result.Bytes = requestBytes(*aiRequest)
+		if plan.Mode == util.RescanReused {
+			// list me sha nahi tha, info call se pata chala ki kuch badla nahi
+			result.Status = DiscoveryHitDeduped
+			job.ReposDeduped++
+		} else {
+			result.Status = DiscoveryHitScanned
+			job.ReposScanned++
+		}
+		job.BytesScanned += result.Bytes
+		job.Hits = append(job.Hits, result)
+		scannedResources = append(scannedResources, discoveryResource(resourceType, hit.ResourceID, findings, plan.Mode, job))
+
+		if opts.ByteBudget.Exhausted() {
+			job.BudgetExhausted = "bytes"
+		}
+		// har repo ke baad progress save, taaki GET pe live status dikhe
+		if err := saveDiscoveryJob(job); err != nil {
+			log.Printf("op=runDiscoveryJob stage=progress_save_error job_id=%s error=%v", job.JobID, err)
+		}
+	}
+
+	scanResult := &models.SCAN_RESULT{
+		RequestID:        "discovery-" + job.JobID,
+		ScannedResources: scannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         coverage,
+		Status:           coverage.Status,
+	}
+	if err := saveDiscoveryResult(scanResult); err != nil {
+		log.Printf("op=runDiscoveryJob stage=save_scan_result_error job_id=%s error=%v", job.JobID, err)
+		job.Error = fmt.Sprintf("failed to save scan results: %v", err)
+	} else {
+		job.ScanResultID = scanResult.ID.Hex()
+	}
+
+	job.Status = DiscoveryCompleted
+	finishDiscoveryJob(job, start)
+}
+
+// findings ko job ke aggregate me jodta hai aur scan result ke liye resource banata hai
+func discoveryResource(resourceType, resourceID string, findings []models.Finding, mode util.RescanMode, job *models.DISCOVERY_JOB) models.SCANNED_RESOURCE {
+	job.TotalFindings += len(findings)
+	for secretType, count := range util.CountFindingsByType(findings) {
+		job.FindingsByType[secretType] += count
+	}
+	return models.SCANNED_RESOURCE{
+		Type:     resourceType,
+		ID:       resourceID,
+		Findings: findings,
+		ScanMode: string(mode),
+	}
+}
+
+func finishDiscoveryJob(job *models.DISCOVERY_JOB, start time.Time) {
+	job.FinishedAt = time.Now().Format(time.RFC3339)
+	if err := saveDiscoveryJob(job); err != nil {
+		log.Printf("op=runDiscoveryJob stage=save_error job_id=%s error=%v", job.JobID, err)
+	}
+	log.Printf(
+		"op=runDiscoveryJob stage=%s job_id=%s listed=%d scanned=%d deduped=%d failed=%d bytes=%d budget_exhausted=%q total_findings=%d error=%q elapsed=%s",
+		job.Status, job.JobID, job.ReposListed, job.ReposScanned, job.ReposDeduped, job.ReposFailed, job.BytesScanned, job.BudgetExhausted, job.TotalFindings, job.Error, time.Since(start),
+	)
+}
+
+// byte budget me wahi ginte hai jo asal me download hua
+func requestBytes(req models.AI_REQUEST) int64 {
+	var total int64
+	for _, sibling := range req.Siblings {
+		total += int64(len(sibling.FileContent))
+	}
+	for _, pr := range req.PullRequests {
+		total += int64(len(pr.Diff))
+	}
+	for _, commit := range req.History {
+		total += int64(len(commit.Diff))
+	}
+	return total
+}
+
+func discoveryEnvInt64(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(util.GetEnv(key, ""), 10, 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	util_model "github.com/MishraShardendu22/Scanner/util/model"
+)
+
+// search me chaar repos; acme-labs/cached pichle scan jaisa hi hai, other/skip author pattern se bahar
+func fakeDiscoveryHub(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/models":
+			if r.URL.Query().Get("search") != "acme" || r.URL.Query().Has("author") {
+				http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `[{"id":"acme-labs/cached","sha":"s1"},{"id":"other/skip","sha":"s2"},{"id":"acme-corp/first","sha":"s3"},{"id":"acme-labs/second","sha":"s4"}]`)
+		case strings.HasPrefix(r.URL.Path, "/api/models/"):
+			fmt.Fprint(w, `{"sha":"new","siblings":[{"rfilename":"config.json","size":10}]}`)
+		case strings.HasSuffix(r.URL.Path, "/resolve/main/config.json"):
+			fmt.Fprint(w, `{"a": 123}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func withDiscoveryStore(t *testing.T) {
+	t.Helper()
+	previousState, previousScan := loadDiscoveryScanState, scanDiscoveryRequest
+	previousRequest, previousJob, previousResult := saveDiscoveryRequest, saveDiscoveryJob, saveDiscoveryResult
+	loadDiscoveryScanState = func(provider, resourceType, resourceID string) *models.RESOURCE_SCAN_STATE {
+		if resourceID != "acme-labs/cached" {
+			return nil
+		}
+		return &models.RESOURCE_SCAN_STATE{CommitSHA: "s1", RuleSetVersion: util.RuleSetVersion(), Findings: []models.Finding{{SecretType: "Test"}}}
+	}
+	scanDiscoveryRequest = func(req models.AI_REQUEST, _ *util.RescanPlan, patterns []util_model.SecretPattern, resourceType, resourceID string) []models.Finding {
+		return util.ScanAIRequest(req, patterns, resourceType, resourceID)
+	}
+	saveDiscoveryRequest = func(*models.AI_REQUEST) error { return nil }
+	saveDiscoveryJob = func(*models.DISCOVERY_JOB) error { return nil }
+	saveDiscoveryResult = func(*models.SCAN_RESULT) error { return nil }
+	t.Cleanup(func() {
+		loadDiscoveryScanState, scanDiscoveryRequest = previousState, previousScan
+		saveDiscoveryRequest, saveDiscoveryJob, saveDiscoveryResult = previousRequest, previousJob, previousResult
+	})
+}
+
+func TestRunDiscoveryJobBudgetsAndDedupe(t *testing.T) {
+	hub := fakeDiscoveryHub(t)
+	defer hub.Close()
+	t.Setenv("HF_BASE_URL", hub.URL)
+	t.Setenv("INCREMENTAL_RESCAN", "false")
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+	withDiscoveryStore(t)
+
+	tests := []struct {
+		name      string
+		budget    models.DISCOVERY_BUDGET
+		statuses  []string
+		exhausted string
+	}{
+		{
+			name:      "repo budget",
+			budget:    models.DISCOVERY_BUDGET{MaxRepos: 1, MaxBytes: 1 << 20},
+			statuses:  []string{DiscoveryHitDeduped, DiscoveryHitScanned, DiscoveryHitBudget},
+			exhausted: "repos",
+		},
+		{
+			// pehla repo poora byte budget le leta hai, doosra download hi nahi hota
+			name:      "byte budget",
+			budget:    models.DISCOVERY_BUDGET{MaxRepos: 10, MaxBytes: 10},
+			statuses:  []string{DiscoveryHitDeduped, DiscoveryHitScanned, DiscoveryHitBudget},
+			exhausted: "bytes",
+		},
+		{
+			name:     "no limit hit",
+			budget:   models.DISCOVERY_BUDGET{MaxRepos: 10, MaxBytes: 1 << 20},
+			statuses: []string{DiscoveryHitDeduped, DiscoveryHitScanned, DiscoveryHitScanned},
+		},
+	}
+	for _, tt := range tests {
+		job := &models.DISCOVERY_JOB{
+			JobID:          tt.name,
+			Query:          models.DISCOVERY_QUERY{ResourceType: "models", Search: "acme", Author: "acme-*"},
+			Budget:         tt.budget,
+			FindingsByType: map[string]int{},
+		}
+		runDiscoveryJob(job, util.FetchOptions{ScanKey: job.JobID, ByteBudget: util.NewByteBudget(tt.budget.MaxBytes)})
+
+		if job.Status != DiscoveryCompleted || job.ReposListed != 3 {
+			t.Fatalf("%s: status=%s listed=%d error=%q, want completed with 3 acme-* repos", tt.name, job.Status, job.ReposListed, job.Error)
+		}
+		var statuses []string
+		for _, hit := range job.Hits {
+			statuses = append(statuses, hit.Status)
+		}
+		if strings.Join(statuses, ",") != strings.Join(tt.statuses, ",") {
+			t.Errorf("%s: statuses = %v, want %v", tt.name, statuses, tt.statuses)
+		}
+		if job.BudgetExhausted != tt.exhausted {
+			t.Errorf("%s: budget_exhausted = %q, want %q", tt.name, job.BudgetExhausted, tt.exhausted)
+		}
+		if job.ReposDeduped != 1 || job.TotalFindings != 1 {
+			t.Errorf("%s: deduped=%d findings=%d, want the cached repo reused with its finding", tt.name, job.ReposDeduped, job.TotalFindings)
+		}
+	}
+}
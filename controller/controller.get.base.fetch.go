@@ -37,7 +37,12 @@ func FetchPRs(c *fiber.Ctx) error {
 		requestID, c.Method(), c.OriginalURL(), resourceType, resourceID, c.IP(), c.Get("User-Agent"),
 	)
 
-	aiRequest, err := discussion.FetchAndSaveDiscussionsByType(resourceType, resourceID, "pr")
+	discussionFilter, err := util.ParseDiscussionQuery(c)
+	if err != nil {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	aiRequest, err := discussion.FetchAndSaveDiscussionsByType(resourceType, resourceID, "pr", discussionFilter)
 	if err != nil {
 		log.Printf(
 			"op=FetchPRs stage=fetch_error request_id=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
@@ -66,7 +71,7 @@ func FetchModel(c *fiber.Ctx) error {
 
 	start := time.Now()
 	requestID := uuid.New().String()
-	url := fmt.Sprintf("https://huggingface.co/api/models/%s", modelID)
+	url := fmt.Sprintf("%s/api/models/%s?blobs=true", util.HFBaseURL(), modelID)
 
 	log.Printf(
 		"op=FetchModel stage=start request_id=%s method=%s path=%s model_id=%s ip=%s user_agent=%q url=%s",
@@ -120,6 +125,10 @@ func FetchModel(c *fiber.Ctx) error {
 
 	// basically url modify kar raha hai for the includeDiscussion
 	includePRs, includeDiscussion := util.ParseIncludeFlags(c)
+	discussionFilter, err := util.ParseDiscussionQuery(c)
+	if err != nil {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
 	log.Printf(
 		"op=FetchModel stage=parse_flags request_id=%s model_id=%s include_prs=%t include_discussion=%t elapsed=%s",
 		requestID, modelID, includePRs, includeDiscussion, time.Since(start),
@@ -138,7 +147,7 @@ func FetchModel(c *fiber.Ctx) error {
 			"op=FetchModel stage=fetch_siblings request_id=%s model_id=%s sibling_candidates=%d",
 			requestID, modelID, len(siblings),
 		)
-		aiRequest.Siblings = util.FetchFilesFromSiblings(modelID, siblings)
+		aiRequest.Siblings = util.FetchFilesFromSiblings("models", modelID, siblings, requestID)
 		log.Printf(
 			"op=FetchModel stage=fetch_siblings_done request_id=%s model_id=%s files=%d",
 			requestID, modelID, len(aiRequest.Siblings),
@@ -150,7 +159,7 @@ func FetchModel(c *fiber.Ctx) error {
 			"op=FetchModel stage=fetch_discussions_start request_id=%s model_id=%s include_prs=%t include_discussion=%t",
 			requestID, modelID, includePRs, includeDiscussion,
 		)
-		discussions, _ := util.FetchDiscussions(modelID, "models", includePRs, includeDiscussion)
+		discussions, _ := util.FetchDiscussions(modelID, "models", includePRs, includeDiscussion, discussionFilter, requestID)
 		aiRequest.Discussions = discussions
 		log.Printf(
 			"op=FetchModel stage=fetch_discussions_done request_id=%s model_id=%s discussions=%d",
@@ -187,7 +196,7 @@ func FetchSpace(c *fiber.Ctx) error {
 
 	start := time.Now()
 	requestID := uuid.New().String()
-	url := fmt.Sprintf("https://huggingface.co/api/spaces/%s", spaceID)
+	url := fmt.Sprintf("%s/api/spaces/%s?blobs=true", util.HFBaseURL(), spaceID)
 
 	log.Printf(
 		"op=FetchSpace stage=start request_id=%s method=%s path=%s space_id=%s ip=%s user_agent=%q url=%s",
@@ -240,6 +249,10 @@ func FetchSpace(c *fiber.Ctx) error {
 	}
 
 	includePRs, includeDiscussion := util.ParseIncludeFlags(c)
+	discussionFilter, err := util.ParseDiscussionQuery(c)
+	if err != nil {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
 	log.Printf(
 		"op=FetchSpace stage=parse_flags request_id=%s space_id=%s include_prs=%t include_discussion=%t elapsed=%s",
 		requestID, spaceID, includePRs, includeDiscussion, time.Since(start),
@@ -259,7 +272,7 @@ func FetchSpace(c *fiber.Ctx) error {
 			"op=FetchSpace stage=fetch_siblings request_id=%s space_id=%s sibling_candidates=%d",
 			requestID, spaceID, len(siblings),
 		)
-		aiRequest.Siblings = util.FetchFilesFromSiblings(spaceID, siblings)
+		aiRequest.Siblings = util.FetchFilesFromSiblings("spaces", spaceID, siblings, requestID)
 		log.Printf(
 			"op=FetchSpace stage=fetch_siblings_done request_id=%s space_id=%s files=%d",
 			requestID, spaceID, len(aiRequest.Siblings),
@@ -271,7 +284,7 @@ func FetchSpace(c *fiber.Ctx) error {
 			"op=FetchSpace stage=fetch_discussions_start request_id=%s space_id=%s include_prs=%t include_discussion=%t",
 			requestID, spaceID, includePRs, includeDiscussion,
 		)
-		discussions, _ := util.FetchDiscussions(spaceID, "spaces", includePRs, includeDiscussion)
+		discussions, _ := util.FetchDiscussions(spaceID, "spaces", includePRs, includeDiscussion, discussionFilter, requestID)
 		aiRequest.Discussions = discussions
 		log.Printf(
 			"op=FetchSpace stage=fetch_discussions_done request_id=%s space_id=%s discussions=%d",
@@ -309,7 +322,7 @@ func FetchDataset(c *fiber.Ctx) error {
 
 	start := time.Now()
 	requestID := uuid.New().String()
-	url := fmt.Sprintf("https://huggingface.co/api/datasets/%s", datasetID)
+	url := fmt.Sprintf("%s/api/datasets/%s?blobs=true", util.HFBaseURL(), datasetID)
 
 	log.Printf(
 		"op=FetchDataset stage=start request_id=%s method=%s path=%s dataset_id=%s ip=%s user_agent=%q url=%s",
@@ -362,6 +375,10 @@ func FetchDataset(c *fiber.Ctx) error {
 	}
 
 	includePRs, includeDiscussion := util.ParseIncludeFlags(c)
+	discussionFilter, err := util.ParseDiscussionQuery(c)
+	if err != nil {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
 	log.Printf(
 		"op=FetchDataset stage=parse_flags request_id=%s dataset_id=%s include_prs=%t include_discussion=%t elapsed=%s",
 		requestID, datasetID, includePRs, includeDiscussion, time.Since(start),
@@ -380,7 +397,7 @@ func FetchDataset(c *fiber.Ctx) error {
 			"op=FetchDataset stage=fetch_siblings request_id=%s dataset_id=%s sibling_candidates=%d",
 			requestID, datasetID, len(siblings),
 		)
-		aiRequest.Siblings = util.FetchFilesFromSiblings(datasetID, siblings)
+		aiRequest.Siblings = util.FetchFilesFromSiblings("datasets", datasetID, siblings, requestID)
 		log.Printf(
 			"op=FetchDataset stage=fetch_siblings_done request_id=%s dataset_id=%s files=%d",
 			requestID, datasetID, len(aiRequest.Siblings),
@@ -392,7 +409,7 @@ func FetchDataset(c *fiber.Ctx) error {
 			"op=FetchDataset stage=fetch_discussions_start request_id=%s dataset_id=%s include_prs=%t include_discussion=%t",
 			requestID, datasetID, includePRs, includeDiscussion,
 		)
-		discussions, _ := util.FetchDiscussions(datasetID, "datasets", includePRs, includeDiscussion)
+		discussions, _ := util.FetchDiscussions(datasetID, "datasets", includePRs, includeDiscussion, discussionFilter, requestID)
 		aiRequest.Discussions = discussions
 		log.Printf(
 			"op=FetchDataset stage=fetch_discussions_done request_id=%s dataset_id=%s discussions=%d",
@@ -440,7 +457,12 @@ func FetchDiscussions(c *fiber.Ctx) error {
 	// this basically fetches them and saves them in db in a way that we know 
 	// if pr is fetched or discussion is fetched
 	// if both, pr and discussion are needed, we call this twice
-	aiRequest, err := discussion.FetchAndSaveDiscussionsByType(resourceType, resourceID, "discussion")
+	discussionFilter, err := util.ParseDiscussionQuery(c)
+	if err != nil {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	aiRequest, err := discussion.FetchAndSaveDiscussionsByType(resourceType, resourceID, "discussion", discussionFilter)
 	if err != nil {
 		log.Printf(
 			"op=FetchDiscussions stage=fetch_error request_id=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
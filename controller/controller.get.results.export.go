@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"bufio"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// export ki settings jo dono endpoints me same hai: format, columns (secret display policy ke saath), finding filter
+type exportRequest struct {
+	format  util.ExportFormat
+	name    string
+	columns []util.ExportColumn
+	filter  util.ExportFilter
+	policy  string
+}
+
+func parseExportRequest(c *fiber.Ctx) (exportRequest, string) {
+	request := exportRequest{name: strings.ToLower(c.Query("format", "csv")), policy: util.SecretDisplayPolicy()}
+
+	format, ok := util.ExportFormats[request.name]
+	if !ok {
+		return request, "Invalid format: use " + strings.Join(util.ExportFormatNames(), ", ")
+	}
+	request.format = format
+
+	columns, err := util.ParseExportColumns(c.Query("columns"), request.policy)
+	if err != nil {
+		return request, err.Error()
+	}
+	request.columns = columns
+
+	filter, err := util.ParseExportFilter(c)
+	if err != nil {
+		return request, err.Error()
+	}
+	request.filter = filter
+	return request, ""
+}
+
+// ek scan ke saare (filter wale) findings, CSV / NDJSON / JUnit me stream
+func ExportScanResult(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	scanID := c.Params("scan_id")
+	log.Printf(
+		"op=ExportScanResult stage=start request_id=%s method=%s path=%s scan_id=%s ip=%s user_agent=%q",
+		requestID, c.Method(), c.OriginalURL(), scanID, c.IP(), c.Get("User-Agent"),
+	)
+
+	objectID, err := primitive.ObjectIDFromHex(scanID)
+	if err != nil {
+		log.Printf(
+			"op=ExportScanResult stage=validation_error request_id=%s scan_id=%s error=%q elapsed=%s",
+			requestID, scanID, "invalid hex", time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, "Invalid scan ID format", nil, "")
+	}
+
+	request, problem := parseExportRequest(c)
+	if problem != "" {
+		log.Printf(
+			"op=ExportScanResult stage=validation_error request_id=%s scan_id=%s error=%q elapsed=%s",
+			requestID, scanID, problem, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, problem, nil, "")
+	}
+
+	scanResult := &models.SCAN_RESULT{}
+	if err := mgm.Coll(scanResult).FindByID(objectID, scanResult); err != nil {
+		log.Printf(
+			"op=ExportScanResult stage=not_found request_id=%s scan_id=%s elapsed=%s",
+			requestID, scanID, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusNotFound, "Scan result not found", nil, "")
+	}
+
+	scans := []models.SCAN_RESULT{*scanResult}
+	return streamExport(c, request, "scan-"+scanID, "ExportScanResult", requestID, start, func() (*models.SCAN_RESULT, error) {
+		if len(scans) == 0 {
+			return nil, nil
+		}
+		scan := scans[0]
+		scans = scans[1:]
+		return &scan, nil
+	}, func() {})
+}
+
+// saare scans (since / until / secret_type / severity / provider / resource / source_type filter ke saath) ke findings;
+// scans cursor se ek ek karke aate hai, poora result kabhi memory me nahi banta
+func ExportResults(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	log.Printf(
+		"op=ExportResults stage=start request_id=%s method=%s path=%s ip=%s user_agent=%q",
+		requestID, c.Method(), c.OriginalURL(), c.IP(), c.Get("User-Agent"),
+	)
+
+	request, problem := parseExportRequest(c)
+	if problem != "" {
+		log.Printf(
+			"op=ExportResults stage=validation_error request_id=%s error=%q elapsed=%s",
+			requestID, problem, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, problem, nil, "")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := mgm.Coll(&models.SCAN_RESULT{}).Find(mgm.Ctx(), request.filter.MongoQuery(), opts)
+	if err != nil {
+		log.Printf(
+			"op=ExportResults stage=db_query_error request_id=%s error=%v elapsed=%s",
+			requestID, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to fetch scan results", nil, "")
+	}
+
+	// cursor har raaste pe band ho: stream writer chale to wahan, na chale (HEAD / error) to streamExport me
+	var closeOnce sync.Once
+	closeCursor := func() { closeOnce.Do(func() { cursor.Close(mgm.Ctx()) }) }
+
+	err = streamExport(c, request, "findings-"+time.Now().UTC().Format("20060102-150405"), "ExportResults", requestID, start, func() (*models.SCAN_RESULT, error) {
+		if !cursor.Next(mgm.Ctx()) {
+			return nil, cursor.Err()
+		}
+		scan := &models.SCAN_RESULT{}
+		if err := cursor.Decode(scan); err != nil {
+			return nil, err
+		}
+		return scan, nil
+	}, closeCursor)
+	if err != nil {
+		closeCursor()
+	}
+	return err
+}
+
+// headers abhi, body stream writer me; status 200 bhej dene ke baad ki errors sirf log hoti hai (body wahi ruk jaati hai)
+func streamExport(c *fiber.Ctx, request exportRequest, fileName, op, requestID string, start time.Time, next func() (*models.SCAN_RESULT, error), done func()) error {
+	c.Set(fiber.HeaderContentType, request.format.ContentType)
+	c.Set(fiber.HeaderContentDisposition, util.AttachmentDisposition(fileName+"."+request.format.Extension))
+	c.Status(fiber.StatusOK)
+
+	// HEAD pe body nahi jaati, stream writer ke bharose done nahi chhodna
+	if c.Method() == fiber.MethodHead {
+		done()
+		return nil
+	}
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer done()
+
+		scans, resources, findings := 0, 0, 0
+		exporter, err := request.format.New(w, request.columns)
+		if err != nil {
+			log.Printf("op=%s stage=stream_error request_id=%s error=%v elapsed=%s", op, requestID, err, time.Since(start))
+			return
+		}
+		for {
+			scan, err := next()
+			if err != nil {
+				log.Printf("op=%s stage=stream_error request_id=%s scans=%d error=%v elapsed=%s", op, requestID, scans, err, time.Since(start))
+				return
+			}
+			if scan == nil {
+				break
+			}
+			scans++
+			for _, resource := range scan.ScannedResources {
+				if !request.filter.MatchResource(resource) {
+					continue
+				}
+				rows := util.ExportRows(*scan, resource, request.filter, request.policy)
+				if err := exporter.WriteResource(*scan, resource, rows); err != nil {
+					log.Printf("op=%s stage=stream_error request_id=%s scans=%d error=%v elapsed=%s", op, requestID, scans, err, time.Since(start))
+					return
+				}
+				resources++
+				findings += len(rows)
+			}
+			// client (ya proxy) tak har scan ke baad data pahunche
+			if err := w.Flush(); err != nil {
+				log.Printf("op=%s stage=client_gone request_id=%s scans=%d error=%v elapsed=%s", op, requestID, scans, err, time.Since(start))
+				return
+			}
+		}
+		if err := exporter.Close(); err != nil {
+			log.Printf("op=%s stage=stream_error request_id=%s error=%v elapsed=%s", op, requestID, err, time.Since(start))
+			return
+		}
+		w.Flush()
+
+		log.Printf(
+			"op=%s stage=success request_id=%s format=%s secret_display=%s scans=%d resources=%d findings=%d elapsed=%s",
+			op, requestID, request.name, request.policy, scans, resources, findings, time.Since(start),
+		)
+	})
+	return nil
+}
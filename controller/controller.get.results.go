@@ -56,22 +56,11 @@ func GetDashboard(c *fiber.Ctx) error {
 	bySourceType := make(map[string]int)
 	resourcesWithIssues := 0
 
-	highRiskPatterns := map[string]bool{
-		"AWS Access Key ID":       true,
-		"GitHub PAT":              true,
-		"OpenAI / LLM API Key":    true,
-		"Stripe Secret Key":       true,
-		"Database URI with creds": true,
-		"PostgreSQL URI":          true,
-		"MySQL URI":               true,
-		"MongoDB URI":             true,
-		"Google API Key":          true,
-		"Kubernetes Bearer Token": true,
-		"GitHub Actions Token":    true,
-	}
-
 	highRiskFindings := []models.Finding{}
 	recentScans := []map[string]interface{}{}
+	// imported findings apni severity ke saath aati hai, isliye count har finding pe
+	mediumRiskCount := 0
+	lowRiskCount := 0
 
 	for _, scan := range scanResults {
 		scanFindings := 0
@@ -85,8 +74,13 @@ func GetDashboard(c *fiber.Ctx) error {
 				totalFindings++
 				bySecretType[finding.SecretType]++
 				bySourceType[finding.SourceType]++
-				if highRiskPatterns[finding.SecretType] {
+				switch util.SeverityOf(finding) {
+				case util.SeverityHigh:
 					highRiskFindings = append(highRiskFindings, finding)
+				case util.SeverityMedium:
+					mediumRiskCount++
+				case util.SeverityLow:
+					lowRiskCount++
 				}
 			}
 		}
@@ -116,17 +110,6 @@ func GetDashboard(c *fiber.Ctx) error {
 		"low":    0,
 	}
 
-	mediumRiskCount := 0
-	lowRiskCount := 0
-	for secretType, count := range bySecretType {
-		if !highRiskPatterns[secretType] {
-			if secretType == "API Key" || secretType == "Access Token" {
-				mediumRiskCount += count
-			} else {
-				lowRiskCount += count
-			}
-		}
-	}
 	severityBreakdown["medium"] = mediumRiskCount
 	severityBreakdown["low"] = lowRiskCount
 	dashboardData["severity_breakdown"] = severityBreakdown
@@ -247,6 +230,46 @@ func GetScanResult(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, fiber.StatusNotFound, "Scan result not found", nil, "")
 	}
 
+	// SARIF me raw SARIF log chahiye, ResponseAPI wala wrapper nahi (uploaders use seedha parse karte hai)
+	switch format := c.Query("format"); format {
+	case "", "json":
+	case "sarif":
+		findings := []models.Finding{}
+		for _, resource := range scanResult.ScannedResources {
+			for _, finding := range resource.Findings {
+				if finding.Provider == "" {
+					finding.Provider = resource.Provider
+				}
+				if finding.ResourceType == "" {
+					finding.ResourceType = resource.Type
+				}
+				if finding.ResourceID == "" {
+					finding.ResourceID = resource.ID
+				}
+				findings = append(findings, finding)
+			}
+		}
+		sarifLog := util.BuildSARIF(findings, util.ScanRuleSet(*scanResult).Patterns, "scan/"+scanResult.ID.Hex()+"/", util.SecretDisplayPolicy())
+
+		log.Printf(
+			"op=GetScanResult stage=success request_id=%s scan_id=%s format=sarif resources=%d total_findings=%d rules=%d elapsed=%s",
+			requestID, scanResult.ID.Hex(), len(scanResult.ScannedResources), len(findings), len(sarifLog.Runs[0].Tool.Driver.Rules), time.Since(start),
+		)
+
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="scan-`+scanResult.ID.Hex()+`.sarif"`)
+		if err := c.Status(fiber.StatusOK).JSON(sarifLog); err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "application/sarif+json")
+		return nil
+	default:
+		log.Printf(
+			"op=GetScanResult stage=validation_error request_id=%s scan_id=%s error=%q elapsed=%s",
+			requestID, scanID, "unsupported format "+format, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, "Invalid format: use json or sarif", nil, "")
+	}
+
 	totalFindings := 0
 	findingsByType := make(map[string]int)
 	findingsByResource := make(map[string]int)
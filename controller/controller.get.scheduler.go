@@ -0,0 +1,11 @@
+package controller
+
+import (
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// outbound HF scheduler ka haal: budget, in-flight, aur har scan key ke retries / throttles
+func GetSchedulerStats(c *fiber.Ctx) error {
+	return util.ResponseAPI(c, fiber.StatusOK, "Scheduler stats retrieved successfully", util.SharedScheduler().Stats(), "")
+}
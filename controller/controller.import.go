@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/kamva/mgm/v3"
+)
+
+// response me itni hi rejected rows, baaki sirf ginti me
+const importRejectedLimit = 100
+
+// gitleaks / trufflehog / SARIF report body me; findings ek naye SCAN_RESULT (status "imported") me save,
+// jo rows map nahi hui wo response me wapas
+func ImportScanResult(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	format := strings.ToLower(c.Query("format"))
+	opts := util.ImportOptions{
+		Provider:     strings.TrimSpace(c.Query("provider")),
+		ResourceType: strings.TrimSpace(c.Query("resource_type")),
+		ResourceID:   strings.TrimSpace(c.Query("resource_id")),
+	}
+	log.Printf(
+		"op=ImportScanResult stage=start request_id=%s method=%s path=%s format=%s provider=%s resource_type=%s resource_id=%s bytes=%d ip=%s",
+		requestID, c.Method(), c.OriginalURL(), format, opts.Provider, opts.ResourceType, opts.ResourceID, len(c.Body()), c.IP(),
+	)
+
+	// resource dena ho to type aur id dono, warna kis resource ki finding hai pata nahi chalega
+	if (opts.ResourceType == "") != (opts.ResourceID == "") {
+		log.Printf(
+			"op=ImportScanResult stage=validation_error request_id=%s error=%q elapsed=%s",
+			requestID, "resource_type without resource_id", time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, "resource_type and resource_id must be given together", nil, "")
+	}
+
+	imported, err := util.ImportFindings(format, c.Body(), opts)
+	if err != nil {
+		log.Printf(
+			"op=ImportScanResult stage=validation_error request_id=%s format=%s error=%q elapsed=%s",
+			requestID, format, err.Error(), time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	rejected := imported.Rejected
+	if rejected == nil {
+		rejected = []util.ImportRejected{}
+	}
+	if len(rejected) > importRejectedLimit {
+		rejected = rejected[:importRejectedLimit]
+	}
+	response := map[string]interface{}{
+		"format":         format,
+		"tool":           imported.Tool,
+		"total":          imported.Total,
+		"imported":       len(imported.Findings),
+		"rejected_count": len(imported.Rejected),
+		"rejected":       rejected,
+	}
+
+	// report me rows thi par ek bhi map nahi hui: kuch save nahi karna
+	if imported.Total > 0 && len(imported.Findings) == 0 {
+		log.Printf(
+			"op=ImportScanResult stage=all_rejected request_id=%s format=%s total=%d elapsed=%s",
+			requestID, format, imported.Total, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusUnprocessableEntity, "No rows could be imported", response, "")
+	}
+
+	scanResult := &models.SCAN_RESULT{
+		RequestID:        fmt.Sprintf("IM-%s-%s", time.Now().Format("2006-0102"), uuid.New().String()[:8]),
+		ScannedResources: util.GroupFindingsByResourceID(imported.Findings),
+		Status:           "imported",
+	}
+	// clean report bhi save hoti hai taaki resource dashboard pe "no secrets" dikhe
+	if len(scanResult.ScannedResources) == 0 && opts.ResourceID != "" {
+		provider := opts.Provider
+		if provider == "" {
+			provider = util.ProviderImport
+		}
+		scanResult.ScannedResources = []models.SCANNED_RESOURCE{{
+			Provider: provider,
+			Type:     opts.ResourceType,
+			ID:       opts.ResourceID,
+			Findings: []models.Finding{},
+		}}
+	}
+	if err := mgm.Coll(scanResult).Create(scanResult); err != nil {
+		log.Printf(
+			"op=ImportScanResult stage=save_error request_id=%s error=%v elapsed=%s",
+			requestID, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to store imported results", nil, "")
+	}
+
+	response["scan_id"] = scanResult.ID.Hex()
+	response["request_id"] = scanResult.RequestID
+	response["resources"] = len(scanResult.ScannedResources)
+
+	log.Printf(
+		"op=ImportScanResult stage=success request_id=%s scan_id=%s format=%s tool=%q total=%d imported=%d rejected=%d elapsed=%s",
+		requestID, scanResult.ID.Hex(), format, imported.Tool, imported.Total, len(imported.Findings), len(imported.Rejected), time.Since(start),
+	)
+
+	return util.ResponseAPI(c, fiber.StatusOK, "Scan results imported successfully", response, "")
+}
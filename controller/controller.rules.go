@@ -0,0 +1,339 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// create / update ki body; enabled na diya to true
+type ruleBody struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Regex       string                  `json:"regex"`
+	Keywords    []string                `json:"keywords"`
+	Severity    string                  `json:"severity"`
+	Tags        []string                `json:"tags"`
+	References  []string                `json:"references"`
+	Enabled     *bool                   `json:"enabled"`
+	SecretGroup int                     `json:"secret_group"`
+	Entropy     float64                 `json:"entropy"`
+	Path        string                  `json:"path"`
+	Allowlists  []models.RULE_ALLOWLIST `json:"allowlists"`
+	Tests       models.RULE_TESTS       `json:"tests"`
+}
+
+// body -> RULE; API se aaye har rule ke saath kam se kam ek match test chahiye
+func parseRuleBody(c *fiber.Ctx) (models.RULE, string) {
+	var body ruleBody
+	if err := c.BodyParser(&body); err != nil {
+		return models.RULE{}, "Invalid request body"
+	}
+	rule := models.RULE{
+		ID:          util.SARIFRuleID(body.Name),
+		Name:        strings.TrimSpace(body.Name),
+		Description: strings.TrimSpace(body.Description),
+		Regex:       body.Regex,
+		Keywords:    trimmedValues(body.Keywords),
+		Severity:    strings.ToLower(strings.TrimSpace(body.Severity)),
+		Tags:        trimmedValues(body.Tags),
+		References:  trimmedValues(body.References),
+		Enabled:     body.Enabled == nil || *body.Enabled,
+		SecretGroup: body.SecretGroup,
+		Entropy:     body.Entropy,
+		Path:        body.Path,
+		Allowlists:  body.Allowlists,
+		Tests:       body.Tests,
+	}
+	if len(rule.Tests.Match) == 0 {
+		return rule, "tests.match needs at least one sample the rule must find"
+	}
+	if err := util.ValidateRule(rule); err != nil {
+		return rule, err.Error()
+	}
+	return rule, ""
+}
+
+func trimmedValues(values []string) []string {
+	var out []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// chalu rule set: revision, version aur saare rules (disabled bhi)
+func GetRules(c *fiber.Ctx) error {
+	ruleSet := util.ActiveRuleSet()
+	return util.ResponseAPI(c, fiber.StatusOK, "Rules retrieved successfully", map[string]interface{}{
+		"revision": ruleSet.Revision,
+		"version":  ruleSet.Version,
+		"enabled":  len(ruleSet.Patterns),
+		"rules":    ruleSet.Rules,
+	}, "")
+}
+
+func GetRule(c *fiber.Ctx) error {
+	rule, ok := util.ActiveRuleSet().Rule(c.Params("id"))
+	if !ok {
+		return util.ResponseAPI(c, fiber.StatusNotFound, "Rule not found", nil, "")
+	}
+	return util.ResponseAPI(c, fiber.StatusOK, "Rule retrieved successfully", map[string]interface{}{"rule": rule}, "")
+}
+
+func CreateRule(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	rule, problem := parseRuleBody(c)
+	if problem != "" {
+		log.Printf(
+			"op=CreateRule stage=validation_error request_id=%s rule_id=%s error=%q elapsed=%s",
+			requestID, rule.ID, problem, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, problem, nil, "")
+	}
+
+	saved, err := util.SaveRuleSet("create "+rule.ID, func(rules []models.RULE) ([]models.RULE, error) {
+		for _, existing := range rules {
+			if existing.ID == rule.ID {
+				return nil, util.ErrRuleExists
+			}
+		}
+		return append(rules, rule), nil
+	})
+	return ruleSetSaved(c, "CreateRule", requestID, rule.ID, start, saved, err, fiber.StatusCreated, "Rule created successfully")
+}
+
+// poora rule replace; name badla to id bhi badal jaata hai
+func UpdateRule(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+	ruleID := c.Params("id")
+
+	rule, problem := parseRuleBody(c)
+	if problem != "" {
+		log.Printf(
+			"op=UpdateRule stage=validation_error request_id=%s rule_id=%s error=%q elapsed=%s",
+			requestID, ruleID, problem, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, problem, nil, "")
+	}
+
+	saved, err := util.SaveRuleSet("update "+ruleID, func(rules []models.RULE) ([]models.RULE, error) {
+		for i, existing := range rules {
+			if existing.ID == ruleID {
+				rules[i] = rule
+				return rules, nil
+			}
+		}
+		return nil, util.ErrRuleNotFound
+	})
+	return ruleSetSaved(c, "UpdateRule", requestID, rule.ID, start, saved, err, fiber.StatusOK, "Rule updated successfully")
+}
+
+func DeleteRule(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+	ruleID := c.Params("id")
+
+	saved, err := util.SaveRuleSet("delete "+ruleID, func(rules []models.RULE) ([]models.RULE, error) {
+		for i, existing := range rules {
+			if existing.ID == ruleID {
+				return append(rules[:i], rules[i+1:]...), nil
+			}
+		}
+		return nil, util.ErrRuleNotFound
+	})
+	return ruleSetSaved(c, "DeleteRule", requestID, ruleID, start, saved, err, fiber.StatusOK, "Rule deleted successfully")
+}
+
+// gitleaks-style TOML / YAML rules file body me; same id wale rules replace, baaki jud jaate hai, sab ek nayi revision me
+func ImportRules(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	format := strings.ToLower(c.Query("format"))
+	log.Printf(
+		"op=ImportRules stage=start request_id=%s method=%s path=%s format=%s bytes=%d ip=%s",
+		requestID, c.Method(), c.OriginalURL(), format, len(c.Body()), c.IP(),
+	)
+
+	rules, warnings, err := util.ParseGitleaksRules(c.Body(), format, "import")
+	if err == nil && len(rules) == 0 {
+		err = errors.New("rules file has no importable rules")
+	}
+	if err != nil {
+		log.Printf(
+			"op=ImportRules stage=validation_error request_id=%s format=%s error=%q elapsed=%s",
+			requestID, format, err.Error(), time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), map[string]interface{}{"warnings": warnings}, "")
+	}
+
+	saved, err := util.SaveRuleSet("import "+strconv.Itoa(len(rules))+" rules", func(existing []models.RULE) ([]models.RULE, error) {
+		return util.MergeRules(existing, rules), nil
+	})
+	if err != nil {
+		return ruleSetSaved(c, "ImportRules", requestID, "", start, saved, err, fiber.StatusOK, "")
+	}
+
+	log.Printf(
+		"op=ImportRules stage=success request_id=%s revision=%d version=%s imported=%d warnings=%d elapsed=%s",
+		requestID, saved.Revision, saved.Version, len(rules), len(warnings), time.Since(start),
+	)
+	ids := []string{}
+	for _, rule := range rules {
+		ids = append(ids, rule.ID)
+	}
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return util.ResponseAPI(c, fiber.StatusOK, "Rules imported successfully", map[string]interface{}{
+		"revision": saved.Revision,
+		"version":  saved.Version,
+		"change":   saved.Change,
+		"imported": len(rules),
+		"rule_ids": ids,
+		"warnings": warnings,
+	}, "")
+}
+
+// saari revisions, nayi pehle; rules ke bina (ek revision ke rules /rules/versions/:revision pe)
+func GetRuleSetVersions(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	ruleSets := []models.RULE_SET{}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "revision", Value: -1}}).
+		SetProjection(bson.M{"rules": 0})
+	cursor, err := mgm.Coll(&models.RULE_SET{}).Find(mgm.Ctx(), bson.M{}, opts)
+	if err == nil {
+		err = cursor.All(mgm.Ctx(), &ruleSets)
+	}
+	if err != nil {
+		log.Printf(
+			"op=GetRuleSetVersions stage=db_query_error request_id=%s error=%v elapsed=%s",
+			requestID, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to fetch rule set versions", nil, "")
+	}
+
+	versions := []map[string]interface{}{}
+	for _, ruleSet := range ruleSets {
+		versions = append(versions, map[string]interface{}{
+			"revision":   ruleSet.Revision,
+			"version":    ruleSet.Version,
+			"change":     ruleSet.Change,
+			"created_at": ruleSet.CreatedAt,
+		})
+	}
+	active := util.ActiveRuleSet()
+	return util.ResponseAPI(c, fiber.StatusOK, "Rule set versions retrieved successfully", map[string]interface{}{
+		"active_revision": active.Revision,
+		"active_version":  active.Version,
+		"versions":        versions,
+	}, "")
+}
+
+// revision 0 built-in rules hai
+func GetRuleSetVersion(c *fiber.Ctx) error {
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision < 0 {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, "Invalid revision", nil, "")
+	}
+	if revision == 0 {
+		builtin := util.BuiltinRuleSet()
+		return util.ResponseAPI(c, fiber.StatusOK, "Rule set retrieved successfully", map[string]interface{}{
+			"revision": builtin.Revision,
+			"version":  builtin.Version,
+			"change":   "built-in rules",
+			"rules":    builtin.Rules,
+		}, "")
+	}
+	ruleSet, err := util.FindRuleSet(revision)
+	if err != nil {
+		return util.ResponseAPI(c, fiber.StatusNotFound, "Rule set revision not found", nil, "")
+	}
+	return util.ResponseAPI(c, fiber.StatusOK, "Rule set retrieved successfully", map[string]interface{}{
+		"revision":   ruleSet.Revision,
+		"version":    ruleSet.Version,
+		"change":     ruleSet.Change,
+		"created_at": ruleSet.CreatedAt,
+		"rules":      ruleSet.Rules,
+	}, "")
+}
+
+// purani revision ke rules ek nayi revision ban ke wapas active; history kabhi overwrite nahi hoti
+func RestoreRuleSetVersion(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision < 0 {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, "Invalid revision", nil, "")
+	}
+	rules := util.BuiltinRules()
+	if revision > 0 {
+		ruleSet, err := util.FindRuleSet(revision)
+		if err != nil {
+			return util.ResponseAPI(c, fiber.StatusNotFound, "Rule set revision not found", nil, "")
+		}
+		rules = ruleSet.Rules
+	}
+
+	saved, err := util.SaveRuleSet("restore revision "+strconv.Itoa(revision), func([]models.RULE) ([]models.RULE, error) {
+		return rules, nil
+	})
+	return ruleSetSaved(c, "RestoreRuleSetVersion", requestID, "", start, saved, err, fiber.StatusOK, "Rule set restored successfully")
+}
+
+// SaveRuleSet ki error ko status me badalta hai; save hua to nayi revision response me
+func ruleSetSaved(c *fiber.Ctx, op, requestID, ruleID string, start time.Time, saved *models.RULE_SET, err error, status int, message string) error {
+	if err != nil {
+		log.Printf(
+			"op=%s stage=save_error request_id=%s rule_id=%s error=%v elapsed=%s",
+			op, requestID, ruleID, err, time.Since(start),
+		)
+		switch {
+		case errors.Is(err, util.ErrRuleNotFound):
+			return util.ResponseAPI(c, fiber.StatusNotFound, "Rule not found", nil, "")
+		case errors.Is(err, util.ErrRuleExists):
+			return util.ResponseAPI(c, fiber.StatusConflict, "Rule already exists", nil, "")
+		case errors.Is(err, util.ErrInvalidRuleSet):
+			return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+		}
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to save rule set", nil, "")
+	}
+
+	log.Printf(
+		"op=%s stage=success request_id=%s rule_id=%s revision=%d version=%s elapsed=%s",
+		op, requestID, ruleID, saved.Revision, saved.Version, time.Since(start),
+	)
+	response := map[string]interface{}{
+		"revision": saved.Revision,
+		"version":  saved.Version,
+		"change":   saved.Change,
+	}
+	if ruleID != "" {
+		for _, rule := range saved.Rules {
+			if rule.ID == ruleID {
+				response["rule"] = rule
+			}
+		}
+	}
+	return util.ResponseAPI(c, status, message, response, "")
+}
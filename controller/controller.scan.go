@@ -66,7 +66,10 @@ func ScanRequest(c *fiber.Ctx) error {
 		requestID, reqID, resourceType, resourceID,
 	)
 
-	findings := util.ScanAIRequest(*aiRequest, util.SecretConfig, resourceType, resourceID)
+	ruleSet := util.ActiveRuleSet()
+	// stored request me cached files ka content nahi hota, unka outcome cache se (na mile to skipped)
+	util.ResolveCachedSiblings(aiRequest)
+	findings := util.ScanAIRequest(*aiRequest, ruleSet.Patterns, resourceType, resourceID)
 
 	log.Printf(
 		"op=ScanRequest stage=scanning_done request_id=%s req_id=%s findings=%d elapsed=%s",
@@ -78,7 +81,11 @@ func ScanRequest(c *fiber.Ctx) error {
 	scanResult := &models.SCAN_RESULT{
 		RequestID:        reqID,
 		ScannedResources: scannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         util.CoverageFromRequest(*aiRequest),
 	}
+	scanResult.Status = scanResult.Coverage.Status
 
 	if err := mgm.Coll(scanResult).Create(scanResult); err != nil {
 		log.Printf(
@@ -103,6 +110,9 @@ func ScanRequest(c *fiber.Ctx) error {
 		"findings_by_type":   findingsByType,
 		"findings_by_source": findingsBySource,
 		"scanned_resources":  scannedResources,
+		"blob_cache":         util.CountBlobCache(aiRequest.Siblings),
+		"coverage":           scanResult.Coverage,
+		"status":             scanResult.Status,
 	}, "")
 }
 
@@ -124,7 +134,7 @@ func ScanOrgModels(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, fiber.StatusBadRequest, "Organization name is required", nil, "")
 	}
 
-	allFindings, scannedCount, err := util.ScanOrgResources(org, util.ResourceTypeModel)
+	allFindings, report, err := util.ScanOrgResources(org, util.ResourceTypeModel)
 	if err != nil {
 		statusCode := fiber.StatusInternalServerError
 		if err.Error() == fmt.Sprintf("no %s found for this organization", util.ResourceTypeModel) {
@@ -137,8 +147,8 @@ func ScanOrgModels(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, statusCode, err.Error(), nil, "")
 	}
 
-	scannedResources := util.GroupFindingsByResource(allFindings)
-	scanResult, err := util.SaveScanResults("org-scan-"+org+"-models", scannedResources)
+	scannedResources := util.GroupFindingsByResourceID(allFindings)
+	scanResult, err := util.SaveScanResults("org-scan-"+org+"-models", scannedResources, report.Coverage, report.RuleSet)
 	if err != nil {
 		log.Printf(
 			"op=ScanOrgModels stage=db_create_error request_id=%s org=%s error=%v elapsed=%s",
@@ -148,16 +158,19 @@ func ScanOrgModels(c *fiber.Ctx) error {
 	}
 
 	log.Printf(
-		"op=ScanOrgModels stage=success request_id=%s org=%s scan_id=%s models_scanned=%d findings=%d resources=%d elapsed=%s",
-		requestID, org, scanResult.ID.Hex(), scannedCount, len(allFindings), len(scannedResources), time.Since(start),
+		"op=ScanOrgModels stage=success request_id=%s org=%s scan_id=%s models_scanned=%d findings=%d resources=%d stale=%d missing=%d failed=%d elapsed=%s",
+		requestID, org, scanResult.ID.Hex(), len(report.Resources), len(allFindings), len(scannedResources), len(report.Stale), len(report.Missing), len(report.Failed), time.Since(start),
 	)
 
 	return util.ResponseAPI(c, fiber.StatusOK, "Organization models scanned successfully", map[string]interface{}{
 		"scan_id":           scanResult.ID.Hex(),
 		"organization":      org,
-		"models_scanned":    scannedCount,
+		"models_scanned":    len(report.Resources),
 		"total_findings":    len(allFindings),
 		"scanned_resources": scannedResources,
+		"resources":         report,
+		"coverage":          report.Coverage,
+		"status":            scanResult.Status,
 	}, "")
 }
 
@@ -179,7 +192,7 @@ func ScanOrgDatasets(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, fiber.StatusBadRequest, "Organization name is required", nil, "")
 	}
 
-	allFindings, scannedCount, err := util.ScanOrgResources(org, util.ResourceTypeDataset)
+	allFindings, report, err := util.ScanOrgResources(org, util.ResourceTypeDataset)
 	if err != nil {
 		statusCode := fiber.StatusInternalServerError
 		if err.Error() == fmt.Sprintf("no %s found for this organization", util.ResourceTypeDataset) {
@@ -192,8 +205,8 @@ func ScanOrgDatasets(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, statusCode, err.Error(), nil, "")
 	}
 
-	scannedResources := util.GroupFindingsByResource(allFindings)
-	scanResult, err := util.SaveScanResults("org-scan-"+org+"-datasets", scannedResources)
+	scannedResources := util.GroupFindingsByResourceID(allFindings)
+	scanResult, err := util.SaveScanResults("org-scan-"+org+"-datasets", scannedResources, report.Coverage, report.RuleSet)
 	if err != nil {
 		log.Printf(
 			"op=ScanOrgDatasets stage=db_create_error request_id=%s org=%s error=%v elapsed=%s",
@@ -203,16 +216,19 @@ func ScanOrgDatasets(c *fiber.Ctx) error {
 	}
 
 	log.Printf(
-		"op=ScanOrgDatasets stage=success request_id=%s org=%s scan_id=%s datasets_scanned=%d findings=%d resources=%d elapsed=%s",
-		requestID, org, scanResult.ID.Hex(), scannedCount, len(allFindings), len(scannedResources), time.Since(start),
+		"op=ScanOrgDatasets stage=success request_id=%s org=%s scan_id=%s datasets_scanned=%d findings=%d resources=%d stale=%d missing=%d failed=%d elapsed=%s",
+		requestID, org, scanResult.ID.Hex(), len(report.Resources), len(allFindings), len(scannedResources), len(report.Stale), len(report.Missing), len(report.Failed), time.Since(start),
 	)
 
 	return util.ResponseAPI(c, fiber.StatusOK, "Organization datasets scanned successfully", map[string]interface{}{
 		"scan_id":           scanResult.ID.Hex(),
 		"organization":      org,
-		"datasets_scanned":  scannedCount,
+		"datasets_scanned":  len(report.Resources),
 		"total_findings":    len(allFindings),
 		"scanned_resources": scannedResources,
+		"resources":         report,
+		"coverage":          report.Coverage,
+		"status":            scanResult.Status,
 	}, "")
 }
 
@@ -234,7 +250,7 @@ func ScanOrgSpaces(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, fiber.StatusBadRequest, "Organization name is required", nil, "")
 	}
 
-	allFindings, scannedCount, err := util.ScanOrgResources(org, util.ResourceTypeSpace)
+	allFindings, report, err := util.ScanOrgResources(org, util.ResourceTypeSpace)
 	if err != nil {
 		statusCode := fiber.StatusInternalServerError
 		if err.Error() == fmt.Sprintf("no %s found for this organization", util.ResourceTypeSpace) {
@@ -247,8 +263,8 @@ func ScanOrgSpaces(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, statusCode, err.Error(), nil, "")
 	}
 
-	scannedResources := util.GroupFindingsByResource(allFindings)
-	scanResult, err := util.SaveScanResults("org-scan-"+org+"-spaces", scannedResources)
+	scannedResources := util.GroupFindingsByResourceID(allFindings)
+	scanResult, err := util.SaveScanResults("org-scan-"+org+"-spaces", scannedResources, report.Coverage, report.RuleSet)
 	if err != nil {
 		log.Printf(
 			"op=ScanOrgSpaces stage=db_create_error request_id=%s org=%s error=%v elapsed=%s",
@@ -258,16 +274,19 @@ func ScanOrgSpaces(c *fiber.Ctx) error {
 	}
 
 	log.Printf(
-		"op=ScanOrgSpaces stage=success request_id=%s org=%s scan_id=%s spaces_scanned=%d findings=%d resources=%d elapsed=%s",
-		requestID, org, scanResult.ID.Hex(), scannedCount, len(allFindings), len(scannedResources), time.Since(start),
+		"op=ScanOrgSpaces stage=success request_id=%s org=%s scan_id=%s spaces_scanned=%d findings=%d resources=%d stale=%d missing=%d failed=%d elapsed=%s",
+		requestID, org, scanResult.ID.Hex(), len(report.Resources), len(allFindings), len(scannedResources), len(report.Stale), len(report.Missing), len(report.Failed), time.Since(start),
 	)
 
 	return util.ResponseAPI(c, fiber.StatusOK, "Organization spaces scanned successfully", map[string]interface{}{
 		"scan_id":           scanResult.ID.Hex(),
 		"organization":      org,
-		"spaces_scanned":    scannedCount,
+		"spaces_scanned":    len(report.Resources),
 		"total_findings":    len(allFindings),
 		"scanned_resources": scannedResources,
+		"resources":         report,
+		"coverage":          report.Coverage,
+		"status":            scanResult.Status,
 	}, "")
 }
 
@@ -322,13 +341,20 @@ func ScanByID(c *fiber.Ctx) error {
 		requestID, id, resourceType, resourceID,
 	)
 
-	findings := util.ScanAIRequest(*aiRequest, util.SecretConfig, resourceType, resourceID)
+	ruleSet := util.ActiveRuleSet()
+	// stored request me cached files ka content nahi hota, unka outcome cache se (na mile to skipped)
+	util.ResolveCachedSiblings(aiRequest)
+	findings := util.ScanAIRequest(*aiRequest, ruleSet.Patterns, resourceType, resourceID)
 	scannedResources := util.GroupFindingsByResource(findings)
 
 	scanResult := &models.SCAN_RESULT{
 		RequestID:        aiRequest.RequestID,
 		ScannedResources: scannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         util.CoverageFromRequest(*aiRequest),
 	}
+	scanResult.Status = scanResult.Coverage.Status
 	if err := mgm.Coll(scanResult).Create(scanResult); err != nil {
 		log.Printf(
 			"op=ScanByID stage=db_create_error request_id=%s id=%s error=%v elapsed=%s",
@@ -347,5 +373,8 @@ func ScanByID(c *fiber.Ctx) error {
 		"request_id":        aiRequest.RequestID,
 		"total_findings":    len(findings),
 		"scanned_resources": scannedResources,
+		"blob_cache":        util.CountBlobCache(aiRequest.Siblings),
+		"coverage":          scanResult.Coverage,
+		"status":            scanResult.Status,
 	}, "")
 }
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/kamva/mgm/v3"
+)
+
+type hubCacheScanBody struct {
+	ResourceType string `json:"resource_type"`
+	RepoID       string `json:"repo_id"`
+	Revision     string `json:"revision"`
+}
+
+// server ki local HF hub cache (HF_HUB_CACHE / HF_HOME / ~/.cache/huggingface/hub) scan karta hai, bina network ke.
+// repo_id diya ho to sirf wo repo, warna cache ke saare repos; cache dir sirf env se aati hai, request se nahi
+func ScanHubCache(c *fiber.Ctx) error {
+	start := time.Now()
+	traceID := uuid.New().String()
+
+	var body hubCacheScanBody
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			log.Printf(
+				"op=ScanHubCache stage=body_parse_error trace_id=%s error=%v elapsed=%s",
+				traceID, err, time.Since(start),
+			)
+			return util.ResponseAPI(c, fiber.StatusBadRequest, "Invalid request body", nil, "")
+		}
+	}
+	if body.ResourceType == "" {
+		body.ResourceType = "models"
+	}
+
+	root := util.HubCacheDir()
+	log.Printf(
+		"op=ScanHubCache stage=start trace_id=%s cache_dir=%s resource_type=%s repo_id=%s revision=%s",
+		traceID, root, body.ResourceType, body.RepoID, body.Revision,
+	)
+
+	var repos []util.HubCacheRepo
+	if body.RepoID != "" {
+		repo, err := util.FindHubCacheRepo(root, body.ResourceType, body.RepoID)
+		if err != nil {
+			return util.ResponseAPI(c, fiber.StatusNotFound, err.Error(), nil, "")
+		}
+		repos = append(repos, repo)
+	} else {
+		all, err := util.ListHubCacheRepos(root)
+		if err != nil {
+			log.Printf(
+				"op=ScanHubCache stage=list_error trace_id=%s cache_dir=%s error=%v elapsed=%s",
+				traceID, root, err, time.Since(start),
+			)
+			return util.ResponseAPI(c, fiber.StatusNotFound, fmt.Sprintf("Hub cache not readable: %v", err), nil, "")
+		}
+		repos = all
+	}
+
+	scanID := fmt.Sprintf("SG-%s-%s", time.Now().Format("2006-0102"), uuid.New().String()[:8])
+	allScannedResources := []models.SCANNED_RESOURCE{}
+	formattedResources := []map[string]interface{}{}
+	coverage := util.NewScanCoverage()
+	totalFindings := 0
+	ruleSet := util.ActiveRuleSet()
+
+	for _, repo := range repos {
+		aiRequest, err := util.LoadHubCacheRequest(repo, body.Revision)
+		if err != nil {
+			log.Printf(
+				"op=ScanHubCache stage=load_error trace_id=%s resource_type=%s resource_id=%s error=%v",
+				traceID, repo.ResourceType, repo.ResourceID, err,
+			)
+			util.AddFailedResource(coverage, repo.ResourceType+"/"+repo.ResourceID, err)
+			continue
+		}
+		if err := mgm.Coll(aiRequest).Create(aiRequest); err != nil {
+			log.Printf(
+				"op=ScanHubCache stage=save_request_error trace_id=%s resource_id=%s error=%v",
+				traceID, repo.ResourceID, err,
+			)
+		}
+
+		findings := util.ScanAIRequest(*aiRequest, ruleSet.Patterns, repo.ResourceType, repo.ResourceID)
+		findings = util.PinFindingsToRevision(findings, aiRequest.CommitSHA)
+		if findings == nil {
+			findings = []models.Finding{}
+		}
+		totalFindings += len(findings)
+		util.MergeCoverage(coverage, util.CoverageFromRequest(*aiRequest), repo.ResourceType+"/"+repo.ResourceID)
+
+		allScannedResources = append(allScannedResources, models.SCANNED_RESOURCE{
+			Type:     repo.ResourceType,
+			ID:       repo.ResourceID,
+			Findings: findings,
+			ScanMode: string(util.RescanFull),
+		})
+		formattedResources = append(formattedResources, map[string]interface{}{
+			"type":       repo.ResourceType,
+			"id":         repo.ResourceID,
+			"commit_sha": aiRequest.CommitSHA,
+			"findings":   util.FormatFindings(findings),
+		})
+	}
+
+	scanResult := &models.SCAN_RESULT{
+		RequestID:        "hub-cache-" + scanID,
+		ScannedResources: allScannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         coverage,
+		Status:           coverage.Status,
+	}
+	if err := mgm.Coll(scanResult).Create(scanResult); err != nil {
+		log.Printf(
+			"op=ScanHubCache stage=db_create_error trace_id=%s error=%v elapsed=%s",
+			traceID, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to save scan results", nil, "")
+	}
+
+	log.Printf(
+		"op=ScanHubCache stage=success trace_id=%s scan_id=%s repos=%d scanned=%d total_findings=%d storage_id=%s elapsed=%s",
+		traceID, scanID, len(repos), len(allScannedResources), totalFindings, scanResult.ID.Hex(), time.Since(start),
+	)
+
+	return util.ResponseAPI(c, fiber.StatusOK, "Hub cache scan completed successfully", map[string]interface{}{
+		"scan_id":           scanID,
+		"cache_dir":         root,
+		"scanned_resources": formattedResources,
+		"timestamp":         time.Now().Format(time.RFC3339),
+		"total_findings":    totalFindings,
+		"storage_id":        scanResult.ID.Hex(),
+		"coverage":          coverage,
+		"status":            coverage.Status,
+	}, "")
+}
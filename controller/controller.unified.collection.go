@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// collection ke har repo item (model / dataset / space) ko normal pipeline se scan karta hai,
+// papers ka repo nahi hota to unhe skip karke response me bata dete hai
+func scanCollection(c *fiber.Ctx, slug string, opts util.FetchOptions, scanID string) error {
+	start := time.Now()
+	traceID := uuid.New().String()
+
+	log.Printf(
+		"op=scanCollection stage=start trace_id=%s collection=%s include_prs=%t include_discussions=%t",
+		traceID, slug, opts.IncludePRs, opts.IncludeDiscussions,
+	)
+
+	title, items, err := util.FetchCollectionItems(slug)
+	if err != nil {
+		log.Printf(
+			"op=scanCollection stage=fetch_error trace_id=%s collection=%s error=%v elapsed=%s",
+			traceID, slug, err, time.Since(start),
+		)
+		// galat slug HF pe 404 hai, baaki (5xx, network) hamari taraf se fetch fail
+		if util.StatusFromError(err) == http.StatusNotFound {
+			return util.ResponseAPI(c, fiber.StatusNotFound, "Collection not found", nil, "")
+		}
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to fetch collection", nil, "")
+	}
+
+	// har item ka apna slot, taaki result me order wahi rahe jo collection me hai
+	scannedResources := make([]*models.SCANNED_RESOURCE, len(items))
+	skippedItems := []util.CollectionItem{}
+	failedItems := map[string]string{}
+	var totalFindings int
+	var blobCache util.BlobCacheStats
+	coverage := util.NewScanCoverage()
+	var mu sync.Mutex
+	ruleSet := util.ActiveRuleSet()
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10)
+
+	for i, item := range items {
+		resourceType, ok := item.ResourceType()
+		if !ok || item.ID == "" {
+			log.Printf(
+				"op=scanCollection stage=skip_item trace_id=%s collection=%s index=%d type=%s id=%s",
+				traceID, slug, i, item.Type, item.ID,
+			)
+			skippedItems = append(skippedItems, item)
+			continue
+		}
+
+		wg.Add(1)
+		go func(index int, id string, resType string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			localStart := time.Now()
+			aiRequest := &models.AI_REQUEST{
+				RequestID:    uuid.New().String(),
+				Provider:     util.ProviderHuggingFace,
+				ResourceType: resType,
+				ResourceID:   id,
+				Siblings:     []models.SIBLING{},
+				Discussions:  []models.DISCUSSION{},
+			}
+
+			plan, err := fetchAndAddToRequest(aiRequest, id, resType, opts)
+			if err != nil {
+				log.Printf(
+					"op=scanCollection stage=fetch_item_error trace_id=%s collection=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
+					traceID, slug, resType, id, err, time.Since(localStart),
+				)
+				mu.Lock()
+				failedItems[resType+"/"+id] = err.Error()
+				util.AddFailedResource(coverage, resType+"/"+id, err)
+				mu.Unlock()
+				return
+			}
+
+			if err := saveScanRequest(aiRequest); err != nil {
+				log.Printf(
+					"op=scanCollection stage=save_request_error trace_id=%s collection=%s resource_id=%s error=%v",
+					traceID, slug, id, err,
+				)
+			}
+
+			findings := util.ScanWithPlan(*aiRequest, plan, ruleSet.Patterns, resType, id)
+			if findings == nil {
+				findings = []models.Finding{}
+			}
+
+			mu.Lock()
+			totalFindings += len(findings)
+			blobCache.Add(util.CountBlobCache(aiRequest.Siblings))
+			util.MergeCoverage(coverage, util.CoverageFromRequest(*aiRequest), resType+"/"+id)
+			scannedResources[index] = &models.SCANNED_RESOURCE{
+				Provider: util.ProviderHuggingFace,
+				Type:     resType,
+				ID:       id,
+				Findings: findings,
+				ScanMode: string(plan.Mode),
+			}
+			mu.Unlock()
+
+			log.Printf(
+				"op=scanCollection stage=scan_item_done trace_id=%s collection=%s resource_type=%s resource_id=%s findings=%d elapsed=%s",
+				traceID, slug, resType, id, len(findings), time.Since(localStart),
+			)
+		}(i, item.ID, string(resourceType))
+	}
+	wg.Wait()
+
+	allScannedResources := []models.SCANNED_RESOURCE{}
+	for _, resource := range scannedResources {
+		if resource != nil {
+			allScannedResources = append(allScannedResources, *resource)
+		}
+	}
+
+	scanResult := &models.SCAN_RESULT{
+		RequestID:        "collection-" + slug,
+		ScannedResources: allScannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         coverage,
+		Status:           coverage.Status,
+	}
+	if err := saveScanResult(scanResult); err != nil {
+		log.Printf(
+			"op=scanCollection stage=db_create_error trace_id=%s collection=%s error=%v elapsed=%s",
+			traceID, slug, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to save scan results", nil, "")
+	}
+
+	formattedResources := []map[string]interface{}{}
+	for _, resource := range allScannedResources {
+		formattedResources = append(formattedResources, map[string]interface{}{
+			"provider":  resource.Provider,
+			"type":      resource.Type,
+			"id":        resource.ID,
+			"findings":  util.FormatFindings(resource.Findings),
+			"scan_mode": resource.ScanMode,
+		})
+	}
+
+	log.Printf(
+		"op=scanCollection stage=success trace_id=%s collection=%s scan_id=%s items=%d scanned=%d skipped=%d failed=%d total_findings=%d storage_id=%s elapsed=%s",
+		traceID, slug, scanID, len(items), len(allScannedResources), len(skippedItems), len(failedItems), totalFindings, scanResult.ID.Hex(), time.Since(start),
+	)
+
+	return util.ResponseAPI(c, fiber.StatusOK, "Collection scan completed successfully", map[string]interface{}{
+		"scan_id":           scanID,
+		"collection":        slug,
+		"title":             title,
+		"scanned_resources": formattedResources,
+		"skipped_items":     skippedItems,
+		"failed_items":      failedItems,
+		"timestamp":         time.Now().Format(time.RFC3339),
+		"total_findings":    totalFindings,
+		"items_scanned":     len(allScannedResources),
+		"storage_id":        scanResult.ID.Hex(),
+		"blob_cache":        blobCache,
+		"coverage":          coverage,
+		"status":            coverage.Status,
+	}, "")
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestScanCollectionFetchErrorStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		upstream int
+		want     int
+	}{
+		{"unknown collection", http.StatusNotFound, fiber.StatusNotFound},
+		{"hub failure", http.StatusForbidden, fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/collections/acme/missing-64f9a1b2c3d4e5f6a7b8c9d0" {
+					t.Errorf("unexpected request %s", r.URL.Path)
+				}
+				http.Error(w, `{"error":"upstream"}`, tt.upstream)
+			}))
+			defer hub.Close()
+			t.Setenv("HF_BASE_URL", hub.URL)
+
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				return scanCollection(c, "acme/missing-64f9a1b2c3d4e5f6a7b8c9d0", util.FetchOptions{}, "")
+			})
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+// model, paper, dataset, hata hua model aur space ek collection me; paper skip, hata hua model failed,
+// baaki collection ke order me aur huggingface provider ke saath save hote hai
+func TestScanCollectionMixedItems(t *testing.T) {
+	slug := "acme/mixed-64f9a1b2c3d4e5f6a7b8c9d0"
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/collections/"+slug:
+			fmt.Fprint(w, `{"title":"Mixed","items":[`+
+				`{"type":"model","id":"acme/zeta"},{"type":"paper","id":"2401.00001"},{"type":"dataset","id":"acme/data"},`+
+				`{"type":"model","id":"acme/deleted"},{"type":"space","id":"acme/app"}]}`)
+		case r.URL.Path == "/api/models/acme/zeta" || r.URL.Path == "/api/datasets/acme/data" || r.URL.Path == "/api/spaces/acme/app":
+			fmt.Fprint(w, `{"sha":"abc","siblings":[{"rfilename":".env","blobId":"b1","size":60}]}`)
+		case strings.HasSuffix(r.URL.Path, "/resolve/main/.env"):
+			fmt.Fprintf(w, "GITHUB_TOKEN=ghp_%s\n", strings.Repeat("c", 36))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer hub.Close()
+	t.Setenv("HF_BASE_URL", hub.URL)
+	store := withScanStore(t)
+
+	status, data := postUnifiedScan(t, models.ScanRequestBody{Collection: slug})
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(store.results) != 1 {
+		t.Fatalf("saved %d results", len(store.results))
+	}
+	result := store.results[0]
+	order := []string{}
+	for _, resource := range result.ScannedResources {
+		order = append(order, resource.Type+"/"+resource.ID)
+		if resource.Provider != util.ProviderHuggingFace || len(resource.Findings) != 1 {
+			t.Errorf("%s/%s provider=%q findings=%d", resource.Type, resource.ID, resource.Provider, len(resource.Findings))
+		}
+	}
+	if got := strings.Join(order, ","); got != "models/acme/zeta,datasets/acme/data,spaces/acme/app" {
+		t.Errorf("scanned resources = %s, want collection order", got)
+	}
+	for _, request := range store.requests {
+		if request.Provider != util.ProviderHuggingFace {
+			t.Errorf("saved request %s provider = %q", request.ResourceID, request.Provider)
+		}
+	}
+
+	skipped, _ := data["skipped_items"].([]any)
+	failed, _ := data["failed_items"].(map[string]any)
+	if len(skipped) != 1 || skipped[0].(map[string]any)["type"] != "paper" {
+		t.Errorf("skipped items = %v, want the paper", skipped)
+	}
+	if _, ok := failed["models/acme/deleted"]; !ok || len(failed) != 1 {
+		t.Errorf("failed items = %v, want models/acme/deleted", failed)
+	}
+	if result.Status != util.CoveragePartial || len(result.Coverage.Failed) != 1 || result.Coverage.Failed[0].Status != http.StatusNotFound {
+		t.Errorf("coverage = %+v, want partial with the deleted model failed", result.Coverage)
+	}
+}
@@ -3,9 +3,7 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"sync"
 	"time"
 
@@ -22,6 +20,13 @@ func init() {
 
 var httpClient = util.SharedHTTPClient()
 
+// unified scan (single resource, org, collection, source owner) ki requests aur results yahin se Mongo me jaate hai;
+// tests inhe memory wale se badalte hai
+var (
+	saveScanRequest = func(aiRequest *models.AI_REQUEST) error { return mgm.Coll(aiRequest).Create(aiRequest) }
+	saveScanResult  = func(scanResult *models.SCAN_RESULT) error { return mgm.Coll(scanResult).Create(scanResult) }
+)
+
 func UnifiedScan(c *fiber.Ctx) error {
 	start := time.Now()
 	traceID := uuid.New().String()
@@ -35,25 +40,73 @@ func UnifiedScan(c *fiber.Ctx) error {
 		return util.ResponseAPI(c, fiber.StatusBadRequest, "Invalid request body", nil, "")
 	}
 
+	fetchOpts, err := util.FetchOptionsFromRequest(req)
+	if err != nil {
+		log.Printf(
+			"op=UnifiedScan stage=validation_error trace_id=%s error=%q elapsed=%s",
+			traceID, err.Error(), time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
+
 	log.Printf(
-		"op=UnifiedScan stage=start trace_id=%s method=%s path=%s ip=%s user_agent=%q model_id=%s dataset_id=%s space_id=%s org=%s user=%s include_prs=%t include_discussions=%t",
+		"op=UnifiedScan stage=start trace_id=%s method=%s path=%s ip=%s user_agent=%q model_id=%s dataset_id=%s space_id=%s org=%s user=%s collection=%s include_prs=%t include_discussions=%t",
 		traceID, c.Method(), c.OriginalURL(), c.IP(), c.Get("User-Agent"),
-		req.ModelID, req.DatasetID, req.SpaceID, req.Org, req.User, req.IncludePRs, req.IncludeDiscussions,
+		req.ModelID, req.DatasetID, req.SpaceID, req.Org, req.User, req.Collection, req.IncludePRs, req.IncludeDiscussions,
 	)
 
 	scanID := fmt.Sprintf("SG-%s-%s", time.Now().Format("2006-0102"), uuid.New().String()[:8])
 	requestID := uuid.New().String()
+	// is scan ki saari outbound requests scheduler me ek hi key ke neeche
+	fetchOpts.ScanKey = scanID
+
+	source, err := util.GetSource(req.Provider)
+	if err != nil {
+		return util.ResponseAPI(c, fiber.StatusBadRequest, err.Error(), nil, "")
+	}
 
 	aiRequest := &models.AI_REQUEST{
 		RequestID:   requestID,
+		Provider:    source.Name(),
 		Siblings:    []models.SIBLING{},
 		Discussions: []models.DISCUSSION{},
 	}
 
 	var scannedResources []models.SCANNED_RESOURCE
 	var resourceType, resourceID string
+	var plan *util.RescanPlan
 
-	if req.ModelID != "" {
+	if req.ResourceID != "" {
+		// kisi bhi provider ka ek item; resource_type na ho to provider ka pehla type
+		resourceType = req.ResourceType
+		if resourceType == "" {
+			resourceType = source.ResourceTypes()[0]
+		}
+		resourceID = req.ResourceID
+		aiRequest.ResourceType = resourceType
+		aiRequest.ResourceID = resourceID
+
+		log.Printf(
+			"op=UnifiedScan stage=fetch_source_start trace_id=%s request_id=%s provider=%s resource_type=%s resource_id=%s",
+			traceID, requestID, source.Name(), resourceType, resourceID,
+		)
+		if plan, err = fetchAndAddToRequest(aiRequest, resourceID, resourceType, fetchOpts); err != nil {
+			log.Printf(
+				"op=UnifiedScan stage=fetch_source_error trace_id=%s request_id=%s provider=%s resource_id=%s error=%v elapsed=%s",
+				traceID, requestID, source.Name(), resourceID, err, time.Since(start),
+			)
+			return util.ResponseAPI(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to fetch %s: %v", resourceType, err), nil, "")
+		}
+	} else if source.Name() != util.ProviderHuggingFace {
+		owner := req.Org
+		if owner == "" {
+			owner = req.User
+		}
+		if owner == "" {
+			return util.ResponseAPI(c, fiber.StatusBadRequest, "resource_id or org is required for provider "+source.Name(), nil, "")
+		}
+		return scanSourceOwner(c, source, req.ResourceType, owner, fetchOpts, scanID)
+	} else if req.ModelID != "" {
 		resourceType = "models"
 		resourceID = req.ModelID
 		aiRequest.ResourceType = resourceType
@@ -63,7 +116,7 @@ func UnifiedScan(c *fiber.Ctx) error {
 			"op=UnifiedScan stage=fetch_model_start trace_id=%s request_id=%s model_id=%s",
 			traceID, requestID, req.ModelID,
 		)
-		if err := fetchAndAddToRequest(aiRequest, req.ModelID, "models", req.IncludePRs, req.IncludeDiscussions); err != nil {
+		if plan, err = fetchAndAddToRequest(aiRequest, req.ModelID, "models", fetchOpts); err != nil {
 			log.Printf(
 				"op=UnifiedScan stage=fetch_model_error trace_id=%s request_id=%s model_id=%s error=%v elapsed=%s",
 				traceID, requestID, req.ModelID, err, time.Since(start),
@@ -84,7 +137,7 @@ func UnifiedScan(c *fiber.Ctx) error {
 			"op=UnifiedScan stage=fetch_dataset_start trace_id=%s request_id=%s dataset_id=%s",
 			traceID, requestID, req.DatasetID,
 		)
-		if err := fetchAndAddToRequest(aiRequest, req.DatasetID, "datasets", req.IncludePRs, req.IncludeDiscussions); err != nil {
+		if plan, err = fetchAndAddToRequest(aiRequest, req.DatasetID, "datasets", fetchOpts); err != nil {
 			log.Printf(
 				"op=UnifiedScan stage=fetch_dataset_error trace_id=%s request_id=%s dataset_id=%s error=%v elapsed=%s",
 				traceID, requestID, req.DatasetID, err, time.Since(start),
@@ -105,7 +158,7 @@ func UnifiedScan(c *fiber.Ctx) error {
 			"op=UnifiedScan stage=fetch_space_start trace_id=%s request_id=%s space_id=%s",
 			traceID, requestID, req.SpaceID,
 		)
-		if err := fetchAndAddToRequest(aiRequest, req.SpaceID, "spaces", req.IncludePRs, req.IncludeDiscussions); err != nil {
+		if plan, err = fetchAndAddToRequest(aiRequest, req.SpaceID, "spaces", fetchOpts); err != nil {
 			log.Printf(
 				"op=UnifiedScan stage=fetch_space_error trace_id=%s request_id=%s space_id=%s error=%v elapsed=%s",
 				traceID, requestID, req.SpaceID, err, time.Since(start),
@@ -121,26 +174,32 @@ func UnifiedScan(c *fiber.Ctx) error {
 			"op=UnifiedScan stage=org_scan_start trace_id=%s org=%s include_prs=%t include_discussions=%t",
 			traceID, req.Org, req.IncludePRs, req.IncludeDiscussions,
 		)
-		return scanOrganization(c, req.Org, req.IncludePRs, req.IncludeDiscussions, scanID)
+		return scanOrganization(c, req.Org, fetchOpts, scanID)
 	} else if req.User != "" {
 		log.Printf(
 			"op=UnifiedScan stage=user_scan_start trace_id=%s user=%s include_prs=%t include_discussions=%t",
 			traceID, req.User, req.IncludePRs, req.IncludeDiscussions,
 		)
-		return scanOrganization(c, req.User, req.IncludePRs, req.IncludeDiscussions, scanID)
+		return scanOrganization(c, req.User, fetchOpts, scanID)
+	} else if req.Collection != "" {
+		log.Printf(
+			"op=UnifiedScan stage=collection_scan_start trace_id=%s collection=%s include_prs=%t include_discussions=%t",
+			traceID, req.Collection, req.IncludePRs, req.IncludeDiscussions,
+		)
+		return scanCollection(c, req.Collection, fetchOpts, scanID)
 	} else {
 		log.Printf(
 			"op=UnifiedScan stage=validation_error trace_id=%s error=%q elapsed=%s",
-			traceID, "missing one of model_id,dataset_id,space_id,org,user", time.Since(start),
+			traceID, "missing one of resource_id,model_id,dataset_id,space_id,org,user,collection", time.Since(start),
 		)
-		return util.ResponseAPI(c, fiber.StatusBadRequest, "At least one of model_id, dataset_id, space_id, org, or user is required", nil, "")
+		return util.ResponseAPI(c, fiber.StatusBadRequest, "At least one of resource_id, model_id, dataset_id, space_id, org, user, or collection is required", nil, "")
 	}
 
 	log.Printf(
 		"op=UnifiedScan stage=save_request_start trace_id=%s request_id=%s",
 		traceID, requestID,
 	)
-	if err := mgm.Coll(aiRequest).Create(aiRequest); err != nil {
+	if err := saveScanRequest(aiRequest); err != nil {
 		log.Printf(
 			"op=UnifiedScan stage=save_request_error trace_id=%s request_id=%s error=%v elapsed=%s",
 			traceID, requestID, err, time.Since(start),
@@ -156,10 +215,11 @@ func UnifiedScan(c *fiber.Ctx) error {
 		"op=UnifiedScan stage=scanning_start trace_id=%s request_id=%s resource_type=%s resource_id=%s",
 		traceID, requestID, resourceType, resourceID,
 	)
-	findings := util.ScanAIRequest(*aiRequest, util.SecretConfig, resourceType, resourceID)
+	ruleSet := util.ActiveRuleSet()
+	findings := util.ScanWithPlan(*aiRequest, plan, ruleSet.Patterns, resourceType, resourceID)
 	log.Printf(
-		"op=UnifiedScan stage=scanning_done trace_id=%s request_id=%s findings=%d elapsed=%s",
-		traceID, requestID, len(findings), time.Since(start),
+		"op=UnifiedScan stage=scanning_done trace_id=%s request_id=%s findings=%d scan_mode=%s elapsed=%s",
+		traceID, requestID, len(findings), plan.Mode, time.Since(start),
 	)
 
 	findingsMap := make(map[string][]models.Finding)
@@ -179,9 +239,11 @@ func UnifiedScan(c *fiber.Ctx) error {
 	}
 
 	scannedResource := models.SCANNED_RESOURCE{
+		Provider: aiRequest.Provider,
 		Type:     resourceType,
 		ID:       resourceID,
 		Findings: resourceFindings,
+		ScanMode: string(plan.Mode),
 	}
 	scannedResources = append(scannedResources, scannedResource)
 
@@ -192,8 +254,12 @@ func UnifiedScan(c *fiber.Ctx) error {
 	scanResult := &models.SCAN_RESULT{
 		RequestID:        requestID,
 		ScannedResources: scannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         util.CoverageFromRequest(*aiRequest),
 	}
-	if err := mgm.Coll(scanResult).Create(scanResult); err != nil {
+	scanResult.Status = scanResult.Coverage.Status
+	if err := saveScanResult(scanResult); err != nil {
 		log.Printf(
 			"op=UnifiedScan stage=save_scan_result_error trace_id=%s request_id=%s error=%v elapsed=%s",
 			traceID, requestID, err, time.Since(start),
@@ -209,14 +275,21 @@ func UnifiedScan(c *fiber.Ctx) error {
 		"scan_id": scanID,
 		"scanned_resources": []map[string]interface{}{
 			{
-				"type":     resourceType,
-				"id":       resourceID,
-				"findings": util.FormatFindings(resourceFindings),
+				"provider":  aiRequest.Provider,
+				"type":      resourceType,
+				"id":        resourceID,
+				"findings":  util.FormatFindings(resourceFindings),
+				"scan_mode": plan.Mode,
 			},
 		},
+		"scan_mode":      plan.Mode,
+		"rescan":         plan,
 		"timestamp":      time.Now().Format(time.RFC3339),
 		"total_findings": len(findings),
 		"storage_id":     scanResult.ID.Hex(),
+		"blob_cache":     util.CountBlobCache(aiRequest.Siblings),
+		"coverage":       scanResult.Coverage,
+		"status":         scanResult.Status,
 	}
 
 	log.Printf(
@@ -255,9 +328,12 @@ func StoreScanResult(c *fiber.Ctx) error {
 		ScannedResources: []models.SCANNED_RESOURCE{},
 	}
 	if resources, ok := scanData["scanned_resources"].([]interface{}); ok {
-		for _, res := range resources {
+		for i, res := range resources {
 			if resMap, ok := res.(map[string]interface{}); ok {
 				resource := models.SCANNED_RESOURCE{}
+				if provider, ok := resMap["provider"].(string); ok {
+					resource.Provider = provider
+				}
 				if resType, ok := resMap["type"].(string); ok {
 					resource.Type = resType
 				}
@@ -265,24 +341,15 @@ func StoreScanResult(c *fiber.Ctx) error {
 					resource.ID = resID
 				}
 				if findings, ok := resMap["findings"].([]interface{}); ok {
-					for _, f := range findings {
+					for j, f := range findings {
 						if fMap, ok := f.(map[string]interface{}); ok {
-							finding := models.Finding{}
-							if secretType, ok := fMap["secret_type"].(string); ok {
-								finding.SecretType = secretType
-							}
-							if pattern, ok := fMap["pattern"].(string); ok {
-								finding.Pattern = pattern
-							}
-							if secret, ok := fMap["secret"].(string); ok {
-								finding.Secret = secret
-							}
-							if file, ok := fMap["file"].(string); ok {
-								finding.FileName = file
-								finding.SourceType = "file"
-							}
-							if line, ok := fMap["line"].(float64); ok {
-								finding.Line = int(line)
+							finding, err := storedFinding(fMap, resource)
+							if err != nil {
+								log.Printf(
+									"op=StoreScanResult stage=validation_error trace_id=%s scan_id=%s resource=%d finding=%d error=%v elapsed=%s",
+									traceID, scanIDValue, i, j, err, time.Since(start),
+								)
+								return util.ResponseAPI(c, fiber.StatusBadRequest, fmt.Sprintf("Invalid finding %d in scanned_resources[%d]: %v", j, i, err), nil, "")
 							}
 							resource.Findings = append(resource.Findings, finding)
 						}
@@ -313,155 +380,102 @@ func StoreScanResult(c *fiber.Ctx) error {
 	}, "")
 }
 
-func fetchAndAddToRequest(aiRequest *models.AI_REQUEST, resourceID, resourceType string, includePRs, includeDiscussions bool) error {
-	start := time.Now()
-	traceID := uuid.New().String()
-
-	url := fmt.Sprintf("https://huggingface.co/api/%s/%s", resourceType, resourceID)
-	log.Printf(
-		"op=fetchAndAddToRequest stage=start trace_id=%s resource_type=%s resource_id=%s url=%s include_prs=%t include_discussions=%t",
-		traceID, resourceType, resourceID, url, includePRs, includeDiscussions,
-	)
-
-	resp, err := httpClient.Get(url)
+// store body ki finding: Finding ke saare json fields, purana "file" key bhi; source_type na diya ho to
+// fields se (discussion / pr / history / file), resource ki info finding pe bhi
+func storedFinding(fMap map[string]interface{}, resource models.SCANNED_RESOURCE) (models.Finding, error) {
+	raw, err := json.Marshal(fMap)
 	if err != nil {
-		log.Printf(
-			"op=fetchAndAddToRequest stage=http_get_error trace_id=%s resource_type=%s resource_id=%s url=%s error=%v elapsed=%s",
-			traceID, resourceType, resourceID, url, err, time.Since(start),
-		)
-		return err
+		return models.Finding{}, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf(
-			"op=fetchAndAddToRequest stage=not_ok trace_id=%s resource_type=%s resource_id=%s status=%d elapsed=%s",
-			traceID, resourceType, resourceID, resp.StatusCode, time.Since(start),
-		)
-		return fmt.Errorf("resource not found: %d", resp.StatusCode)
+	finding := models.Finding{}
+	if err := json.Unmarshal(raw, &finding); err != nil {
+		return models.Finding{}, err
 	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf(
-			"op=fetchAndAddToRequest stage=read_error trace_id=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
-			traceID, resourceType, resourceID, err, time.Since(start),
-		)
-		return err
+	if file, ok := fMap["file"].(string); ok && finding.FileName == "" {
+		finding.FileName = file
 	}
 
-	var resourceData map[string]interface{}
-	if err := json.Unmarshal(body, &resourceData); err != nil {
-		log.Printf(
-			"op=fetchAndAddToRequest stage=json_unmarshal_error trace_id=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
-			traceID, resourceType, resourceID, err, time.Since(start),
-		)
-		return err
+	if finding.SourceType == "" {
+		switch {
+		case finding.DiscussionNum != 0 || finding.CommentID != "":
+			finding.SourceType = "discussion"
+		case finding.PRNum != 0 && finding.FileName != "":
+			finding.SourceType = "pr"
+		case finding.CommitSHA != "" || finding.FirstCommit != "":
+			finding.SourceType = "history"
+		case finding.FileName != "":
+			finding.SourceType = "file"
+		}
 	}
 
-	if siblings, ok := resourceData["siblings"].([]interface{}); ok {
-		log.Printf(
-			"op=fetchAndAddToRequest stage=fetch_files_start trace_id=%s resource_type=%s resource_id=%s sibling_candidates=%d",
-			traceID, resourceType, resourceID, len(siblings),
-		)
-		aiRequest.Siblings = util.FetchFilesFromSiblings(resourceID, siblings)
-		log.Printf(
-			"op=fetchAndAddToRequest stage=fetch_files_done trace_id=%s resource_type=%s resource_id=%s files=%d",
-			traceID, resourceType, resourceID, len(aiRequest.Siblings),
-		)
+	if finding.Provider == "" {
+		finding.Provider = resource.Provider
 	}
-
-	if includePRs || includeDiscussions {
-		log.Printf(
-			"op=fetchAndAddToRequest stage=fetch_discussions_start trace_id=%s resource_type=%s resource_id=%s include_prs=%t include_discussions=%t",
-			traceID, resourceType, resourceID, includePRs, includeDiscussions,
-		)
-		discussions, _ := util.FetchDiscussions(resourceID, resourceType, includePRs, includeDiscussions)
-		aiRequest.Discussions = discussions
-		log.Printf(
-			"op=fetchAndAddToRequest stage=fetch_discussions_done trace_id=%s resource_type=%s resource_id=%s discussions=%d",
-			traceID, resourceType, resourceID, len(discussions),
-		)
+	if finding.ResourceType == "" {
+		finding.ResourceType = resource.Type
 	}
+	if finding.ResourceID == "" {
+		finding.ResourceID = resource.ID
+	}
+	return finding, nil
+}
 
-	log.Printf(
-		"op=fetchAndAddToRequest stage=success trace_id=%s resource_type=%s resource_id=%s elapsed=%s",
-		traceID, resourceType, resourceID, time.Since(start),
-	)
-
-	return nil
+// aiRequest ke provider (khaali = huggingface) ka Source item fetch karta hai,
+// plan batata hai ki files full, incremental ya reused scan karni hai
+func fetchAndAddToRequest(aiRequest *models.AI_REQUEST, resourceID, resourceType string, opts util.FetchOptions) (*util.RescanPlan, error) {
+	source, err := util.GetSource(aiRequest.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if !util.SupportsResourceType(source, resourceType) {
+		return nil, fmt.Errorf("provider %s does not support resource type %q", source.Name(), resourceType)
+	}
+	aiRequest.Provider = source.Name()
+	return source.Fetch(aiRequest, resourceType, resourceID, opts)
 }
 
-func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions bool, scanID string) error {
+func scanOrganization(c *fiber.Ctx, org string, opts util.FetchOptions, scanID string) error {
 	start := time.Now()
 	traceID := uuid.New().String()
 
-	modelsURL := fmt.Sprintf("https://huggingface.co/api/models?author=%s&full=true", org)
 	log.Printf(
-		"op=scanOrganization stage=start trace_id=%s org=%s include_prs=%t include_discussions=%t url=%s",
-		traceID, org, includePRs, includeDiscussions, modelsURL,
+		"op=scanOrganization stage=start trace_id=%s org=%s include_prs=%t include_discussions=%t",
+		traceID, org, opts.IncludePRs, opts.IncludeDiscussions,
 	)
 
-	resp, err := httpClient.Get(modelsURL)
-	if err != nil {
-		log.Printf(
-			"op=scanOrganization stage=http_get_error trace_id=%s org=%s url=%s error=%v elapsed=%s",
-			traceID, org, modelsURL, err, time.Since(start),
-		)
-		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to fetch organization models", nil, "")
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	// saare pages (Link header), sirf pehle 1000 / pehle 10 models nahi
+	hits, err := util.ListHubRepos("models", org)
 	if err != nil {
 		log.Printf(
-			"op=scanOrganization stage=read_error trace_id=%s org=%s error=%v elapsed=%s",
+			"op=scanOrganization stage=list_error trace_id=%s org=%s error=%v elapsed=%s",
 			traceID, org, err, time.Since(start),
 		)
-		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to read response", nil, "")
-	}
-
-	var modelsData []map[string]interface{}
-	if err := json.Unmarshal(body, &modelsData); err != nil {
-		log.Printf(
-			"op=scanOrganization stage=json_unmarshal_error trace_id=%s org=%s error=%v elapsed=%s",
-			traceID, org, err, time.Since(start),
-		)
-		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to parse response", nil, "")
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to fetch organization models", nil, "")
 	}
 
 	log.Printf(
 		"op=scanOrganization stage=parsed trace_id=%s org=%s models=%d",
-		traceID, org, len(modelsData),
+		traceID, org, len(hits),
 	)
 
 	var allScannedResources []models.SCANNED_RESOURCE
 	var totalFindings int
+	var blobCache util.BlobCacheStats
+	scanModes := map[string]util.RescanMode{}
+	coverage := util.NewScanCoverage()
 	var mu sync.Mutex
 
-	limit := 10
-	if len(modelsData) < limit {
-		limit = len(modelsData)
-	}
-
+	total := len(hits)
 	log.Printf(
-		"op=scanOrganization stage=scanning_models_start trace_id=%s org=%s concurrent=%d limit=%d",
-		traceID, org, 10, limit,
+		"op=scanOrganization stage=scanning_models_start trace_id=%s org=%s concurrent=%d models=%d",
+		traceID, org, 10, total,
 	)
 
+	ruleSet := util.ActiveRuleSet()
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 10)
 
-	for i := 0; i < limit; i++ {
-		modelData := modelsData[i]
-		modelID, ok := modelData["id"].(string)
-		if !ok {
-			log.Printf(
-				"op=scanOrganization stage=skip_missing_id trace_id=%s org=%s index=%d",
-				traceID, org, i,
-			)
-			continue
-		}
+	for i, hit := range hits {
 		wg.Add(1)
 		go func(id string, index int) {
 			defer wg.Done()
@@ -471,7 +485,7 @@ func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions b
 			localStart := time.Now()
 			log.Printf(
 				"op=scanOrganization stage=scan_model_start trace_id=%s org=%s index=%d total=%d model_id=%s",
-				traceID, org, index+1, limit, id,
+				traceID, org, index+1, total, id,
 			)
 
 			aiRequest := &models.AI_REQUEST{
@@ -482,18 +496,33 @@ func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions b
 				Discussions:  []models.DISCUSSION{},
 			}
 
-			if err := fetchAndAddToRequest(aiRequest, id, "models", includePRs, includeDiscussions); err != nil {
+			plan, err := fetchAndAddToRequest(aiRequest, id, "models", opts)
+			if err != nil {
 				log.Printf(
 					"op=scanOrganization stage=fetch_model_error trace_id=%s org=%s model_id=%s error=%v elapsed=%s",
 					traceID, org, id, err, time.Since(localStart),
 				)
+				mu.Lock()
+				util.AddFailedResource(coverage, id, err)
+				mu.Unlock()
 				return
 			}
 
-			findings := util.ScanAIRequest(*aiRequest, util.SecretConfig, "models", id)
+			// agle rescan me unchanged files ka content isi snapshot se aata hai
+			if err := saveScanRequest(aiRequest); err != nil {
+				log.Printf(
+					"op=scanOrganization stage=save_request_error trace_id=%s org=%s model_id=%s error=%v",
+					traceID, org, id, err,
+				)
+			}
+
+			findings := util.ScanWithPlan(*aiRequest, plan, ruleSet.Patterns, "models", id)
 
 			mu.Lock()
 			totalFindings += len(findings)
+			blobCache.Add(util.CountBlobCache(aiRequest.Siblings))
+			scanModes[id] = plan.Mode
+			util.MergeCoverage(coverage, util.CoverageFromRequest(*aiRequest), id)
 			mu.Unlock()
 
 			if len(findings) > 0 {
@@ -505,6 +534,7 @@ func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions b
 					Type:     "model",
 					ID:       id,
 					Findings: findings,
+					ScanMode: string(plan.Mode),
 				}
 				mu.Lock()
 				allScannedResources = append(allScannedResources, scannedResource)
@@ -515,7 +545,7 @@ func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions b
 					traceID, org, id, time.Since(localStart),
 				)
 			}
-		}(modelID, i)
+		}(hit.ResourceID, i)
 	}
 	wg.Wait()
 
@@ -527,8 +557,12 @@ func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions b
 	scanResult := &models.SCAN_RESULT{
 		RequestID:        "org-" + org,
 		ScannedResources: allScannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         coverage,
+		Status:           coverage.Status,
 	}
-	if err := mgm.Coll(scanResult).Create(scanResult); err != nil {
+	if err := saveScanResult(scanResult); err != nil {
 		log.Printf(
 			"op=scanOrganization stage=db_create_error trace_id=%s org=%s error=%v elapsed=%s",
 			traceID, org, err, time.Since(start),
@@ -539,9 +573,10 @@ func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions b
 	formattedResources := []map[string]interface{}{}
 	for _, resource := range allScannedResources {
 		formattedResources = append(formattedResources, map[string]interface{}{
-			"type":     resource.Type,
-			"id":       resource.ID,
-			"findings": util.FormatFindings(resource.Findings),
+			"type":      resource.Type,
+			"id":        resource.ID,
+			"findings":  util.FormatFindings(resource.Findings),
+			"scan_mode": resource.ScanMode,
 		})
 	}
 
@@ -550,13 +585,17 @@ func scanOrganization(c *fiber.Ctx, org string, includePRs, includeDiscussions b
 		"scanned_resources": formattedResources,
 		"timestamp":         time.Now().Format(time.RFC3339),
 		"total_findings":    totalFindings,
-		"models_scanned":    limit,
+		"models_scanned":    total,
 		"storage_id":        scanResult.ID.Hex(),
+		"blob_cache":        blobCache,
+		"scan_modes":        scanModes,
+		"coverage":          coverage,
+		"status":            coverage.Status,
 	}
 
 	log.Printf(
 		"op=scanOrganization stage=success trace_id=%s org=%s scan_id=%s total_findings=%d models_scanned=%d storage_id=%s elapsed=%s",
-		traceID, org, scanID, totalFindings, limit, scanResult.ID.Hex(), time.Since(start),
+		traceID, org, scanID, totalFindings, total, scanResult.ID.Hex(), time.Since(start),
 	)
 
 	return util.ResponseAPI(c, fiber.StatusOK, "Organization scan completed successfully", response, "")
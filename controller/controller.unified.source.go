@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// non-HF provider ke owner (org / group / bucket) ke saare items, Source.ListItems se;
+// resource_type khaali ho to provider ke saare types
+func scanSourceOwner(c *fiber.Ctx, source util.Source, resourceType, owner string, opts util.FetchOptions, scanID string) error {
+	start := time.Now()
+	traceID := uuid.New().String()
+
+	resourceTypes := source.ResourceTypes()
+	if resourceType != "" {
+		if !util.SupportsResourceType(source, resourceType) {
+			return util.ResponseAPI(c, fiber.StatusBadRequest, fmt.Sprintf("Provider %s does not support resource type %q", source.Name(), resourceType), nil, "")
+		}
+		resourceTypes = []string{resourceType}
+	}
+
+	log.Printf(
+		"op=scanSourceOwner stage=start trace_id=%s provider=%s owner=%s resource_types=%v",
+		traceID, source.Name(), owner, resourceTypes,
+	)
+
+	items := []util.SourceItem{}
+	for _, rt := range resourceTypes {
+		listed, err := source.ListItems(rt, owner)
+		if err != nil {
+			log.Printf(
+				"op=scanSourceOwner stage=list_error trace_id=%s provider=%s owner=%s resource_type=%s error=%v elapsed=%s",
+				traceID, source.Name(), owner, rt, err, time.Since(start),
+			)
+			return util.ResponseAPI(c, fiber.StatusInternalServerError, fmt.Sprintf("Failed to list %s for %s: %v", rt, owner, err), nil, "")
+		}
+		items = append(items, listed...)
+	}
+
+	var allScannedResources []models.SCANNED_RESOURCE
+	var totalFindings int
+	var blobCache util.BlobCacheStats
+	scanModes := map[string]util.RescanMode{}
+	coverage := util.NewScanCoverage()
+	var mu sync.Mutex
+	ruleSet := util.ActiveRuleSet()
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10)
+
+	for i, item := range items {
+		wg.Add(1)
+		go func(item util.SourceItem, index int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			localStart := time.Now()
+			key := item.ResourceType + "/" + item.ResourceID
+
+			aiRequest := &models.AI_REQUEST{
+				RequestID:    uuid.New().String(),
+				Provider:     source.Name(),
+				ResourceType: item.ResourceType,
+				ResourceID:   item.ResourceID,
+				Siblings:     []models.SIBLING{},
+				Discussions:  []models.DISCUSSION{},
+			}
+			plan, err := fetchAndAddToRequest(aiRequest, item.ResourceID, item.ResourceType, opts)
+			if err != nil {
+				log.Printf(
+					"op=scanSourceOwner stage=fetch_error trace_id=%s provider=%s resource=%s error=%v elapsed=%s",
+					traceID, source.Name(), key, err, time.Since(localStart),
+				)
+				mu.Lock()
+				util.AddFailedResource(coverage, key, err)
+				mu.Unlock()
+				return
+			}
+			if err := saveScanRequest(aiRequest); err != nil {
+				log.Printf(
+					"op=scanSourceOwner stage=save_request_error trace_id=%s provider=%s resource=%s error=%v",
+					traceID, source.Name(), key, err,
+				)
+			}
+
+			findings := util.ScanWithPlan(*aiRequest, plan, ruleSet.Patterns, item.ResourceType, item.ResourceID)
+			log.Printf(
+				"op=scanSourceOwner stage=scan_done trace_id=%s provider=%s index=%d total=%d resource=%s findings=%d scan_mode=%s elapsed=%s",
+				traceID, source.Name(), index+1, len(items), key, len(findings), plan.Mode, time.Since(localStart),
+			)
+
+			mu.Lock()
+			defer mu.Unlock()
+			totalFindings += len(findings)
+			blobCache.Add(util.CountBlobCache(aiRequest.Siblings))
+			scanModes[key] = plan.Mode
+			util.MergeCoverage(coverage, util.CoverageFromRequest(*aiRequest), key)
+			if len(findings) > 0 {
+				allScannedResources = append(allScannedResources, models.SCANNED_RESOURCE{
+					Provider: source.Name(),
+					Type:     item.ResourceType,
+					ID:       item.ResourceID,
+					Findings: findings,
+					ScanMode: string(plan.Mode),
+				})
+			}
+		}(item, i)
+	}
+	wg.Wait()
+
+	scanResult := &models.SCAN_RESULT{
+		RequestID:        source.Name() + "-" + owner,
+		ScannedResources: allScannedResources,
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
+		Coverage:         coverage,
+		Status:           coverage.Status,
+	}
+	if err := saveScanResult(scanResult); err != nil {
+		log.Printf(
+			"op=scanSourceOwner stage=db_create_error trace_id=%s provider=%s owner=%s error=%v elapsed=%s",
+			traceID, source.Name(), owner, err, time.Since(start),
+		)
+		return util.ResponseAPI(c, fiber.StatusInternalServerError, "Failed to save scan results", nil, "")
+	}
+
+	formattedResources := []map[string]interface{}{}
+	for _, resource := range allScannedResources {
+		formattedResources = append(formattedResources, map[string]interface{}{
+			"provider":  resource.Provider,
+			"type":      resource.Type,
+			"id":        resource.ID,
+			"findings":  util.FormatFindings(resource.Findings),
+			"scan_mode": resource.ScanMode,
+		})
+	}
+
+	log.Printf(
+		"op=scanSourceOwner stage=success trace_id=%s provider=%s owner=%s scan_id=%s items=%d total_findings=%d storage_id=%s elapsed=%s",
+		traceID, source.Name(), owner, scanID, len(items), totalFindings, scanResult.ID.Hex(), time.Since(start),
+	)
+
+	return util.ResponseAPI(c, fiber.StatusOK, "Owner scan completed successfully", map[string]interface{}{
+		"scan_id":           scanID,
+		"provider":          source.Name(),
+		"owner":             owner,
+		"scanned_resources": formattedResources,
+		"timestamp":         time.Now().Format(time.RFC3339),
+		"total_findings":    totalFindings,
+		"items_scanned":     len(items),
+		"storage_id":        scanResult.ID.Hex(),
+		"blob_cache":        blobCache,
+		"scan_modes":        scanModes,
+		"coverage":          coverage,
+		"status":            coverage.Status,
+	}, "")
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/MishraShardendu22/Scanner/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// koi internal artifact store jaisa provider, sirf Source interface ke through; HF ka koi code path nahi
+type artifactSource struct{}
+
+const artifactCommit = "0123456789abcdef0123456789abcdef01234567"
+
+func init() {
+	util.RegisterSource(artifactSource{})
+}
+
+func (artifactSource) Name() string { return "artifacts" }
+
+func (artifactSource) ResourceTypes() []string { return []string{"bundles"} }
+
+func (artifactSource) ListItems(resourceType, owner string) ([]util.SourceItem, error) {
+	return []util.SourceItem{
+		{Provider: "artifacts", ResourceType: resourceType, ResourceID: owner + "/one"},
+		{Provider: "artifacts", ResourceType: resourceType, ResourceID: owner + "/two"},
+	}, nil
+}
+
+func (artifactSource) Fetch(aiRequest *models.AI_REQUEST, resourceType, resourceID string, opts util.FetchOptions) (*util.RescanPlan, error) {
+	aiRequest.CommitSHA = artifactCommit
+	aiRequest.Siblings = []models.SIBLING{{
+		RFilename:   "deploy/.env",
+		FileContent: "# " + resourceID + "\nGITHUB_TOKEN=ghp_" + strings.Repeat(resourceID[len(resourceID)-1:], 36) + "\n",
+	}}
+	return util.PlanForCommit(aiRequest, opts), nil
+}
+
+func (artifactSource) FileURL(resourceType, resourceID, revision, fileName string, line int) string {
+	return fmt.Sprintf("https://artifacts.example/%s/%s/%s@%s#L%d", resourceType, resourceID, fileName, revision, line)
+}
+
+func TestUnifiedScanRegisteredSource(t *testing.T) {
+	store := withScanStore(t)
+
+	status, data := postUnifiedScan(t, models.ScanRequestBody{Provider: "artifacts", ResourceID: "team/one"})
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if data["total_findings"] != float64(1) {
+		t.Errorf("total_findings = %v, want 1", data["total_findings"])
+	}
+	if len(store.requests) != 1 || store.requests[0].Provider != "artifacts" || store.requests[0].ResourceType != "bundles" || store.requests[0].CommitSHA != artifactCommit {
+		t.Fatalf("saved requests = %+v", store.requests)
+	}
+	if len(store.results) != 1 || len(store.results[0].ScannedResources) != 1 {
+		t.Fatalf("saved results = %+v", store.results)
+	}
+	resource := store.results[0].ScannedResources[0]
+	if resource.Provider != "artifacts" || resource.Type != "bundles" || resource.ID != "team/one" || len(resource.Findings) != 1 {
+		t.Fatalf("scanned resource = %+v", resource)
+	}
+	finding := resource.Findings[0]
+	wantURL := "https://artifacts.example/bundles/team/one/deploy/.env@" + artifactCommit + "#L2"
+	if finding.Provider != "artifacts" || finding.URL != wantURL || finding.Secret != "ghp_"+strings.Repeat("e", 36) {
+		t.Errorf("finding provider=%s url=%s secret=%s, want artifacts %s", finding.Provider, finding.URL, finding.Secret, wantURL)
+	}
+}
+
+func TestUnifiedScanRegisteredSourceOwner(t *testing.T) {
+	store := withScanStore(t)
+
+	status, _ := postUnifiedScan(t, models.ScanRequestBody{Provider: "artifacts", Org: "team"})
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(store.requests) != 2 || len(store.results) != 1 {
+		t.Fatalf("saved %d requests, %d results", len(store.requests), len(store.results))
+	}
+	result := store.results[0]
+	if result.RequestID != "artifacts-team" || result.Status != util.CoverageComplete {
+		t.Errorf("result request_id=%s status=%s", result.RequestID, result.Status)
+	}
+	ids := []string{}
+	for _, resource := range result.ScannedResources {
+		ids = append(ids, resource.ID)
+		for _, finding := range resource.Findings {
+			if finding.Provider != "artifacts" || !strings.HasPrefix(finding.URL, "https://artifacts.example/bundles/"+resource.ID+"/") {
+				t.Errorf("%s finding provider=%s url=%s", resource.ID, finding.Provider, finding.URL)
+			}
+		}
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "team/one,team/two" {
+		t.Errorf("scanned resources = %v", ids)
+	}
+
+	if status, _ := postUnifiedScan(t, models.ScanRequestBody{Provider: "artifacts", ResourceType: "models", ResourceID: "team/one"}); status == fiber.StatusOK {
+		t.Error("unsupported resource type for the provider was scanned")
+	}
+	if status, _ := postUnifiedScan(t, models.ScanRequestBody{Provider: "nowhere", ResourceID: "team/one"}); status != fiber.StatusBadRequest {
+		t.Errorf("unknown provider status = %d, want 400", status)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/MishraShardendu22/Scanner/models"
 	"github.com/MishraShardendu22/Scanner/templ_ms22"
+	"github.com/MishraShardendu22/Scanner/util"
 	"github.com/gofiber/fiber/v2"
 	"github.com/kamva/mgm/v3"
 	"go.mongodb.org/mongo-driver/bson"
@@ -126,15 +127,33 @@ func GetResultsPage(c *fiber.Ctx) error {
 	return templ_ms22.ResultsListNew(results, page, totalPages).Render(c.Context(), c.Response().BodyWriter())
 }
 
+// ?format=html / markdown pe dashboard ke bahar bhejne layak report download hoti hai
 func GetResultDetailPage(c *fiber.Ctx) error {
 	c.Set("Content-Type", "text/html; charset=utf-8")
 	requestID := c.Params("request_id")
 
+	format := c.Query("format")
+	if format != "" && format != "html" && format != "markdown" && format != "md" {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid format: use html or markdown")
+	}
+
 	var result models.SCAN_RESULT
 	err := mgm.Coll(&models.SCAN_RESULT{}).First(bson.M{"request_id": requestID}, &result)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).SendString("Result not found")
 	}
 
+	switch format {
+	case "html":
+		report := util.BuildScanReport(result)
+		c.Set(fiber.HeaderContentDisposition, util.AttachmentDisposition(report.FileName("html")))
+		return templ_ms22.ScanReport(report).Render(c.Context(), c.Response().BodyWriter())
+	case "markdown", "md":
+		report := util.BuildScanReport(result)
+		c.Set("Content-Type", "text/markdown; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, util.AttachmentDisposition(report.FileName("md")))
+		return c.SendString(util.RenderMarkdownReport(report))
+	}
+
 	return templ_ms22.ResultDetailNew(result).Render(c.Context(), c.Response().BodyWriter())
 }
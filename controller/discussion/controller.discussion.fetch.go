@@ -12,18 +12,23 @@ import (
 // discissons can be of two types: "pr" or "discussion", 
 // this basically fetches them and saves them in db in a way that we know 
 // if pr is fetched or discussion is fetched
-func FetchAndSaveDiscussionsByType(resourceType, resourceID, discussionType string) (*models.AI_REQUEST, error) {
-	url := fmt.Sprintf("https://huggingface.co/api/%s/%s/discussions?types=%s&status=all", resourceType, resourceID, discussionType)
+// filter se status / created date ke hisaab se chhantte hai, then har thread ke comments bhi le aate hai
+func FetchAndSaveDiscussionsByType(resourceType, resourceID, discussionType string, filter util.DiscussionFilter) (*models.AI_REQUEST, error) {
+	url := util.DiscussionListURL(resourceType, resourceID, discussionType, filter)
 
-	discussions, err := util.GetDiscussionsFromURL(url)
+	discussions, err := util.GetDiscussionsFromURL(url, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch %s", discussionType)
 	}
+	discussions = util.FilterDiscussions(discussions, filter)
+	discussions = util.AttachDiscussionEvents(resourceType, resourceID, discussions, "")
 
 	aiRequest := &models.AI_REQUEST{
-		RequestID:   uuid.New().String(),
-		Siblings:    []models.SIBLING{},
-		Discussions: discussions,
+		RequestID:    uuid.New().String(),
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Siblings:     []models.SIBLING{},
+		Discussions:  discussions,
 	}
 
 	if err := mgm.Coll(aiRequest).Create(aiRequest); err != nil {
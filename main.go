@@ -9,11 +9,13 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/MishraShardendu22/Scanner/util"
 
+	"github.com/MishraShardendu22/Scanner/cli"
 	"github.com/MishraShardendu22/Scanner/database"
 	"github.com/MishraShardendu22/Scanner/models"
 	"github.com/MishraShardendu22/Scanner/route"
@@ -36,6 +38,11 @@ var (
 
 func main() {
 
+	// "scanner scan ..." jaise subcommands server nahi chalate, scan karke exit code ke saath nikal jaate hai
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	flag.Parse()
 	fmt.Println("Stack Guard Assignment")
 
@@ -47,6 +54,30 @@ func main() {
 
 	setupLogger(config)
 
+	// gitleaks-style rules file built-in rules me merge; galat file pe server start nahi hota
+	if rulesFile := util.GetEnv("RULES_FILE", ""); rulesFile != "" {
+		if err := util.LoadRulesFile(rulesFile); err != nil {
+			log.Fatalf("Rules file load failed: %v", err)
+		}
+	}
+
+	// saved rule set (warna built-in rules) scanner me, phir doosre instances ke changes ke liye poll
+	if err := util.EnsureRuleSetIndexes(); err != nil {
+		log.Printf("op=main stage=rule_set_index_error error=%v", err)
+	}
+	if err := util.LoadActiveRuleSet(); err != nil {
+		log.Printf("op=main stage=rule_set_load_error error=%v", err)
+	}
+	// saved revision chalu ho to file ke rules usme merge (nayi revision); merge na ho paaye to server start nahi hota
+	saved, err := util.MergeRulesFileIntoSaved()
+	if err != nil {
+		log.Fatalf("Rules file merge into saved rule set failed: %v", err)
+	}
+	if saved != nil {
+		log.Printf("op=main stage=rules_file_merged revision=%d version=%s", saved.Revision, saved.Version)
+	}
+	go util.WatchRuleSets()
+
 	logger := slog.Default()
 	logger.Info("Starting Security Scanner",
 		"environment", config.Environment,
@@ -124,6 +155,7 @@ func SetUpRoutes(app *fiber.App, logger *slog.Logger, config *models.Config) {
 	route.SetupOrgRoutes(app)
 	route.SetupScanRoutes(app)
 	route.SetupResultRoutes(app)
+	route.SetupRuleRoutes(app)
 }
 
 func setupLogger(config *models.Config) {
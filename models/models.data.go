@@ -41,20 +41,170 @@ type DISCUSSION struct {
 	RepoName      string `json:"repo_name" bson:"repo_name"`
 	NumComments   int64  `json:"numComments" bson:"numComments"`
 	Pinned        bool   `json:"pinned" bson:"pinned"`
+
+	Events []DISCUSSION_EVENT `json:"events,omitempty" bson:"events,omitempty"`
+	// thread (comments / edits) fetch nahi ho paya to uska error, tab sirf title scan hua
+	EventsError string `json:"events_error,omitempty" bson:"events_error,omitempty"`
+}
+
+// discussion thread ka ek text version, comment ho ya uska purana edit ya title change
+type DISCUSSION_EVENT struct {
+	ID         string `json:"id" bson:"id"`
+	Type       string `json:"type" bson:"type"`
+	AuthorName string `json:"author_name" bson:"author_name"`
+	CreatedAt  string `json:"createdAt" bson:"createdAt"`
+	Content    string `json:"content" bson:"content"`
+	Edited     bool   `json:"edited,omitempty" bson:"edited,omitempty"`
 }
 
 type SIBLING struct {
 	RFilename   string `json:"rfilename" bson:"rfilename"`
 	FileContent string `json:"file_content" bson:"file_content"`
+	BlobID      string `json:"blob_id,omitempty" bson:"blob_id,omitempty"`
+	LFSSha256   string `json:"lfs_sha256,omitempty" bson:"lfs_sha256,omitempty"`
+	Size        int64  `json:"size,omitempty" bson:"size,omitempty"`
+	Cached      bool   `json:"cached,omitempty" bson:"cached,omitempty"`
+	// fetch ke waqt mila cached outcome; content download nahi hua isliye scan inhi se hota hai
+	CachedMatches []BLOB_MATCH `json:"-" bson:"-"`
+	// full, sample ya stream (fetch policy ka decision)
+	FetchMode string `json:"fetch_mode,omitempty" bson:"fetch_mode,omitempty"`
+	Truncated bool   `json:"truncated,omitempty" bson:"truncated,omitempty"`
+	// object store ka version (S3 versionId), git wale sources me khaali
+	VersionID string `json:"version_id,omitempty" bson:"version_id,omitempty"`
+	// container image: kis layer ki file hai, aur kya baad ki layer ne use delete / overwrite kar diya
+	LayerDigest string `json:"layer_digest,omitempty" bson:"layer_digest,omitempty"`
+	Shadowed    bool   `json:"shadowed,omitempty" bson:"shadowed,omitempty"`
+}
+
+// image config ke wo hisse jisme build ke waqt secrets aa jaate hai (ENV, LABEL, CMD, RUN history)
+type IMAGE_CONFIG struct {
+	Digest     string          `json:"digest" bson:"digest"`
+	Env        []string        `json:"env,omitempty" bson:"env,omitempty"`
+	Labels     []string        `json:"labels,omitempty" bson:"labels,omitempty"`
+	Entrypoint []string        `json:"entrypoint,omitempty" bson:"entrypoint,omitempty"`
+	Cmd        []string        `json:"cmd,omitempty" bson:"cmd,omitempty"`
+	History    []IMAGE_HISTORY `json:"history,omitempty" bson:"history,omitempty"`
+}
+
+type IMAGE_HISTORY struct {
+	CreatedBy string `json:"created_by" bson:"created_by"`
+	// jo layer is step ne banayi; ENV / LABEL jaise steps layer nahi banate
+	LayerDigest string `json:"layer_digest,omitempty" bson:"layer_digest,omitempty"`
+}
+
+// jo file fetch / scan nahi hui aur kyun (extension, size, lfs, lfs_pointer)
+type SKIPPED_FILE struct {
+	ResourceID string `json:"resource_id,omitempty" bson:"resource_id,omitempty"`
+	RFilename  string `json:"rfilename" bson:"rfilename"`
+	Reason     string `json:"reason" bson:"reason"`
+	Size       int64  `json:"size,omitempty" bson:"size,omitempty"`
+}
+
+// jo file / discussion list fetch karte waqt fail hui, status 0 matlab network / read error
+type FAILED_ITEM struct {
+	ResourceID string `json:"resource_id,omitempty" bson:"resource_id,omitempty"`
+	Name       string `json:"name" bson:"name"`
+	Status     int    `json:"status,omitempty" bson:"status,omitempty"`
+	Error      string `json:"error" bson:"error"`
+}
+
+// scan ne repo ka kitna hissa sach me dekha;
+// kuch bhi fail / truncate / size ki wajah se skip hua to status "partial"
+type SCAN_COVERAGE struct {
+	Status             string         `json:"status" bson:"status"`
+	FilesConsidered    int            `json:"files_considered" bson:"files_considered"`
+	FilesScanned       int            `json:"files_scanned" bson:"files_scanned"`
+	Skipped            []SKIPPED_FILE `json:"skipped" bson:"skipped"`
+	Truncated          []SKIPPED_FILE `json:"truncated" bson:"truncated"`
+	Failed             []FAILED_ITEM  `json:"failed" bson:"failed"`
+	DiscussionsFetched int            `json:"discussions_fetched" bson:"discussions_fetched"`
+	DiscussionsFailed  []FAILED_ITEM  `json:"discussions_failed" bson:"discussions_failed"`
+	// PR diffs / commit history jo maange the par fetch nahi hue
+	PullRequestsFailed []FAILED_ITEM `json:"pull_requests_failed" bson:"pull_requests_failed"`
+	HistoryFailed      []FAILED_ITEM `json:"history_failed" bson:"history_failed"`
+}
+
+// ek blob (file content) ke scan ka nateeja, resource / file name se independent
+// taaki same blob kisi bhi repo me mile to dobara download aur scan na karna pade
+type BLOB_MATCH struct {
+	SecretType string `json:"secret_type" bson:"secret_type"`
+	Pattern    string `json:"pattern" bson:"pattern"`
+	Secret     string `json:"secret" bson:"secret"`
+	Line       int    `json:"line" bson:"line"`
+	Cell       int    `json:"cell,omitempty" bson:"cell,omitempty"`
+	CellLine   int    `json:"cell_line,omitempty" bson:"cell_line,omitempty"`
+}
+
+type BLOB_SCAN_CACHE struct {
+	mgm.DefaultModel `bson:",inline"`
+	BlobKey          string       `json:"blob_key" bson:"blob_key"`
+	RuleSetVersion   string       `json:"rule_set_version" bson:"rule_set_version"`
+	Matches          []BLOB_MATCH `json:"matches" bson:"matches"`
+}
+
+// PR ki diff, har commit ki alag (agar mili) warna poore PR ki ek saath
+type PR_COMMIT struct {
+	OID     string `json:"oid" bson:"oid"`
+	Subject string `json:"subject" bson:"subject"`
+	Diff    string `json:"diff,omitempty" bson:"diff,omitempty"`
+}
+
+type PULL_REQUEST struct {
+	Num            int64       `json:"num" bson:"num"`
+	Title          string      `json:"title" bson:"title"`
+	Status         string      `json:"status" bson:"status"`
+	AuthorName     string      `json:"author_name" bson:"author_name"`
+	TargetBranch   string      `json:"target_branch,omitempty" bson:"target_branch,omitempty"`
+	MergeCommitOID string      `json:"merge_commit_oid,omitempty" bson:"merge_commit_oid,omitempty"`
+	Diff           string      `json:"diff,omitempty" bson:"diff,omitempty"`
+	Commits        []PR_COMMIT `json:"commits,omitempty" bson:"commits,omitempty"`
+}
+
+// repo history ka ek commit aur uski diff, oldest first store hote hai
+type COMMIT_DIFF struct {
+	OID   string `json:"oid" bson:"oid"`
+	Title string `json:"title" bson:"title"`
+	Date  string `json:"date" bson:"date"`
+	Diff  string `json:"diff,omitempty" bson:"diff,omitempty"`
+	// diff fetch nahi hui; khaali Diff wala commit (jisme kuch badla hi nahi) isse alag hai
+	Failed bool `json:"failed,omitempty" bson:"failed,omitempty"`
 }
 
 type AI_REQUEST struct {
 	mgm.DefaultModel `bson:",inline"`
-	RequestID        string       `json:"request_id" bson:"request_id"`
-	ResourceType     string       `json:"resource_type,omitempty" bson:"resource_type,omitempty"`
-	ResourceID       string       `json:"resource_id,omitempty" bson:"resource_id,omitempty"`
-	Siblings         []SIBLING    `json:"siblings" bson:"siblings"`
-	Discussions      []DISCUSSION `json:"discussions" bson:"discussions"`
+	RequestID        string         `json:"request_id" bson:"request_id"`
+	Provider         string         `json:"provider,omitempty" bson:"provider,omitempty"`
+	ResourceType     string         `json:"resource_type,omitempty" bson:"resource_type,omitempty"`
+	ResourceID       string         `json:"resource_id,omitempty" bson:"resource_id,omitempty"`
+	Siblings         []SIBLING      `json:"siblings" bson:"siblings"`
+	Discussions      []DISCUSSION   `json:"discussions" bson:"discussions"`
+	PullRequests     []PULL_REQUEST `json:"pull_requests,omitempty" bson:"pull_requests,omitempty"`
+	History          []COMMIT_DIFF  `json:"history,omitempty" bson:"history,omitempty"`
+	ImageConfig      *IMAGE_CONFIG  `json:"image_config,omitempty" bson:"image_config,omitempty"`
+	CommitSHA        string         `json:"sha,omitempty" bson:"sha,omitempty"`
+	LastModified     string         `json:"last_modified,omitempty" bson:"last_modified,omitempty"`
+	Skipped          []SKIPPED_FILE `json:"skipped_files,omitempty" bson:"skipped_files,omitempty"`
+	Failed           []FAILED_ITEM  `json:"failed_files,omitempty" bson:"failed_files,omitempty"`
+	// discussion / PR list hi fetch nahi hui
+	DiscussionsFailed []FAILED_ITEM `json:"discussions_failed,omitempty" bson:"discussions_failed,omitempty"`
+	// PR diffs / commit history (ya unka koi hissa) fetch nahi hua
+	PullRequestsFailed []FAILED_ITEM `json:"pull_requests_failed,omitempty" bson:"pull_requests_failed,omitempty"`
+	HistoryFailed      []FAILED_ITEM `json:"history_failed,omitempty" bson:"history_failed,omitempty"`
+}
+
+// resource ka last successful scan: kis commit pe hua aur file / history findings kya the,
+// agli baar isi se decide hota hai ki full, incremental ya reused scan karna hai
+type RESOURCE_SCAN_STATE struct {
+	mgm.DefaultModel `bson:",inline"`
+	Provider         string    `json:"provider,omitempty" bson:"provider,omitempty"`
+	ResourceType     string    `json:"resource_type" bson:"resource_type"`
+	ResourceID       string    `json:"resource_id" bson:"resource_id"`
+	CommitSHA        string    `json:"sha" bson:"sha"`
+	LastModified     string    `json:"last_modified,omitempty" bson:"last_modified,omitempty"`
+	RequestID        string    `json:"request_id" bson:"request_id"`
+	RuleSetVersion   string    `json:"rule_set_version" bson:"rule_set_version"`
+	HistoryScanned   bool      `json:"history_scanned" bson:"history_scanned"`
+	Findings         []Finding `json:"findings" bson:"findings"`
 }
 
 type Finding struct {
@@ -62,35 +212,179 @@ type Finding struct {
 	Pattern         string `json:"pattern" bson:"pattern"`
 	Secret          string `json:"secret" bson:"secret"`
 	SourceType      string `json:"source_type" bson:"source_type"`
+	Provider        string `json:"provider,omitempty" bson:"provider,omitempty"`
 	Organization    string `json:"organization,omitempty" bson:"organization,omitempty"`
 	ResourceID      string `json:"resource_id,omitempty" bson:"resource_id,omitempty"`
 	ResourceType    string `json:"resource_type,omitempty" bson:"resource_type,omitempty"`
 	URL             string `json:"url,omitempty" bson:"url,omitempty"`
 	FileName        string `json:"file_name,omitempty" bson:"file_name,omitempty"`
 	Line            int    `json:"line,omitempty" bson:"line,omitempty"`
+	Cell            int    `json:"cell,omitempty" bson:"cell,omitempty"` // .ipynb: notebook cell (1 se), CellLine us cell ke source me
+	CellLine        int    `json:"cell_line,omitempty" bson:"cell_line,omitempty"`
 	DiscussionNum   int64  `json:"discussion_num,omitempty" bson:"discussion_num,omitempty"`
 	DiscussionTitle string `json:"discussion_title,omitempty" bson:"discussion_title,omitempty"`
 	DiscussionRepo  string `json:"discussion_repo,omitempty" bson:"discussion_repo,omitempty"`
+	CommentID       string `json:"comment_id,omitempty" bson:"comment_id,omitempty"`
+	CommentAuthor   string `json:"comment_author,omitempty" bson:"comment_author,omitempty"`
+	CommentCreated  string `json:"comment_created_at,omitempty" bson:"comment_created_at,omitempty"`
+	PRNum           int64  `json:"pr_num,omitempty" bson:"pr_num,omitempty"`
+	CommitSHA       string `json:"commit_sha,omitempty" bson:"commit_sha,omitempty"`
+	VersionID       string `json:"version_id,omitempty" bson:"version_id,omitempty"`
+	LayerDigest     string `json:"layer_digest,omitempty" bson:"layer_digest,omitempty"`
+	FirstCommit     string `json:"first_commit,omitempty" bson:"first_commit,omitempty"`
+	LastCommit      string `json:"last_commit,omitempty" bson:"last_commit,omitempty"`
+	StillAtHead     *bool  `json:"still_at_head,omitempty" bson:"still_at_head,omitempty"`
+	// imported findings (gitleaks / trufflehog / SARIF): dusre tool ka naam, uski di hui severity aur fingerprint;
+	// khaali ho to severity secret type se aur fingerprint finding ke fields se banta hai
+	Tool        string `json:"tool,omitempty" bson:"tool,omitempty"`
+	Severity    string `json:"severity,omitempty" bson:"severity,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty" bson:"fingerprint,omitempty"`
 }
 
 type SCANNED_RESOURCE struct {
+	Provider string    `json:"provider,omitempty" bson:"provider,omitempty"`
 	Type     string    `json:"type" bson:"type"`
 	ID       string    `json:"id" bson:"id"`
 	Findings []Finding `json:"findings" bson:"findings"`
+	ScanMode string    `json:"scan_mode,omitempty" bson:"scan_mode,omitempty"`
 }
 
 type SCAN_RESULT struct {
 	mgm.DefaultModel `bson:",inline"`
 	RequestID        string             `json:"request_id" bson:"request_id"`
 	ScannedResources []SCANNED_RESOURCE `json:"scanned_resources" bson:"scanned_resources"`
+	Coverage         *SCAN_COVERAGE     `json:"coverage,omitempty" bson:"coverage,omitempty"`
+	Status           string             `json:"status,omitempty" bson:"status,omitempty"`
+	// scan kis rule set pe hua: version rules ka hash, revision saved RULE_SET ka number (0 = built-in rules)
+	RuleSetVersion  string `json:"rule_set_version,omitempty" bson:"rule_set_version,omitempty"`
+	RuleSetRevision int    `json:"rule_set_revision,omitempty" bson:"rule_set_revision,omitempty"`
+}
+
+// scanner ka ek rule; id name ka slug hai, tests save ke time chalte hai (match wale milne chahiye, no_match wale nahi)
+type RULE struct {
+	ID          string           `json:"id" bson:"id"`
+	Name        string           `json:"name" bson:"name"`
+	Description string           `json:"description,omitempty" bson:"description,omitempty"`
+	Regex       string           `json:"regex" bson:"regex"`
+	Keywords    []string         `json:"keywords,omitempty" bson:"keywords,omitempty"`
+	Severity    string           `json:"severity,omitempty" bson:"severity,omitempty"`
+	Tags        []string         `json:"tags,omitempty" bson:"tags,omitempty"`
+	References  []string         `json:"references,omitempty" bson:"references,omitempty"`
+	Enabled     bool             `json:"enabled" bson:"enabled"`
+	Tests       RULE_TESTS       `json:"tests" bson:"tests"`
+	SecretGroup int              `json:"secret_group,omitempty" bson:"secret_group,omitempty"`
+	Entropy     float64          `json:"entropy,omitempty" bson:"entropy,omitempty"`
+	Path        string           `json:"path,omitempty" bson:"path,omitempty"`
+	Allowlists  []RULE_ALLOWLIST `json:"allowlists,omitempty" bson:"allowlists,omitempty"`
+	// rule kahan se aaya: khaali = API / built-in, warna rules file ya import ka naam
+	Source string `json:"source,omitempty" bson:"source,omitempty"`
+}
+
+// gitleaks allowlist: condition "OR" (default) me koi ek, "AND" me saare set criteria match hone chahiye
+type RULE_ALLOWLIST struct {
+	Description string   `json:"description,omitempty" bson:"description,omitempty"`
+	Condition   string   `json:"condition,omitempty" bson:"condition,omitempty"`
+	Commits     []string `json:"commits,omitempty" bson:"commits,omitempty"`
+	Paths       []string `json:"paths,omitempty" bson:"paths,omitempty"`
+	Regexes     []string `json:"regexes,omitempty" bson:"regexes,omitempty"`
+	RegexTarget string   `json:"regex_target,omitempty" bson:"regex_target,omitempty"`
+	StopWords   []string `json:"stopwords,omitempty" bson:"stopwords,omitempty"`
+}
+
+type RULE_TESTS struct {
+	Match   []string `json:"match,omitempty" bson:"match,omitempty"`
+	NoMatch []string `json:"no_match,omitempty" bson:"no_match,omitempty"`
+}
+
+// rules ka ek immutable snapshot; har change nayi revision banata hai, sabse badi revision scanner me active
+type RULE_SET struct {
+	mgm.DefaultModel `bson:",inline"`
+	Revision         int    `json:"revision" bson:"revision"`
+	Version          string `json:"version" bson:"version"`
+	Rules            []RULE `json:"rules" bson:"rules"`
+	Change           string `json:"change" bson:"change"`
+	// RULES_FILE ka jo content is revision tak merge ho chuka; har save ke saath aage chalta hai
+	RulesFile *RULES_FILE_STATE `json:"rules_file,omitempty" bson:"rules_file,omitempty"`
+}
+
+// rules file ka hash aur uske har rule ka hash jaisa merge hua tha; rule ka hash ab alag ho to API ne use badla hai
+type RULES_FILE_STATE struct {
+	Source string            `json:"source" bson:"source"`
+	Hash   string            `json:"hash" bson:"hash"`
+	Rules  []RULES_FILE_RULE `json:"rules,omitempty" bson:"rules,omitempty"`
+}
+
+type RULES_FILE_RULE struct {
+	ID   string `json:"id" bson:"id"`
+	Hash string `json:"hash" bson:"hash"`
 }
 
 type ScanRequestBody struct {
+	// provider khaali ho to huggingface; baaki providers ke liye resource_type + resource_id (ya org)
+	Provider           string `json:"provider"`
+	ResourceType       string `json:"resource_type"`
+	ResourceID         string `json:"resource_id"`
+	Ref                string `json:"ref"`
+	GitScope           string `json:"git_scope"`
 	ModelID            string `json:"model_id"`
 	DatasetID          string `json:"dataset_id"`
 	SpaceID            string `json:"space_id"`
 	Org                string `json:"org"`
 	User               string `json:"user"`
+	Collection         string `json:"collection"`
 	IncludeDiscussions bool   `json:"include_discussions"`
 	IncludePRs         bool   `json:"include_prs"`
+	DiscussionStatus   string `json:"discussion_status"`
+	DiscussionsSince   string `json:"discussions_since"`
+	ScanPRDiffs        bool   `json:"scan_pr_diffs"`
+	ScanHistory        bool   `json:"scan_history"`
+	FullRescan         bool   `json:"full_rescan"`
+}
+
+// HF search jisse discovery job repos dhundhta hai; author me glob (acme-*) bhi chalega
+type DISCOVERY_QUERY struct {
+	ResourceType string   `json:"resource_type" bson:"resource_type"`
+	Search       string   `json:"search,omitempty" bson:"search,omitempty"`
+	Author       string   `json:"author,omitempty" bson:"author,omitempty"`
+	Tags         []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	Sort         string   `json:"sort,omitempty" bson:"sort,omitempty"`
+	Limit        int      `json:"limit,omitempty" bson:"limit,omitempty"`
+}
+
+type DISCOVERY_BUDGET struct {
+	MaxRepos int   `json:"max_repos" bson:"max_repos"`
+	MaxBytes int64 `json:"max_bytes" bson:"max_bytes"`
+}
+
+// search ka ek result aur uske saath kya hua: scanned, deduped, failed ya budget_exhausted
+type DISCOVERY_HIT struct {
+	ResourceType string `json:"resource_type" bson:"resource_type"`
+	ResourceID   string `json:"resource_id" bson:"resource_id"`
+	CommitSHA    string `json:"sha,omitempty" bson:"sha,omitempty"`
+	Status       string `json:"status" bson:"status"`
+	ScanMode     string `json:"scan_mode,omitempty" bson:"scan_mode,omitempty"`
+	Findings     int    `json:"findings" bson:"findings"`
+	Bytes        int64  `json:"bytes" bson:"bytes"`
+	Error        string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// ek search query ka poora discovery run, findings SCAN_RESULT (request_id = discovery-<job_id>) me jaati hai
+type DISCOVERY_JOB struct {
+	mgm.DefaultModel `bson:",inline"`
+	JobID            string           `json:"job_id" bson:"job_id"`
+	Query            DISCOVERY_QUERY  `json:"query" bson:"query"`
+	Budget           DISCOVERY_BUDGET `json:"budget" bson:"budget"`
+	Status           string           `json:"status" bson:"status"`
+	Error            string           `json:"error,omitempty" bson:"error,omitempty"`
+	ReposListed      int              `json:"repos_listed" bson:"repos_listed"`
+	ReposScanned     int              `json:"repos_scanned" bson:"repos_scanned"`
+	ReposDeduped     int              `json:"repos_deduped" bson:"repos_deduped"`
+	ReposFailed      int              `json:"repos_failed" bson:"repos_failed"`
+	BytesScanned     int64            `json:"bytes_scanned" bson:"bytes_scanned"`
+	BudgetExhausted  string           `json:"budget_exhausted,omitempty" bson:"budget_exhausted,omitempty"`
+	TotalFindings    int              `json:"total_findings" bson:"total_findings"`
+	FindingsByType   map[string]int   `json:"findings_by_type" bson:"findings_by_type"`
+	Hits             []DISCOVERY_HIT  `json:"hits" bson:"hits"`
+	ScanResultID     string           `json:"scan_result_id,omitempty" bson:"scan_result_id,omitempty"`
+	FinishedAt       string           `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
 }
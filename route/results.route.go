@@ -9,7 +9,11 @@ func SetupResultRoutes(app *fiber.App) {
 
 	api := app.Group("/api")
 
+	// export pehle, warna "/results/:scan_id" usko scan id samajh leta
+	api.Get("/results/export", controller.ExportResults)
+	api.Get("/results/:scan_id/export", controller.ExportScanResult)
 	api.Get("/results/:scan_id", controller.GetScanResult)
 	api.Get("/results", controller.GetAllResults)
 	api.Get("/dashboard", controller.GetDashboard)
+	api.Get("/scheduler", controller.GetSchedulerStats)
 }
@@ -0,0 +1,24 @@
+package route
+
+import (
+	"github.com/MishraShardendu22/Scanner/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRuleRoutes(app *fiber.App) {
+
+	api := app.Group("/api")
+
+	// versions aur import pehle, warna "/rules/:id" unko rule id samajh leta
+	api.Get("/rules/versions", controller.GetRuleSetVersions)
+	api.Get("/rules/versions/:revision", controller.GetRuleSetVersion)
+	api.Post("/rules/versions/:revision/restore", controller.RestoreRuleSetVersion)
+
+	api.Post("/rules/import", controller.ImportRules)
+
+	api.Get("/rules", controller.GetRules)
+	api.Post("/rules", controller.CreateRule)
+	api.Get("/rules/:id", controller.GetRule)
+	api.Put("/rules/:id", controller.UpdateRule)
+	api.Delete("/rules/:id", controller.DeleteRule)
+}
@@ -10,8 +10,17 @@ func SetupScanRoutes(app *fiber.App) {
 	api := app.Group("/api")
 	api.Post("/scan", controller.UnifiedScan)
 	api.Post("/store", controller.StoreScanResult)
+	// gitleaks / trufflehog / SARIF report import
+	api.Post("/import", controller.ImportScanResult)
+
+	// search query se public Hub pe discovery, background job
+	api.Post("/discovery", controller.StartDiscovery)
+	api.Get("/discovery", controller.GetAllDiscoveryJobs)
+	api.Get("/discovery/:job_id", controller.GetDiscoveryJob)
 
 	scanAPI := app.Group("/api/scan")
+	// static path pehle, warna "/:request_id" isko kha jaayega
+	scanAPI.Post("/hub-cache", controller.ScanHubCache)
 	scanAPI.Post("/:request_id", controller.ScanRequest)
 	scanAPI.Post("/by-id/:id", controller.ScanByID)
 	scanAPI.Post("/org/:org/models", controller.ScanOrgModels)
@@ -41,7 +41,7 @@ func ScanForm() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"w-full\"><div class=\"bg-black border-4 border-yellow-400 shadow-lg p-8\"><h2 class=\"text-3xl font-bold mb-6 text-yellow-400\">Start New Security Scan</h2><form id=\"scanForm\" hx-post=\"/scan\" hx-trigger=\"submit\" hx-target=\"#scanResults\" hx-swap=\"innerHTML\" hx-indicator=\"#loadingSpinner\"><!-- Two Column Layout --><div class=\"grid grid-cols-1 lg:grid-cols-2 gap-8\"><!-- Left Column - Resource Type Selection --><div><label class=\"block text-yellow-400 font-semibold mb-4 text-lg\">Select Resource Type</label><div class=\"space-y-4\"><label class=\"cursor-pointer block\"><input type=\"radio\" name=\"resourceType\" value=\"model\" class=\"peer sr-only\" checked><div class=\"border-2 border-yellow-400 peer-checked:bg-yellow-400 bg-black p-6 text-center transition hover:bg-yellow-400 group\"><i class=\"fas fa-robot text-4xl mb-3 text-yellow-400 group-hover:text-black\"></i><div class=\"font-bold text-yellow-400 group-hover:text-black text-xl\">AI Model</div></div></label> <label class=\"cursor-pointer block\"><input type=\"radio\" name=\"resourceType\" value=\"dataset\" class=\"peer sr-only\"><div class=\"border-2 border-yellow-400 peer-checked:bg-yellow-400 bg-black p-6 text-center transition hover:bg-yellow-400 group\"><i class=\"fas fa-database text-4xl mb-3 text-yellow-400 group-hover:text-black\"></i><div class=\"font-bold text-yellow-400 group-hover:text-black text-xl\">Dataset</div></div></label> <label class=\"cursor-pointer block\"><input type=\"radio\" name=\"resourceType\" value=\"space\" class=\"peer sr-only\"><div class=\"border-2 border-yellow-400 peer-checked:bg-yellow-400 bg-black p-6 text-center transition hover:bg-yellow-400 group\"><i class=\"fas fa-cube text-4xl mb-3 text-yellow-400 group-hover:text-black\"></i><div class=\"font-bold text-yellow-400 group-hover:text-black text-xl\">Space</div></div></label></div></div><!-- Right Column - Input Fields --><div class=\"space-y-6\"><!-- Organization/User --><div><label for=\"org\" class=\"block text-yellow-400 font-semibold mb-2\">Organization / User</label> <input type=\"text\" id=\"org\" name=\"org\" placeholder=\"e.g., huggingface, meta-llama\" class=\"w-full px-4 py-3 border-2 border-yellow-400 bg-black text-yellow-400 focus:ring-2 focus:ring-yellow-400 focus:border-yellow-400 placeholder-gray-600\" required></div><!-- Resource ID --><div><label for=\"resourceId\" class=\"block text-yellow-400 font-semibold mb-2\">Resource ID</label> <input type=\"text\" id=\"resourceId\" name=\"resourceId\" placeholder=\"e.g., bert-base-uncased, my-dataset\" class=\"w-full px-4 py-3 border-2 border-yellow-400 bg-black text-yellow-400 focus:ring-2 focus:ring-yellow-400 focus:border-yellow-400 placeholder-gray-600\" required></div><!-- Options --><div class=\"bg-black border-2 border-yellow-400 p-4 space-y-3\"><h3 class=\"font-semibold text-yellow-400 mb-3\">Scan Options</h3><label class=\"flex items-center space-x-3 cursor-pointer\"><input type=\"checkbox\" name=\"includePRs\" class=\"w-5 h-5 text-yellow-400 border-yellow-400 focus:ring-2 focus:ring-yellow-400\"> <span class=\"text-white\">Include Pull Requests</span></label> <label class=\"flex items-center space-x-3 cursor-pointer\"><input type=\"checkbox\" name=\"includeDiscussions\" class=\"w-5 h-5 text-yellow-400 border-yellow-400 focus:ring-2 focus:ring-yellow-400\"> <span class=\"text-white\">Include Discussions</span></label></div><!-- Submit Button --><div class=\"flex space-x-4\"><button type=\"submit\" class=\"flex-1 bg-yellow-400 hover:bg-yellow-500 text-black font-bold py-3 px-6 transition shadow-lg\">Start Scan</button> <button type=\"reset\" class=\"bg-black border-2 border-yellow-400 hover:bg-yellow-400 hover:text-black text-white font-bold py-3 px-6 transition\">Reset</button></div></div></div></form><!-- Results Section --><div id=\"scanResults\" class=\"mt-8 hidden\"><div class=\"border-t-2 border-yellow-400 pt-6\"><h3 class=\"text-2xl font-bold mb-4 text-yellow-400\"><i class=\"fas fa-chart-bar mr-2 text-yellow-400\"></i> Scan Results</h3><div id=\"resultsContent\" class=\"space-y-4\"><!-- Results will be inserted here dynamically --></div></div></div><!-- Loading Spinner --><div id=\"loadingSpinner\" class=\"mt-8 text-center htmx-indicator\"><div class=\"inline-block rounded-full h-12 w-12 border-4 border-gray-700 border-t-yellow-400\" style=\"animation: spin 1s linear infinite;\"></div><p class=\"mt-4 text-gray-300 font-medium\">Scanning in progress...</p></div></div></div><style>\n\t\t\t@keyframes spin {\n\t\t\t\t0% { transform: rotate(0deg); }\n\t\t\t\t100% { transform: rotate(360deg); }\n\t\t\t}\n\t\t</style> <script>\n\t\t\t// Handle resource type selection to show icons\n\t\t\tdocument.querySelectorAll('input[name=\"resourceType\"]').forEach(radio => {\n\t\t\t\tradio.addEventListener('change', function() {\n\t\t\t\t\t// Remove selected styles from all\n\t\t\t\t\tdocument.querySelectorAll('input[name=\"resourceType\"]').forEach(r => {\n\t\t\t\t\t\tconst container = r.nextElementSibling;\n\t\t\t\t\t\tconst icon = container.querySelector('i');\n\t\t\t\t\t\tconst text = container.querySelector('div');\n\t\t\t\t\t\t\n\t\t\t\t\t\tcontainer.classList.remove('bg-yellow-400');\n\t\t\t\t\t\tcontainer.classList.add('bg-black');\n\t\t\t\t\t\ticon.classList.remove('text-black');\n\t\t\t\t\t\ticon.classList.add('text-yellow-400');\n\t\t\t\t\t\ttext.classList.remove('text-black');\n\t\t\t\t\t\ttext.classList.add('text-yellow-400');\n\t\t\t\t\t});\n\t\t\t\t\t\n\t\t\t\t\t// Add selected styles to checked one\n\t\t\t\t\tconst container = this.nextElementSibling;\n\t\t\t\t\tconst icon = container.querySelector('i');\n\t\t\t\t\tconst text = container.querySelector('div');\n\t\t\t\t\t\n\t\t\t\t\tcontainer.classList.remove('bg-black');\n\t\t\t\t\tcontainer.classList.add('bg-yellow-400');\n\t\t\t\t\ticon.classList.remove('text-yellow-400');\n\t\t\t\t\ticon.classList.add('text-black');\n\t\t\t\t\ttext.classList.remove('text-yellow-400');\n\t\t\t\t\ttext.classList.add('text-black');\n\t\t\t\t});\n\t\t\t});\n\n\t\t\t// Set initial state on page load\n\t\t\twindow.addEventListener('DOMContentLoaded', function() {\n\t\t\t\tconst checkedRadio = document.querySelector('input[name=\"resourceType\"]:checked');\n\t\t\t\tif (checkedRadio) {\n\t\t\t\t\tconst container = checkedRadio.nextElementSibling;\n\t\t\t\t\tconst icon = container.querySelector('i');\n\t\t\t\t\tconst text = container.querySelector('div');\n\t\t\t\t\t\n\t\t\t\t\tcontainer.classList.remove('bg-black');\n\t\t\t\t\tcontainer.classList.add('bg-yellow-400');\n\t\t\t\t\ticon.classList.remove('text-yellow-400');\n\t\t\t\t\ticon.classList.add('text-black');\n\t\t\t\t\ttext.classList.remove('text-yellow-400');\n\t\t\t\t\ttext.classList.add('text-black');\n\t\t\t\t}\n\t\t\t});\n\n\t\t\tdocument.getElementById('scanForm').addEventListener('submit', async (e) => {\n\t\t\t\te.preventDefault();\n\n\t\t\t\tconst formData = new FormData(e.target);\n\t\t\t\tconst resourceType = formData.get('resourceType');\n\t\t\t\tconst org = formData.get('org');\n\t\t\t\tconst resourceId = formData.get('resourceId');\n\t\t\t\tconst includePRs = formData.get('includePRs') === 'on';\n\t\t\t\tconst includeDiscussions = formData.get('includeDiscussions') === 'on';\n\n\t\t\t\tconst payload = {\n\t\t\t\t\torg: org,\n\t\t\t\t\tinclude_prs: includePRs,\n\t\t\t\t\tinclude_discussions: includeDiscussions\n\t\t\t\t};\n\n\t\t\t\tif (resourceType === 'model') payload.model_id = resourceId;\n\t\t\t\telse if (resourceType === 'dataset') payload.dataset_id = resourceId;\n\t\t\t\telse if (resourceType === 'space') payload.space_id = resourceId;\n\n\t\t\t\t// Show loading\n\t\t\t\tdocument.getElementById('loadingSpinner').classList.remove('hidden');\n\t\t\t\tdocument.getElementById('scanResults').classList.add('hidden');\n\n\t\t\t\ttry {\n\t\t\t\t\tconst response = await fetch('/scan', {\n\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\theaders: { 'Content-Type': 'application/json' },\n\t\t\t\t\t\tbody: JSON.stringify(payload)\n\t\t\t\t\t});\n\n\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t// Hide loading\n\t\t\t\t\tdocument.getElementById('loadingSpinner').classList.add('hidden');\n\n\t\t\t\t\tif (result.status === 'success') {\n\t\t\t\t\t\t// Redirect to results page\n\t\t\t\t\t\twindow.location.href = `/results/${result.data.request_id}`;\n\t\t\t\t\t} else {\n\t\t\t\t\t\talert('Scan failed: ' + (result.message || 'Unknown error'));\n\t\t\t\t\t}\n\t\t\t\t} catch (error) {\n\t\t\t\t\tdocument.getElementById('loadingSpinner').classList.add('hidden');\n\t\t\t\t\talert('Error: ' + error.message);\n\t\t\t\t}\n\t\t\t});\n\t\t</script>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"w-full\"><div class=\"bg-black border-4 border-yellow-400 shadow-lg p-8\"><h2 class=\"text-3xl font-bold mb-6 text-yellow-400\">Start New Security Scan</h2><form id=\"scanForm\" hx-post=\"/scan\" hx-trigger=\"submit\" hx-target=\"#scanResults\" hx-swap=\"innerHTML\" hx-indicator=\"#loadingSpinner\"><!-- Two Column Layout --><div class=\"grid grid-cols-1 lg:grid-cols-2 gap-8\"><!-- Left Column - Resource Type Selection --><div><label class=\"block text-yellow-400 font-semibold mb-4 text-lg\">Select Resource Type</label><div class=\"space-y-4\"><label class=\"cursor-pointer block\"><input type=\"radio\" name=\"resourceType\" value=\"model\" class=\"peer sr-only\" checked><div class=\"border-2 border-yellow-400 peer-checked:bg-yellow-400 bg-black p-6 text-center transition hover:bg-yellow-400 group\"><i class=\"fas fa-robot text-4xl mb-3 text-yellow-400 group-hover:text-black\"></i><div class=\"font-bold text-yellow-400 group-hover:text-black text-xl\">AI Model</div></div></label> <label class=\"cursor-pointer block\"><input type=\"radio\" name=\"resourceType\" value=\"dataset\" class=\"peer sr-only\"><div class=\"border-2 border-yellow-400 peer-checked:bg-yellow-400 bg-black p-6 text-center transition hover:bg-yellow-400 group\"><i class=\"fas fa-database text-4xl mb-3 text-yellow-400 group-hover:text-black\"></i><div class=\"font-bold text-yellow-400 group-hover:text-black text-xl\">Dataset</div></div></label> <label class=\"cursor-pointer block\"><input type=\"radio\" name=\"resourceType\" value=\"space\" class=\"peer sr-only\"><div class=\"border-2 border-yellow-400 peer-checked:bg-yellow-400 bg-black p-6 text-center transition hover:bg-yellow-400 group\"><i class=\"fas fa-cube text-4xl mb-3 text-yellow-400 group-hover:text-black\"></i><div class=\"font-bold text-yellow-400 group-hover:text-black text-xl\">Space</div></div></label></div></div><!-- Right Column - Input Fields --><div class=\"space-y-6\"><!-- Organization/User --><div><label for=\"org\" class=\"block text-yellow-400 font-semibold mb-2\">Organization / User</label> <input type=\"text\" id=\"org\" name=\"org\" placeholder=\"e.g., huggingface, meta-llama\" class=\"w-full px-4 py-3 border-2 border-yellow-400 bg-black text-yellow-400 focus:ring-2 focus:ring-yellow-400 focus:border-yellow-400 placeholder-gray-600\" required></div><!-- Resource ID --><div><label for=\"resourceId\" class=\"block text-yellow-400 font-semibold mb-2\">Resource ID</label> <input type=\"text\" id=\"resourceId\" name=\"resourceId\" placeholder=\"e.g., bert-base-uncased, my-dataset\" class=\"w-full px-4 py-3 border-2 border-yellow-400 bg-black text-yellow-400 focus:ring-2 focus:ring-yellow-400 focus:border-yellow-400 placeholder-gray-600\" required></div><!-- Options --><div class=\"bg-black border-2 border-yellow-400 p-4 space-y-3\"><h3 class=\"font-semibold text-yellow-400 mb-3\">Scan Options</h3><label class=\"flex items-center space-x-3 cursor-pointer\"><input type=\"checkbox\" name=\"includePRs\" class=\"w-5 h-5 text-yellow-400 border-yellow-400 focus:ring-2 focus:ring-yellow-400\"> <span class=\"text-white\">Include Pull Requests</span></label> <label class=\"flex items-center space-x-3 cursor-pointer\"><input type=\"checkbox\" name=\"includeDiscussions\" class=\"w-5 h-5 text-yellow-400 border-yellow-400 focus:ring-2 focus:ring-yellow-400\"> <span class=\"text-white\">Include Discussions</span></label></div><!-- Submit Button --><div class=\"flex space-x-4\"><button type=\"submit\" class=\"flex-1 bg-yellow-400 hover:bg-yellow-500 text-black font-bold py-3 px-6 transition shadow-lg\">Start Scan</button> <button type=\"reset\" class=\"bg-black border-2 border-yellow-400 hover:bg-yellow-400 hover:text-black text-white font-bold py-3 px-6 transition\">Reset</button></div></div></div></form><!-- Results Section --><div id=\"scanResults\" class=\"mt-8 hidden\"><div class=\"border-t-2 border-yellow-400 pt-6\"><h3 class=\"text-2xl font-bold mb-4 text-yellow-400\"><i class=\"fas fa-chart-bar mr-2 text-yellow-400\"></i> Scan Results</h3><div id=\"resultsContent\" class=\"space-y-4\"><!-- Results will be inserted here dynamically --></div></div></div><!-- Loading Spinner --><div id=\"loadingSpinner\" class=\"mt-8 text-center htmx-indicator\"><div class=\"inline-block rounded-full h-12 w-12 border-4 border-gray-700 border-t-yellow-400\" style=\"animation: spin 1s linear infinite;\"></div><p class=\"mt-4 text-gray-300 font-medium\">Scanning in progress...</p></div></div></div><style>\n\t\t\t@keyframes spin {\n\t\t\t\t0% { transform: rotate(0deg); }\n\t\t\t\t100% { transform: rotate(360deg); }\n\t\t\t}\n\t\t</style> <script>\n\t\t\t// Handle resource type selection to show icons\n\t\t\tdocument.querySelectorAll('input[name=\"resourceType\"]').forEach(radio => {\n\t\t\t\tradio.addEventListener('change', function() {\n\t\t\t\t\t// Remove selected styles from all\n\t\t\t\t\tdocument.querySelectorAll('input[name=\"resourceType\"]').forEach(r => {\n\t\t\t\t\t\tconst container = r.nextElementSibling;\n\t\t\t\t\t\tconst icon = container.querySelector('i');\n\t\t\t\t\t\tconst text = container.querySelector('div');\n\t\t\t\t\t\t\n\t\t\t\t\t\tcontainer.classList.remove('bg-yellow-400');\n\t\t\t\t\t\tcontainer.classList.add('bg-black');\n\t\t\t\t\t\ticon.classList.remove('text-black');\n\t\t\t\t\t\ticon.classList.add('text-yellow-400');\n\t\t\t\t\t\ttext.classList.remove('text-black');\n\t\t\t\t\t\ttext.classList.add('text-yellow-400');\n\t\t\t\t\t});\n\t\t\t\t\t\n\t\t\t\t\t// Add selected styles to checked one\n\t\t\t\t\tconst container = this.nextElementSibling;\n\t\t\t\t\tconst icon = container.querySelector('i');\n\t\t\t\t\tconst text = container.querySelector('div');\n\t\t\t\t\t\n\t\t\t\t\tcontainer.classList.remove('bg-black');\n\t\t\t\t\tcontainer.classList.add('bg-yellow-400');\n\t\t\t\t\ticon.classList.remove('text-yellow-400');\n\t\t\t\t\ticon.classList.add('text-black');\n\t\t\t\t\ttext.classList.remove('text-yellow-400');\n\t\t\t\t\ttext.classList.add('text-black');\n\t\t\t\t});\n\t\t\t});\n\n\t\t\t// Set initial state on page load\n\t\t\twindow.addEventListener('DOMContentLoaded', function() {\n\t\t\t\tconst checkedRadio = document.querySelector('input[name=\"resourceType\"]:checked');\n\t\t\t\tif (checkedRadio) {\n\t\t\t\t\tconst container = checkedRadio.nextElementSibling;\n\t\t\t\t\tconst icon = container.querySelector('i');\n\t\t\t\t\tconst text = container.querySelector('div');\n\t\t\t\t\t\n\t\t\t\t\tcontainer.classList.remove('bg-black');\n\t\t\t\t\tcontainer.classList.add('bg-yellow-400');\n\t\t\t\t\ticon.classList.remove('text-yellow-400');\n\t\t\t\t\ticon.classList.add('text-black');\n\t\t\t\t\ttext.classList.remove('text-yellow-400');\n\t\t\t\t\ttext.classList.add('text-black');\n\t\t\t\t}\n\t\t\t});\n\n\t\t\tdocument.getElementById('scanForm').addEventListener('submit', async (e) => {\n\t\t\t\te.preventDefault();\n\n\t\t\t\tconst formData = new FormData(e.target);\n\t\t\t\tconst resourceType = formData.get('resourceType');\n\t\t\t\tconst org = formData.get('org');\n\t\t\t\tconst resourceId = formData.get('resourceId');\n\t\t\t\tconst includePRs = formData.get('includePRs') === 'on';\n\t\t\t\tconst includeDiscussions = formData.get('includeDiscussions') === 'on';\n\n\t\t\t\tconst payload = {\n\t\t\t\t\torg: org,\n\t\t\t\t\tinclude_prs: includePRs,\n\t\t\t\t\tinclude_discussions: includeDiscussions\n\t\t\t\t};\n\n\t\t\t\tif (resourceType === 'model') payload.model_id = resourceId;\n\t\t\t\telse if (resourceType === 'dataset') payload.dataset_id = resourceId;\n\t\t\t\telse if (resourceType === 'space') payload.space_id = resourceId;\n\n\t\t\t\t// Show loading\n\t\t\t\tdocument.getElementById('loadingSpinner').classList.remove('hidden');\n\t\t\t\tdocument.getElementById('scanResults').classList.add('hidden');\n\n\t\t\t\ttry {\n\t\t\t\t\tconst response = await fetch('/scan', {\n\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\theaders: { 'Content-Type': 'application/json' },\n\t\t\t\t\t\tbody: JSON.stringify(payload)\n\t\t\t\t\t});\n\n\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t// Hide loading\n\t\t\t\t\tdocument.getElementById('loadingSpinner').classList.add('hidden');\n\n\t\t\t\t\tif (result.status === 'success') {\n\t\t\t\t\t\t// Redirect to results page\n\t\t\t\t\t\twindow.location.href = `/results/${encodeURIComponent(result.data.request_id)}`;\n\t\t\t\t\t} else {\n\t\t\t\t\t\talert('Scan failed: ' + (result.message || 'Unknown error'));\n\t\t\t\t\t}\n\t\t\t\t} catch (error) {\n\t\t\t\t\tdocument.getElementById('loadingSpinner').classList.add('hidden');\n\t\t\t\t\talert('Error: ' + error.message);\n\t\t\t\t}\n\t\t\t});\n\t\t</script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
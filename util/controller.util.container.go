@@ -0,0 +1,640 @@
+package util
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// `docker save` ya OCI layout ka tarball disk se; resource id tarball ka absolute path, ref se image tag chun sakte hai
+const (
+	ProviderContainer = "container"
+	ResourceTypeImage = "images"
+)
+
+// tarball local file hai isliye LocalSource wala hi LOCAL_SCAN_ROOTS check lagta hai
+type ContainerSource struct {
+	AllowAnyPath bool
+}
+
+func init() {
+	RegisterSource(ContainerSource{})
+}
+
+func (ContainerSource) Name() string { return ProviderContainer }
+
+func (ContainerSource) ResourceTypes() []string { return []string{ResourceTypeImage} }
+
+func (s ContainerSource) ListItems(resourceType, owner string) ([]SourceItem, error) {
+	tarball, err := resolveLocalPath(owner, false, s.AllowAnyPath)
+	if err != nil {
+		return nil, err
+	}
+	return []SourceItem{{Provider: ProviderContainer, ResourceType: ResourceTypeImage, ResourceID: tarball}}, nil
+}
+
+// pehle pass me manifest + config (env, labels, history), doosre pass me har layer ka filesystem.
+// image id (config digest) hi commit sha hai, same image dobara aaye to pichla scan reuse
+func (s ContainerSource) Fetch(aiRequest *models.AI_REQUEST, resourceType, resourceID string, opts FetchOptions) (*RescanPlan, error) {
+	start := time.Now()
+	traceID := uuid.New().String()
+
+	log.Printf(
+		"op=ContainerSource.Fetch stage=start trace_id=%s tarball=%s ref=%s",
+		traceID, resourceID, opts.Ref,
+	)
+
+	tarball, err := resolveLocalPath(resourceID, false, s.AllowAnyPath)
+	if err != nil {
+		return nil, err
+	}
+	image, err := readImageIndex(tarball, opts.Ref)
+	if err != nil {
+		log.Printf(
+			"op=ContainerSource.Fetch stage=index_error trace_id=%s tarball=%s error=%v elapsed=%s",
+			traceID, tarball, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	aiRequest.Provider = ProviderContainer
+	aiRequest.ResourceType = ResourceTypeImage
+	aiRequest.ResourceID = tarball
+	aiRequest.CommitSHA = image.config.Digest
+	aiRequest.ImageConfig = &image.config
+
+	plan := PlanForCommit(aiRequest, opts)
+	if plan.Mode != RescanReused {
+		aiRequest.Siblings, aiRequest.Skipped, aiRequest.Failed = readImageLayers(tarball, image.layers)
+	}
+
+	log.Printf(
+		"op=ContainerSource.Fetch stage=success trace_id=%s tarball=%s image=%s layers=%d files=%d skipped=%d failed=%d scan_mode=%s elapsed=%s",
+		traceID, tarball, image.config.Digest, len(image.layers), len(aiRequest.Siblings), len(aiRequest.Skipped), len(aiRequest.Failed), plan.Mode, time.Since(start),
+	)
+	return plan, nil
+}
+
+// tarball ke andar file ka path fragment me (layer digest finding pe alag se hota hai)
+func (ContainerSource) FileURL(resourceType, resourceID, revision, fileName string, line int) string {
+	fragment := fileName
+	if line > 0 {
+		fragment = fmt.Sprintf("%s:%d", fileName, line)
+	}
+	fileURL := url.URL{Scheme: "file", Path: resourceID, Fragment: fragment}
+	return fileURL.String()
+}
+
+type imageLayer struct {
+	// outer tarball me layer ki entry ("<id>/layer.tar" ya "blobs/sha256/<hex>")
+	entry  string
+	digest string
+}
+
+type imageIndex struct {
+	config models.IMAGE_CONFIG
+	layers []imageLayer
+	// history ke har step ke liye: ENV / LABEL jaise steps koi layer nahi banate
+	emptyLayer []bool
+}
+
+// image config json ke kaam ke fields
+type imageConfigFile struct {
+	Config struct {
+		Env        []string          `json:"Env"`
+		Labels     map[string]string `json:"Labels"`
+		Entrypoint []string          `json:"Entrypoint"`
+		Cmd        []string          `json:"Cmd"`
+	} `json:"config"`
+	History []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	} `json:"history"`
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+// docker save ka tarball gzip karke bhi rakha jaata hai (docker save | gzip)
+func openImageTar(tarball string) (*tar.Reader, io.Closer, error) {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := decompressedReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return tar.NewReader(reader), f, nil
+}
+
+// magic bytes dekh ke gzip khol deta hai; zstd layers ke liye decoder nahi hai
+func decompressedReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	switch {
+	case len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		return gzip.NewReader(br)
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return nil, fmt.Errorf("zstd compressed layers are not supported")
+	}
+	return br, nil
+}
+
+// "./blobs/sha256/x" -> "blobs/sha256/x", "/" aur ".." wale paths bhi image root ke andar
+func cleanTarPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func blobEntry(digest string) string {
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
+
+// pehla pass: chhoti json entries (manifest.json, index.json, manifests, config) memory me
+func readImageIndex(tarball, ref string) (*imageIndex, error) {
+	tr, closer, err := openImageTar(tarball)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	const maxMetadataBytes = 8 << 20
+	metadata := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", tarball, err)
+		}
+		if !hdr.FileInfo().Mode().IsRegular() || hdr.Size > maxMetadataBytes {
+			continue
+		}
+		name := cleanTarPath(hdr.Name)
+		// layers bhi blobs/ me hote hai, json sirf "{" / "[" (manifest.json) se shuru hone wali entries
+		br := bufio.NewReader(tr)
+		if first, err := br.Peek(1); err != nil || (first[0] != '{' && first[0] != '[') {
+			continue
+		}
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		metadata[name] = data
+	}
+
+	if manifest, ok := metadata["manifest.json"]; ok {
+		return dockerSaveIndex(manifest, metadata, ref)
+	}
+	if index, ok := metadata["index.json"]; ok {
+		return ociLayoutIndex(index, metadata, ref)
+	}
+	return nil, fmt.Errorf("%s is not a docker save or OCI layout tarball (no manifest.json / index.json)", tarball)
+}
+
+func dockerSaveIndex(manifest []byte, metadata map[string][]byte, ref string) (*imageIndex, error) {
+	var entries []struct {
+		Config   string   `json:"Config"`
+		RepoTags []string `json:"RepoTags"`
+		Layers   []string `json:"Layers"`
+	}
+	if err := json.Unmarshal(manifest, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest.json: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("manifest.json has no images")
+	}
+
+	chosen := 0
+	if ref != "" {
+		chosen = -1
+		for i, entry := range entries {
+			for _, tag := range entry.RepoTags {
+				if tag == ref || tag == ref+":latest" {
+					chosen = i
+				}
+			}
+		}
+		if chosen < 0 {
+			return nil, fmt.Errorf("image %q not found in manifest.json", ref)
+		}
+	}
+	entry := entries[chosen]
+
+	configEntry := cleanTarPath(entry.Config)
+	raw, ok := metadata[configEntry]
+	if !ok {
+		return nil, fmt.Errorf("image config %s missing from tarball", entry.Config)
+	}
+	// image id config json ka sha256 hai
+	sum := sha256.Sum256(raw)
+	index, err := parseImageConfig(raw, "sha256:"+hex.EncodeToString(sum[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	// naye docker (OCI wale) tarball me layer entry hi digest hai, purane me "<id>/layer.tar" to diff_id
+	var diffIDs []string
+	var config imageConfigFile
+	if err := json.Unmarshal(raw, &config); err == nil {
+		diffIDs = config.RootFS.DiffIDs
+	}
+	for i, layer := range entry.Layers {
+		name := cleanTarPath(layer)
+		digest := ""
+		if strings.HasPrefix(name, "blobs/") {
+			digest = strings.Replace(strings.TrimPrefix(name, "blobs/"), "/", ":", 1)
+		} else if i < len(diffIDs) {
+			digest = diffIDs[i]
+		}
+		index.layers = append(index.layers, imageLayer{entry: name, digest: digest})
+	}
+	assignHistoryLayers(index)
+	return index, nil
+}
+
+func ociLayoutIndex(raw []byte, metadata map[string][]byte, ref string) (*imageIndex, error) {
+	manifest, err := resolveOCIManifest(raw, metadata, ref, 0)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Config ociDescriptor   `json:"config"`
+		Layers []ociDescriptor `json:"layers"`
+	}
+	if err := json.Unmarshal(manifest, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse image manifest: %w", err)
+	}
+	config, ok := metadata[blobEntry(body.Config.Digest)]
+	if !ok {
+		return nil, fmt.Errorf("image config %s missing from tarball", body.Config.Digest)
+	}
+	index, err := parseImageConfig(config, body.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range body.Layers {
+		index.layers = append(index.layers, imageLayer{entry: blobEntry(layer.Digest), digest: layer.Digest})
+	}
+	assignHistoryLayers(index)
+	return index, nil
+}
+
+// index -> (nested index, multi-platform) -> image manifest; ref "org.opencontainers.image.ref.name" ya
+// containerd ke image name se match hota hai, warna linux/amd64 ya pehla asli manifest
+func resolveOCIManifest(raw []byte, metadata map[string][]byte, ref string, depth int) ([]byte, error) {
+	if depth > 4 {
+		return nil, fmt.Errorf("OCI index nesting too deep")
+	}
+	var index struct {
+		Manifests []ociDescriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse OCI index: %w", err)
+	}
+
+	var chosen *ociDescriptor
+	for i := range index.Manifests {
+		desc := &index.Manifests[i]
+		// attestation manifests (platform unknown/unknown) image nahi hai
+		if desc.Platform != nil && desc.Platform.OS == "unknown" {
+			continue
+		}
+		if ref != "" && depth == 0 {
+			if desc.Annotations["org.opencontainers.image.ref.name"] == ref || desc.Annotations["io.containerd.image.name"] == ref {
+				chosen = desc
+				break
+			}
+			continue
+		}
+		if chosen == nil || (desc.Platform != nil && desc.Platform.OS == "linux" && desc.Platform.Architecture == "amd64") {
+			chosen = desc
+		}
+	}
+	if chosen == nil {
+		if ref != "" && depth == 0 {
+			return nil, fmt.Errorf("image %q not found in index.json", ref)
+		}
+		return nil, fmt.Errorf("OCI index has no image manifests")
+	}
+
+	blob, ok := metadata[blobEntry(chosen.Digest)]
+	if !ok {
+		return nil, fmt.Errorf("manifest %s missing from tarball", chosen.Digest)
+	}
+	if strings.Contains(chosen.MediaType, "index") || strings.Contains(chosen.MediaType, "manifest.list") {
+		return resolveOCIManifest(blob, metadata, ref, depth+1)
+	}
+	return blob, nil
+}
+
+func parseImageConfig(raw []byte, digest string) (*imageIndex, error) {
+	var config imageConfigFile
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse image config: %w", err)
+	}
+	labels := make([]string, 0, len(config.Config.Labels))
+	for key, value := range config.Config.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	index := &imageIndex{config: models.IMAGE_CONFIG{
+		Digest:     digest,
+		Env:        config.Config.Env,
+		Labels:     labels,
+		Entrypoint: config.Config.Entrypoint,
+		Cmd:        config.Config.Cmd,
+	}}
+	for _, step := range config.History {
+		index.config.History = append(index.config.History, models.IMAGE_HISTORY{CreatedBy: step.CreatedBy})
+		index.emptyLayer = append(index.emptyLayer, step.EmptyLayer)
+	}
+	return index, nil
+}
+
+// history ke non-empty steps order me layers banate hai
+func assignHistoryLayers(index *imageIndex) {
+	next := 0
+	for i := range index.config.History {
+		if index.emptyLayer[i] {
+			continue
+		}
+		if next < len(index.layers) {
+			index.config.History[i].LayerDigest = index.layers[next].digest
+		}
+		next++
+	}
+}
+
+// ek layer me kya hai: files (overwrite check ke liye), whiteouts aur opaque dirs
+type layerChanges struct {
+	files     map[string]bool
+	whiteouts map[string]bool
+	opaque    map[string]bool
+}
+
+// ek layer tar ek baar padha gaya to uska result; same entry se bani saari layers yahi share karti hai
+type layerRead struct {
+	files   []models.SIBLING
+	skipped []models.SKIPPED_FILE
+	change  *layerChanges
+	// siblings me is read ki files kahan se shuru hai; -1 jab tak kisi layer ko mili nahi
+	at int
+}
+
+// doosra pass: har layer ka tar padh ke text files policy ke hisaab se; baad me dekhte hai kaunsi file
+// kisi upar wali layer ne delete (whiteout) ya overwrite kar di, wo Shadowed hoti hai par scan phir bhi hoti hai.
+// purana `docker save` same content wali layer ko dobara nahi likhta, "<id>/layer.tar" doosri layer ke tar
+// ka symlink hota hai; aisi layer target entry ka hi content leti hai (target pehle aaye ya baad me)
+func readImageLayers(tarball string, layers []imageLayer) ([]models.SIBLING, []models.SKIPPED_FILE, []models.FAILED_ITEM) {
+	siblings := []models.SIBLING{}
+	siblingLayer := []int{}
+	skipped := []models.SKIPPED_FILE{}
+	failed := []models.FAILED_ITEM{}
+	policy := DefaultFetchPolicy()
+
+	// ek entry kai layers ki ho sakti hai (same layer image me do baar)
+	layerIndex := map[string][]int{}
+	for i, layer := range layers {
+		layerIndex[layer.entry] = append(layerIndex[layer.entry], i)
+	}
+	changes := make([]*layerChanges, len(layers))
+	reads := map[string]*layerRead{}
+	// link entry -> jis entry pe point karta hai, aur jo layers abhi target ka wait kar rahi hai
+	links := map[string]string{}
+	waiting := map[string][]int{}
+
+	use := func(read *layerRead, indices []int) {
+		for _, idx := range indices {
+			changes[idx] = read.change
+		}
+		// files ek hi baar, sabse upar wali layer ke naam se; neeche wali copy usi ne overwrite ki hai
+		top := indices[0]
+		for _, idx := range indices {
+			top = max(top, idx)
+		}
+		if read.at >= 0 {
+			for k := range read.files {
+				siblingLayer[read.at+k] = max(siblingLayer[read.at+k], top)
+			}
+			return
+		}
+		read.at = len(siblings)
+		for range read.files {
+			siblingLayer = append(siblingLayer, top)
+		}
+		siblings = append(siblings, read.files...)
+		skipped = append(skipped, read.skipped...)
+	}
+
+	tr, closer, err := openImageTar(tarball)
+	if err != nil {
+		return siblings, skipped, append(failed, models.FAILED_ITEM{Name: tarball, Error: err.Error()})
+	}
+	defer closer.Close()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			failed = append(failed, models.FAILED_ITEM{Name: tarball, Error: err.Error()})
+			break
+		}
+		entry := cleanTarPath(hdr.Name)
+		indices := layerIndex[entry]
+
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+			target := cleanTarPath(hdr.Linkname)
+			if hdr.Typeflag == tar.TypeSymlink {
+				target = cleanTarPath(path.Join(path.Dir(entry), hdr.Linkname))
+			}
+			links[entry] = target
+			// link ka link bhi ho sakta hai
+			for hops := 0; hops < 8; hops++ {
+				next, ok := links[target]
+				if !ok {
+					break
+				}
+				target = next
+			}
+			if waiters := waiting[entry]; len(waiters) > 0 {
+				delete(waiting, entry)
+				indices = append(indices, waiters...)
+			}
+			if len(indices) == 0 {
+				continue
+			}
+			if read, ok := reads[target]; ok {
+				use(read, indices)
+			} else {
+				waiting[target] = append(waiting[target], indices...)
+			}
+			continue
+		}
+
+		indices = append(indices, waiting[entry]...)
+		delete(waiting, entry)
+		if len(indices) == 0 || reads[entry] != nil {
+			continue
+		}
+		sort.Ints(indices)
+		layer := layers[indices[len(indices)-1]]
+		read := &layerRead{at: -1, change: &layerChanges{files: map[string]bool{}, whiteouts: map[string]bool{}, opaque: map[string]bool{}}}
+		reads[entry] = read
+
+		var err2 error
+		read.files, read.skipped, err2 = readLayer(tr, layer.digest, policy, read.change)
+		if err2 != nil {
+			failed = append(failed, models.FAILED_ITEM{Name: layer.digest, Error: err2.Error()})
+		}
+		use(read, indices)
+		log.Printf(
+			"op=readImageLayers stage=layer_done tarball=%s layer=%s entries=%d whiteouts=%d files=%d",
+			tarball, layer.digest, len(read.change.files), len(read.change.whiteouts)+len(read.change.opaque), len(read.files),
+		)
+	}
+
+	for i, layer := range layers {
+		if changes[i] != nil {
+			continue
+		}
+		if target, ok := links[layer.entry]; ok {
+			failed = append(failed, models.FAILED_ITEM{Name: layer.digest, Error: fmt.Sprintf("layer %s links to %s which is missing from tarball", layer.entry, target)})
+			continue
+		}
+		failed = append(failed, models.FAILED_ITEM{Name: layer.digest, Error: fmt.Sprintf("layer %s missing from tarball", layer.entry)})
+	}
+	for i := range siblings {
+		siblings[i].Shadowed = shadowedByLaterLayer(changes, siblingLayer[i], siblings[i].RFilename)
+	}
+	return siblings, skipped, failed
+}
+
+// ek layer tar ka har entry; jis file ka extension text wala nahi (.netrc, id_rsa, credentials) uske
+// pehle 8KB dekh ke decide hota hai, binary nikli to skip record nahi hota (OS images me hazaaron binaries hoti hai)
+func readLayer(r io.Reader, digest string, policy FetchPolicy, change *layerChanges) ([]models.SIBLING, []models.SKIPPED_FILE, error) {
+	files := []models.SIBLING{}
+	skipped := []models.SKIPPED_FILE{}
+
+	reader, err := decompressedReader(r)
+	if err != nil {
+		return files, skipped, err
+	}
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, skipped, nil
+		}
+		if err != nil {
+			return files, skipped, err
+		}
+		name := cleanTarPath(hdr.Name)
+		if name == "" {
+			continue
+		}
+		dir, base := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case base == ".wh..wh..opq":
+			change.opaque[dir] = true
+			continue
+		case strings.HasPrefix(base, ".wh."):
+			change.whiteouts[path.Join(dir, strings.TrimPrefix(base, ".wh."))] = true
+			continue
+		case hdr.Typeflag == tar.TypeDir:
+			continue
+		}
+		change.files[name] = true
+
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		var body io.Reader = tr
+		if !TextExtensions[strings.ToLower(path.Ext(name))] {
+			head := make([]byte, binarySniffBytes)
+			n, err := io.ReadFull(tr, head)
+			if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+				return files, skipped, err
+			}
+			if LooksBinary(string(head[:n])) {
+				continue
+			}
+			body = io.MultiReader(bytes.NewReader(head[:n]), tr)
+		}
+
+		sibling := models.SIBLING{RFilename: name, Size: hdr.Size, LayerDigest: digest}
+		action, reason := policy.Decide(sibling)
+		if action == FetchSkip {
+			skipped = append(skipped, models.SKIPPED_FILE{RFilename: name, Reason: reason, Size: hdr.Size})
+			continue
+		}
+		sibling.FetchMode = string(action)
+		sibling, reason, err = siblingFromBody(sibling, body, policy, action)
+		if err != nil {
+			return files, skipped, err
+		}
+		if reason != "" {
+			skipped = append(skipped, models.SKIPPED_FILE{RFilename: name, Reason: reason, Size: hdr.Size})
+			continue
+		}
+		files = append(files, sibling)
+	}
+}
+
+// baad ki kisi layer ne same path likha, path ya uski koi parent dir whiteout ki, parent opaque ki,
+// ya parent ki jagah file rakh di to final image me ye file nahi dikhti
+func shadowedByLaterLayer(changes []*layerChanges, layer int, name string) bool {
+	for j := layer + 1; j < len(changes); j++ {
+		change := changes[j]
+		if change == nil {
+			continue
+		}
+		if change.files[name] || change.whiteouts[name] {
+			return true
+		}
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			if dir == "." {
+				dir = ""
+			}
+			if change.opaque[dir] || change.whiteouts[dir] || change.files[dir] {
+				return true
+			}
+			if dir == "" {
+				break
+			}
+		}
+	}
+	return false
+}
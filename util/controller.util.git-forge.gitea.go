@@ -0,0 +1,194 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// Gitea ya uska api bolne wala koi bhi forge (Forgejo, Codeberg), GITEA_BASE_URL se
+type giteaAPI struct {
+	baseURL string
+	token   string
+}
+
+func newGiteaAPI() giteaAPI {
+	return giteaAPI{baseURL: forgeBaseURL("GITEA_BASE_URL", "https://gitea.com"), token: GetEnv("GITEA_TOKEN", "")}
+}
+
+func (g giteaAPI) client() *http.Client { return ProviderHTTPClient("gitea") }
+
+func (g giteaAPI) auth(req *http.Request) {
+	if g.token != "" {
+		req.Header.Set("Authorization", "token "+g.token)
+	}
+}
+
+func (g giteaAPI) api() string {
+	return g.baseURL + "/api/v1"
+}
+
+func (g giteaAPI) listRepos(owner string) ([]SourceItem, error) {
+	type repo struct {
+		FullName  string `json:"full_name"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	items := []SourceItem{}
+	collect := func(r repo) bool {
+		items = append(items, SourceItem{ResourceID: r.FullName, LastModified: r.UpdatedAt})
+		return true
+	}
+
+	err := forgeGetPaged(fmt.Sprintf("%s/orgs/%s/repos?limit=50", g.api(), url.PathEscape(owner)), g, collect)
+	if StatusFromError(err) == http.StatusNotFound {
+		err = forgeGetPaged(fmt.Sprintf("%s/users/%s/repos?limit=50", g.api(), url.PathEscape(owner)), g, collect)
+	}
+	return items, err
+}
+
+// commits?sha= branch, tag aur sha teeno samajhta hai
+func (g giteaAPI) resolveRef(repo, ref string) (string, error) {
+	if ref == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := forgeGet(fmt.Sprintf("%s/repos/%s", g.api(), escapeOwnerRepo(repo)), g, &info); err != nil {
+			return "", err
+		}
+		ref = info.DefaultBranch
+	}
+	var commits []struct {
+		SHA string `json:"sha"`
+	}
+	if _, err := forgeGet(fmt.Sprintf("%s/repos/%s/commits?sha=%s&limit=1&stat=false", g.api(), escapeOwnerRepo(repo), url.QueryEscape(ref)), g, &commits); err != nil {
+		return "", err
+	}
+	if len(commits) == 0 || commits[0].SHA == "" {
+		return "", fmt.Errorf("ref %q not found in %s", ref, repo)
+	}
+	return commits[0].SHA, nil
+}
+
+// Gitea recursive tree bhi pages me deta hai, truncated = aur pages baaki hai
+func (g giteaAPI) listTree(repo, sha string) ([]models.SIBLING, bool, error) {
+	files := []models.SIBLING{}
+	for page := 1; page <= forgeMaxPages(); page++ {
+		var tree struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+				SHA  string `json:"sha"`
+				Size int64  `json:"size"`
+			} `json:"tree"`
+			Truncated bool `json:"truncated"`
+		}
+		treeURL := fmt.Sprintf("%s/repos/%s/git/trees/%s?recursive=true&per_page=1000&page=%d", g.api(), escapeOwnerRepo(repo), sha, page)
+		if _, err := forgeGet(treeURL, g, &tree); err != nil {
+			return files, false, err
+		}
+		for _, entry := range tree.Tree {
+			if entry.Type == "blob" {
+				files = append(files, models.SIBLING{RFilename: entry.Path, BlobID: entry.SHA, Size: entry.Size})
+			}
+		}
+		if !tree.Truncated || len(tree.Tree) == 0 {
+			return files, false, nil
+		}
+	}
+	return files, true, nil
+}
+
+func (g giteaAPI) fileRequest(repo, sha, path string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/repos/%s/raw/%s?ref=%s", g.api(), escapeOwnerRepo(repo), escapeRepoPath(path), sha), nil)
+	if err != nil {
+		return nil, err
+	}
+	g.auth(req)
+	return req, nil
+}
+
+func (g giteaAPI) listThreads(repo string, includeIssues, includePRs bool, limit int) ([]models.DISCUSSION, error) {
+	type issue struct {
+		Number      int64      `json:"number"`
+		Title       string     `json:"title"`
+		Body        string     `json:"body"`
+		State       string     `json:"state"`
+		CreatedAt   string     `json:"created_at"`
+		User        githubUser `json:"user"`
+		PullRequest *struct {
+			Merged bool `json:"merged"`
+		} `json:"pull_request"`
+	}
+	threads := []models.DISCUSSION{}
+	// issues aur PRs ka apna apna limit
+	collect := func() func(issue) bool {
+		count := 0
+		return func(it issue) bool {
+			isPR := it.PullRequest != nil
+			status := it.State
+			if isPR && it.PullRequest.Merged {
+				status = "merged"
+			}
+			threads = append(threads, forgeThread(repo, it.Number, it.Title, it.Body, status, it.CreatedAt, it.User.Login, isPR))
+			count++
+			return count < limit
+		}
+	}
+
+	if includeIssues {
+		if err := forgeGetPaged(fmt.Sprintf("%s/repos/%s/issues?state=all&type=issues&limit=50", g.api(), escapeOwnerRepo(repo)), g, collect()); err != nil {
+			return threads, err
+		}
+	}
+	if includePRs {
+		if err := forgeGetPaged(fmt.Sprintf("%s/repos/%s/issues?state=all&type=pulls&limit=50", g.api(), escapeOwnerRepo(repo)), g, collect()); err != nil {
+			return threads, err
+		}
+	}
+	return threads, nil
+}
+
+func (g giteaAPI) threadComments(repo string, disc models.DISCUSSION) ([]models.DISCUSSION_EVENT, error) {
+	type comment struct {
+		ID        int64      `json:"id"`
+		Body      string     `json:"body"`
+		CreatedAt string     `json:"created_at"`
+		User      githubUser `json:"user"`
+	}
+	var comments []comment
+	if _, err := forgeGet(fmt.Sprintf("%s/repos/%s/issues/%d/comments", g.api(), escapeOwnerRepo(repo), disc.Num), g, &comments); err != nil {
+		return nil, err
+	}
+	events := []models.DISCUSSION_EVENT{}
+	for _, c := range comments {
+		events = append(events, models.DISCUSSION_EVENT{
+			ID:         fmt.Sprintf("%d", c.ID),
+			Type:       "comment",
+			AuthorName: c.User.Login,
+			CreatedAt:  c.CreatedAt,
+			Content:    c.Body,
+		})
+	}
+	return events, nil
+}
+
+func (g giteaAPI) fileURL(repo, revision, path string, line int) string {
+	if revision == "" {
+		revision = "HEAD"
+	}
+	return fmt.Sprintf("%s/%s/src/commit/%s/%s%s", g.baseURL, escapeOwnerRepo(repo), revision, escapeRepoPath(path), lineAnchor(line))
+}
+
+func (g giteaAPI) discussionURL(repo string, num int64, isPullRequest bool, commentID string) string {
+	kind := "issues"
+	if isPullRequest {
+		kind = "pulls"
+	}
+	base := fmt.Sprintf("%s/%s/%s/%d", g.baseURL, escapeOwnerRepo(repo), kind, num)
+	if commentID == "" {
+		return base
+	}
+	return base + "#issuecomment-" + commentID
+}
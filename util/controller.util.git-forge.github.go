@@ -0,0 +1,191 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// GitHub.com ya GitHub Enterprise (GITHUB_API_URL=https://ghe.example.com/api/v3)
+type githubAPI struct {
+	apiURL string
+	webURL string
+	token  string
+}
+
+func newGitHubAPI() githubAPI {
+	apiURL := forgeBaseURL("GITHUB_API_URL", "https://api.github.com")
+	webURL := forgeBaseURL("GITHUB_WEB_URL", "")
+	if webURL == "" {
+		if apiURL == "https://api.github.com" {
+			webURL = "https://github.com"
+		} else {
+			webURL = strings.TrimSuffix(apiURL, "/api/v3")
+		}
+	}
+	return githubAPI{apiURL: apiURL, webURL: webURL, token: GetEnv("GITHUB_TOKEN", "")}
+}
+
+func (g githubAPI) client() *http.Client { return ProviderHTTPClient("github") }
+
+func (g githubAPI) auth(req *http.Request) {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+func (g githubAPI) listRepos(owner string) ([]SourceItem, error) {
+	type repo struct {
+		FullName string `json:"full_name"`
+		PushedAt string `json:"pushed_at"`
+	}
+	items := []SourceItem{}
+	collect := func(r repo) bool {
+		items = append(items, SourceItem{ResourceID: r.FullName, LastModified: r.PushedAt})
+		return true
+	}
+
+	// owner org bhi ho sakta hai aur user bhi
+	err := forgeGetPaged(fmt.Sprintf("%s/orgs/%s/repos?type=all&per_page=100", g.apiURL, url.PathEscape(owner)), g, collect)
+	if StatusFromError(err) == http.StatusNotFound {
+		err = forgeGetPaged(fmt.Sprintf("%s/users/%s/repos?type=all&per_page=100", g.apiURL, url.PathEscape(owner)), g, collect)
+	}
+	return items, err
+}
+
+func (g githubAPI) resolveRef(repo, ref string) (string, error) {
+	if ref == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := forgeGet(fmt.Sprintf("%s/repos/%s", g.apiURL, escapeOwnerRepo(repo)), g, &info); err != nil {
+			return "", err
+		}
+		ref = info.DefaultBranch
+	}
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	if _, err := forgeGet(fmt.Sprintf("%s/repos/%s/commits/%s", g.apiURL, escapeOwnerRepo(repo), url.PathEscape(ref)), g, &commit); err != nil {
+		return "", err
+	}
+	if commit.SHA == "" {
+		return "", fmt.Errorf("ref %q not found in %s", ref, repo)
+	}
+	return commit.SHA, nil
+}
+
+func (g githubAPI) listTree(repo, sha string) ([]models.SIBLING, bool, error) {
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+			SHA  string `json:"sha"`
+			Size int64  `json:"size"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	if _, err := forgeGet(fmt.Sprintf("%s/repos/%s/git/trees/%s?recursive=1", g.apiURL, escapeOwnerRepo(repo), sha), g, &tree); err != nil {
+		return nil, false, err
+	}
+	files := []models.SIBLING{}
+	for _, entry := range tree.Tree {
+		if entry.Type == "blob" {
+			files = append(files, models.SIBLING{RFilename: entry.Path, BlobID: entry.SHA, Size: entry.Size})
+		}
+	}
+	return files, tree.Truncated, nil
+}
+
+func (g githubAPI) fileRequest(repo, sha, path string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s", g.apiURL, escapeOwnerRepo(repo), escapeRepoPath(path), sha), nil)
+	if err != nil {
+		return nil, err
+	}
+	g.auth(req)
+	req.Header.Set("Accept", "application/vnd.github.raw")
+	return req, nil
+}
+
+// issues endpoint PRs bhi deta hai (pull_request field ke saath)
+func (g githubAPI) listThreads(repo string, includeIssues, includePRs bool, limit int) ([]models.DISCUSSION, error) {
+	type issue struct {
+		Number      int64      `json:"number"`
+		Title       string     `json:"title"`
+		Body        string     `json:"body"`
+		State       string     `json:"state"`
+		CreatedAt   string     `json:"created_at"`
+		User        githubUser `json:"user"`
+		PullRequest *struct {
+			MergedAt *string `json:"merged_at"`
+		} `json:"pull_request"`
+	}
+	threads := []models.DISCUSSION{}
+	// ek hi list me dono aate hai, par issues aur PRs ka limit alag alag; dono bhar jaaye tab ruko
+	issues, prs := 0, 0
+	err := forgeGetPaged(fmt.Sprintf("%s/repos/%s/issues?state=all&per_page=100", g.apiURL, escapeOwnerRepo(repo)), g, func(it issue) bool {
+		isPR := it.PullRequest != nil
+		if isPR && includePRs && prs < limit {
+			prs++
+		} else if !isPR && includeIssues && issues < limit {
+			issues++
+		} else {
+			return true
+		}
+		status := it.State
+		if isPR && it.PullRequest.MergedAt != nil {
+			status = "merged"
+		}
+		threads = append(threads, forgeThread(repo, it.Number, it.Title, it.Body, status, it.CreatedAt, it.User.Login, isPR))
+		return (includeIssues && issues < limit) || (includePRs && prs < limit)
+	})
+	return threads, err
+}
+
+func (g githubAPI) threadComments(repo string, disc models.DISCUSSION) ([]models.DISCUSSION_EVENT, error) {
+	type comment struct {
+		ID        int64      `json:"id"`
+		Body      string     `json:"body"`
+		CreatedAt string     `json:"created_at"`
+		User      githubUser `json:"user"`
+	}
+	events := []models.DISCUSSION_EVENT{}
+	err := forgeGetPaged(fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=100", g.apiURL, escapeOwnerRepo(repo), disc.Num), g, func(c comment) bool {
+		events = append(events, models.DISCUSSION_EVENT{
+			ID:         fmt.Sprintf("%d", c.ID),
+			Type:       "comment",
+			AuthorName: c.User.Login,
+			CreatedAt:  c.CreatedAt,
+			Content:    c.Body,
+		})
+		return true
+	})
+	return events, err
+}
+
+func (g githubAPI) fileURL(repo, revision, path string, line int) string {
+	if revision == "" {
+		revision = "HEAD"
+	}
+	return fmt.Sprintf("%s/%s/blob/%s/%s%s", g.webURL, escapeOwnerRepo(repo), revision, escapeRepoPath(path), lineAnchor(line))
+}
+
+func (g githubAPI) discussionURL(repo string, num int64, isPullRequest bool, commentID string) string {
+	kind := "issues"
+	if isPullRequest {
+		kind = "pull"
+	}
+	base := fmt.Sprintf("%s/%s/%s/%d", g.webURL, escapeOwnerRepo(repo), kind, num)
+	if commentID == "" {
+		return base
+	}
+	return base + "#issuecomment-" + commentID
+}
@@ -0,0 +1,200 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// gitlab.com ya self-hosted GitLab (GITLAB_BASE_URL), repo id "group/subgroup/project"
+type gitlabAPI struct {
+	baseURL string
+	token   string
+}
+
+func newGitLabAPI() gitlabAPI {
+	return gitlabAPI{baseURL: forgeBaseURL("GITLAB_BASE_URL", "https://gitlab.com"), token: GetEnv("GITLAB_TOKEN", "")}
+}
+
+func (g gitlabAPI) client() *http.Client { return ProviderHTTPClient("gitlab") }
+
+func (g gitlabAPI) auth(req *http.Request) {
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+}
+
+// api me project path url-encoded hota hai ("group/name" -> "group%2Fname")
+func (g gitlabAPI) project(repo string) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s", g.baseURL, url.PathEscape(repo))
+}
+
+type gitlabAuthor struct {
+	Username string `json:"username"`
+}
+
+func (g gitlabAPI) listRepos(owner string) ([]SourceItem, error) {
+	type project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		LastActivityAt    string `json:"last_activity_at"`
+	}
+	items := []SourceItem{}
+	collect := func(p project) bool {
+		items = append(items, SourceItem{ResourceID: p.PathWithNamespace, LastModified: p.LastActivityAt})
+		return true
+	}
+
+	err := forgeGetPaged(fmt.Sprintf("%s/api/v4/groups/%s/projects?include_subgroups=true&per_page=100", g.baseURL, url.PathEscape(owner)), g, collect)
+	if StatusFromError(err) == http.StatusNotFound {
+		err = forgeGetPaged(fmt.Sprintf("%s/api/v4/users/%s/projects?per_page=100", g.baseURL, url.PathEscape(owner)), g, collect)
+	}
+	return items, err
+}
+
+func (g gitlabAPI) resolveRef(repo, ref string) (string, error) {
+	if ref == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := forgeGet(g.project(repo), g, &info); err != nil {
+			return "", err
+		}
+		ref = info.DefaultBranch
+	}
+	var commit struct {
+		ID string `json:"id"`
+	}
+	if _, err := forgeGet(fmt.Sprintf("%s/repository/commits/%s", g.project(repo), url.PathEscape(ref)), g, &commit); err != nil {
+		return "", err
+	}
+	if commit.ID == "" {
+		return "", fmt.Errorf("ref %q not found in %s", ref, repo)
+	}
+	return commit.ID, nil
+}
+
+// GitLab tree me size nahi aata, to size wali policy fetch ke waqt hi lagti hai (ReadBody ki limit)
+func (g gitlabAPI) listTree(repo, sha string) ([]models.SIBLING, bool, error) {
+	type entry struct {
+		ID   string `json:"id"`
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	files := []models.SIBLING{}
+	entries := 0
+	err := forgeGetPaged(fmt.Sprintf("%s/repository/tree?ref=%s&recursive=true&per_page=100", g.project(repo), sha), g, func(e entry) bool {
+		if e.Type == "blob" {
+			files = append(files, models.SIBLING{RFilename: e.Path, BlobID: e.ID})
+		}
+		entries++
+		return true
+	})
+	// max pages tak bhare hue pages aaye to listing shayad wahi ruk gayi
+	truncated := entries >= forgeMaxPages()*100
+	return files, truncated, err
+}
+
+func (g gitlabAPI) fileRequest(repo, sha, path string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/repository/files/%s/raw?ref=%s", g.project(repo), url.PathEscape(path), sha), nil)
+	if err != nil {
+		return nil, err
+	}
+	g.auth(req)
+	return req, nil
+}
+
+// issues aur merge requests alag endpoints hai, dono ka number (iid) project ke andar hai
+func (g gitlabAPI) listThreads(repo string, includeIssues, includePRs bool, limit int) ([]models.DISCUSSION, error) {
+	type item struct {
+		IID         int64        `json:"iid"`
+		Title       string       `json:"title"`
+		Description string       `json:"description"`
+		State       string       `json:"state"`
+		CreatedAt   string       `json:"created_at"`
+		Author      gitlabAuthor `json:"author"`
+	}
+	threads := []models.DISCUSSION{}
+	// issues aur MRs ka apna apna limit, warna bahut saare issues MRs ko dekhne hi nahi dete
+	collect := func(isPR bool) func(item) bool {
+		count := 0
+		return func(it item) bool {
+			threads = append(threads, forgeThread(repo, it.IID, it.Title, it.Description, gitlabStatus(it.State), it.CreatedAt, it.Author.Username, isPR))
+			count++
+			return count < limit
+		}
+	}
+
+	if includeIssues {
+		if err := forgeGetPaged(fmt.Sprintf("%s/issues?scope=all&per_page=100", g.project(repo)), g, collect(false)); err != nil {
+			return threads, err
+		}
+	}
+	if includePRs {
+		if err := forgeGetPaged(fmt.Sprintf("%s/merge_requests?state=all&per_page=100", g.project(repo)), g, collect(true)); err != nil {
+			return threads, err
+		}
+	}
+	return threads, nil
+}
+
+func (g gitlabAPI) threadComments(repo string, disc models.DISCUSSION) ([]models.DISCUSSION_EVENT, error) {
+	type note struct {
+		ID        int64        `json:"id"`
+		Body      string       `json:"body"`
+		CreatedAt string       `json:"created_at"`
+		Author    gitlabAuthor `json:"author"`
+		System    bool         `json:"system"`
+	}
+	kind := "issues"
+	if disc.IsPullRequest {
+		kind = "merge_requests"
+	}
+	events := []models.DISCUSSION_EVENT{}
+	err := forgeGetPaged(fmt.Sprintf("%s/%s/%d/notes?per_page=100", g.project(repo), kind, disc.Num), g, func(n note) bool {
+		// "changed the description" jaise system notes me user ka text nahi hota
+		if n.System {
+			return true
+		}
+		events = append(events, models.DISCUSSION_EVENT{
+			ID:         fmt.Sprintf("%d", n.ID),
+			Type:       "comment",
+			AuthorName: n.Author.Username,
+			CreatedAt:  n.CreatedAt,
+			Content:    n.Body,
+		})
+		return true
+	})
+	return events, err
+}
+
+func (g gitlabAPI) fileURL(repo, revision, path string, line int) string {
+	if revision == "" {
+		revision = "HEAD"
+	}
+	return fmt.Sprintf("%s/%s/-/blob/%s/%s%s", g.baseURL, repo, revision, escapeRepoPath(path), lineAnchor(line))
+}
+
+func (g gitlabAPI) discussionURL(repo string, num int64, isPullRequest bool, commentID string) string {
+	kind := "issues"
+	if isPullRequest {
+		kind = "merge_requests"
+	}
+	base := fmt.Sprintf("%s/%s/-/%s/%d", g.baseURL, repo, kind, num)
+	if commentID == "" {
+		return base
+	}
+	return base + "#note_" + commentID
+}
+
+// GitLab "opened" / "locked" bolta hai, discussion filter open / closed / merged samajhta hai
+func gitlabStatus(state string) string {
+	switch state {
+	case "opened":
+		return "open"
+	case "locked":
+		return "closed"
+	}
+	return state
+}
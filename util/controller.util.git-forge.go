@@ -0,0 +1,345 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// git forges (GitHub, GitLab, Gitea) me scan karne layak cheez repo hai, resource id "owner/name" (GitLab me "group/sub/name")
+const ResourceTypeRepo = "repos"
+
+// forge REST api ka provider specific hissa; tree -> files -> issues / PRs wala flow sab forges me same hai
+type forgeAPI interface {
+	forgeClient
+	listRepos(owner string) ([]SourceItem, error)
+	// ref khaali ho to default branch; commit sha deta hai
+	resolveRef(repo, ref string) (string, error)
+	// commit ki saari blob files (naam, blob sha, size); true matlab api ne listing adhoori di
+	listTree(repo, sha string) ([]models.SIBLING, bool, error)
+	fileRequest(repo, sha, path string) (*http.Request, error)
+	// issues / PRs ki list, body pehla event hota hai; comments alag se
+	listThreads(repo string, includeIssues, includePRs bool, limit int) ([]models.DISCUSSION, error)
+	threadComments(repo string, disc models.DISCUSSION) ([]models.DISCUSSION_EVENT, error)
+	fileURL(repo, revision, path string, line int) string
+	discussionURL(repo string, num int64, isPullRequest bool, commentID string) string
+}
+
+// forge ki har request ka auth header aur us forge ka apna client (ProviderHTTPClient)
+type forgeClient interface {
+	auth(req *http.Request)
+	client() *http.Client
+}
+
+// ek git forge as a Source; kaunsa forge hai wo api batati hai.
+// api har call pe env se banti hai, init() ke waqt .env load nahi hua hota
+type GitForgeSource struct {
+	name string
+	api  func() forgeAPI
+}
+
+func init() {
+	RegisterSource(GitForgeSource{name: "github", api: func() forgeAPI { return newGitHubAPI() }})
+	RegisterSource(GitForgeSource{name: "gitlab", api: func() forgeAPI { return newGitLabAPI() }})
+	RegisterSource(GitForgeSource{name: "gitea", api: func() forgeAPI { return newGiteaAPI() }})
+}
+
+func (s GitForgeSource) Name() string { return s.name }
+
+func (s GitForgeSource) ResourceTypes() []string { return []string{ResourceTypeRepo} }
+
+func (s GitForgeSource) ListItems(resourceType, owner string) ([]SourceItem, error) {
+	items, err := s.api().listRepos(owner)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Provider = s.name
+		items[i].ResourceType = ResourceTypeRepo
+	}
+	return items, nil
+}
+
+// ref ka commit -> uska tree -> readable files (blob cache + fetch policy ke saath) -> options ho to issues / PR bodies
+func (s GitForgeSource) Fetch(aiRequest *models.AI_REQUEST, resourceType, resourceID string, opts FetchOptions) (*RescanPlan, error) {
+	start := time.Now()
+	traceID := uuid.New().String()
+	api := s.api()
+
+	log.Printf(
+		"op=GitForgeSource.Fetch stage=start trace_id=%s provider=%s repo=%s ref=%s include_prs=%t include_discussions=%t",
+		traceID, s.name, resourceID, opts.Ref, opts.IncludePRs, opts.IncludeDiscussions,
+	)
+
+	sha, err := api.resolveRef(resourceID, opts.Ref)
+	if err != nil {
+		log.Printf(
+			"op=GitForgeSource.Fetch stage=resolve_ref_error trace_id=%s provider=%s repo=%s ref=%s error=%v elapsed=%s",
+			traceID, s.name, resourceID, opts.Ref, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	aiRequest.Provider = s.name
+	aiRequest.ResourceType = ResourceTypeRepo
+	aiRequest.ResourceID = resourceID
+	aiRequest.CommitSHA = sha
+
+	plan := PlanForCommit(aiRequest, opts)
+	if plan.Mode != RescanReused {
+		tree, truncated, err := api.listTree(resourceID, sha)
+		if err != nil {
+			log.Printf(
+				"op=GitForgeSource.Fetch stage=tree_error trace_id=%s provider=%s repo=%s sha=%s error=%v elapsed=%s",
+				traceID, s.name, resourceID, sha, err, time.Since(start),
+			)
+			return nil, err
+		}
+		aiRequest.Siblings, aiRequest.Skipped, aiRequest.Failed = FetchFilesWith(resourceID, tree, opts.ByteBudget, func(file models.SIBLING, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error) {
+			req, err := api.fileRequest(resourceID, sha, file.RFilename)
+			if err != nil {
+				return file, "", err
+			}
+			return fetchForgeFile(api.client(), req, file, policy, action)
+		})
+		if truncated {
+			// listing hi adhoori hai to coverage partial dikhni chahiye
+			aiRequest.Failed = append(aiRequest.Failed, models.FAILED_ITEM{Name: "tree", Error: "tree listing truncated by the forge API"})
+		}
+	}
+	log.Printf(
+		"op=GitForgeSource.Fetch stage=fetch_files_done trace_id=%s provider=%s repo=%s sha=%s files=%d skipped=%d failed=%d scan_mode=%s",
+		traceID, s.name, resourceID, sha, len(aiRequest.Siblings), len(aiRequest.Skipped), len(aiRequest.Failed), plan.Mode,
+	)
+
+	if opts.IncludeDiscussions || opts.IncludePRs {
+		threads, err := api.listThreads(resourceID, opts.IncludeDiscussions, opts.IncludePRs, ForgeMaxThreads())
+		if err != nil {
+			aiRequest.DiscussionsFailed = append(aiRequest.DiscussionsFailed, models.FAILED_ITEM{
+				Name:   "threads",
+				Status: StatusFromError(err),
+				Error:  err.Error(),
+			})
+		}
+		threads = FilterDiscussions(threads, opts.DiscussionFilter)
+		aiRequest.Discussions = attachForgeComments(api, resourceID, threads)
+		log.Printf(
+			"op=GitForgeSource.Fetch stage=fetch_threads_done trace_id=%s provider=%s repo=%s threads=%d",
+			traceID, s.name, resourceID, len(aiRequest.Discussions),
+		)
+	}
+
+	if opts.ScanPRDiffs || opts.ScanHistory {
+		// maanga tha par hua nahi, to coverage partial
+		if opts.ScanPRDiffs {
+			aiRequest.PullRequestsFailed = append(aiRequest.PullRequestsFailed, UnsupportedOption("scan_pr_diffs", s.name))
+		}
+		if opts.ScanHistory {
+			aiRequest.HistoryFailed = append(aiRequest.HistoryFailed, UnsupportedOption("scan_history", s.name))
+		}
+		log.Printf(
+			"op=GitForgeSource.Fetch stage=unsupported_options trace_id=%s provider=%s repo=%s scan_pr_diffs=%t scan_history=%t",
+			traceID, s.name, resourceID, opts.ScanPRDiffs, opts.ScanHistory,
+		)
+	}
+
+	log.Printf(
+		"op=GitForgeSource.Fetch stage=success trace_id=%s provider=%s repo=%s sha=%s elapsed=%s",
+		traceID, s.name, resourceID, sha, time.Since(start),
+	)
+	return plan, nil
+}
+
+func (s GitForgeSource) FileURL(resourceType, resourceID, revision, fileName string, line int) string {
+	return s.api().fileURL(resourceID, revision, fileName, line)
+}
+
+func (s GitForgeSource) DiscussionURL(resourceType, resourceID string, num int64, isPullRequest bool, commentID string) string {
+	return s.api().discussionURL(resourceID, num, isPullRequest, commentID)
+}
+
+// issues aur PRs / MRs dono me se har ek ke liye alag limit
+func ForgeMaxThreads() int {
+	return envInt("FORGE_MAX_THREADS", 200)
+}
+
+func forgeMaxPages() int {
+	return envInt("FORGE_MAX_PAGES", 50)
+}
+
+// har thread ke comments concurrently; fail hua to EventsError, body (pehla event) phir bhi scan hoti hai
+func attachForgeComments(api forgeAPI, repo string, threads []models.DISCUSSION) []models.DISCUSSION {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10)
+
+	for i := range threads {
+		wg.Add(1)
+		go func(disc *models.DISCUSSION) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			comments, err := api.threadComments(repo, *disc)
+			if err != nil {
+				disc.EventsError = err.Error()
+				return
+			}
+			disc.Events = append(disc.Events, comments...)
+			disc.NumComments = int64(len(comments))
+		}(&threads[i])
+	}
+	wg.Wait()
+	return threads
+}
+
+// issue / PR / MR ko DISCUSSION me; body pehla event hai taaki line numbers aur scan comments jaisa hi ho
+func forgeThread(repo string, num int64, title, body, status, createdAt, author string, isPR bool) models.DISCUSSION {
+	disc := models.DISCUSSION{
+		Num:           num,
+		Title:         title,
+		Status:        status,
+		IsPullRequest: isPR,
+		CreatedAt:     createdAt,
+		AuthorName:    author,
+		RepoName:      repo,
+		Events:        []models.DISCUSSION_EVENT{},
+	}
+	if body != "" {
+		disc.Events = append(disc.Events, models.DISCUSSION_EVENT{
+			Type:       "body",
+			AuthorName: author,
+			CreatedAt:  createdAt,
+			Content:    body,
+		})
+	}
+	return disc
+}
+
+// forge api ki ek GET; non-2xx pe HTTPStatusError taaki coverage me status dikhe
+func forgeGet(rawURL string, forge forgeClient, out interface{}) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	forge.auth(req)
+
+	resp, err := forge.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &HTTPStatusError{URL: rawURL, Status: resp.StatusCode}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", rawURL, err)
+	}
+	return resp.Header, nil
+}
+
+// teeno forges pagination ke liye Link: <...>; rel="next" header dete hai; each me ek page ke items
+func forgeGetPaged[T any](rawURL string, forge forgeClient, each func(T) bool) error {
+	pages := 0
+	for next := rawURL; next != "" && pages < forgeMaxPages(); pages++ {
+		var items []T
+		header, err := forgeGet(next, forge, &items)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if !each(item) {
+				return nil
+			}
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		next = nextPageLink(header)
+		// token kisi aur host pe na chala jaaye
+		if next != "" && !sameHost(next, rawURL) {
+			return fmt.Errorf("refusing to follow pagination link to another host: %s", next)
+		}
+	}
+	return nil
+}
+
+// "owner/name" ko api path ke liye, owner aur name alag alag escape ("../x" ya "a?b" path / query na badal sake)
+func escapeOwnerRepo(repo string) string {
+	owner, name, _ := strings.Cut(repo, "/")
+	return url.PathEscape(owner) + "/" + url.PathEscape(name)
+}
+
+// "dir/a b.py" -> "dir/a%20b.py", slashes wahi rehte hai
+func escapeRepoPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// forge ki raw file; FetchFileContent jaisa hi LFS pointer / binary check
+func fetchForgeFile(client *http.Client, req *http.Request, file models.SIBLING, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error) {
+	sibling := file
+	sibling.FileContent = ""
+	sibling.FetchMode = string(action)
+	if action == FetchSample {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", policy.SampleBytes))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return sibling, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return sibling, "", &HTTPStatusError{URL: req.URL.String(), Status: resp.StatusCode}
+	}
+	return siblingFromBody(sibling, resp.Body, policy, action)
+}
+
+// policy ke hisaab se content padhta hai; LFS pointer / binary ho to skip reason deta hai
+func siblingFromBody(sibling models.SIBLING, body io.Reader, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error) {
+	content, truncated, err := policy.ReadBody(body, action)
+	if err != nil {
+		return sibling, "", err
+	}
+	if oid, size, ok := ParseLFSPointer(content); ok {
+		sibling.LFSSha256 = oid
+		sibling.Size = size
+		return sibling, SkipReasonLFSPointer, nil
+	}
+	if LooksBinary(content) {
+		return sibling, SkipReasonBinary, nil
+	}
+	sibling.FileContent = content
+	sibling.Truncated = truncated
+	return sibling, "", nil
+}
+
+// api base url ya web url, trailing slash ke bina
+func forgeBaseURL(key, fallback string) string {
+	return strings.TrimRight(GetEnv(key, fallback), "/")
+}
+
+func lineAnchor(line int) string {
+	if line > 0 {
+		return fmt.Sprintf("#L%d", line)
+	}
+	return ""
+}
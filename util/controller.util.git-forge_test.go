@@ -0,0 +1,146 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+func TestEscapeOwnerRepo(t *testing.T) {
+	tests := []struct {
+		repo string
+		want string
+	}{
+		{"owner/name", "owner/name"},
+		{"owner/na me", "owner/na%20me"},
+		{"owner/../x", "owner/..%2Fx"},
+		{"owner/name?x=1", "owner/name%3Fx=1"},
+	}
+	for _, tt := range tests {
+		if got := escapeOwnerRepo(tt.repo); got != tt.want {
+			t.Errorf("escapeOwnerRepo(%q) = %q, want %q", tt.repo, got, tt.want)
+		}
+	}
+}
+
+// har kind (issue / pr) ke 3 items serve karta hai
+func threadServer(t *testing.T, item func(kind string, n int) string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kind := "issue"
+		if strings.Contains(r.URL.Path, "merge_requests") || r.URL.Query().Get("type") == "pulls" {
+			kind = "pr"
+		}
+		items := []string{}
+		for n := 1; n <= 3; n++ {
+			items = append(items, item(kind, n))
+		}
+		fmt.Fprint(w, "["+strings.Join(items, ",")+"]")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestForgeListThreadsLimitsEachKind(t *testing.T) {
+	gitlab := threadServer(t, func(kind string, n int) string {
+		return fmt.Sprintf(`{"iid":%d,"title":"%s %d","state":"opened"}`, n, kind, n)
+	})
+	gitea := threadServer(t, func(kind string, n int) string {
+		pr := ""
+		if kind == "pr" {
+			pr = `,"pull_request":{"merged":false}`
+		}
+		return fmt.Sprintf(`{"number":%d,"title":"%s %d","state":"open"%s}`, n, kind, n, pr)
+	})
+	// github ek hi list me pehle saare issues deta hai, phir PRs
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"number":1,"state":"open"},{"number":2,"state":"open"},{"number":3,"state":"open"},`+
+			`{"number":4,"state":"open","pull_request":{}},{"number":5,"state":"open","pull_request":{}},{"number":6,"state":"open","pull_request":{}}]`)
+	}))
+	defer github.Close()
+
+	tests := []struct {
+		name string
+		api  forgeAPI
+	}{
+		{"gitlab", gitlabAPI{baseURL: gitlab.URL}},
+		{"gitea", giteaAPI{baseURL: gitea.URL}},
+		{"github", githubAPI{apiURL: github.URL, webURL: github.URL}},
+	}
+	for _, tt := range tests {
+		threads, err := tt.api.listThreads("owner/name", true, true, 2)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		issues, prs := 0, 0
+		for _, thread := range threads {
+			if thread.IsPullRequest {
+				prs++
+			} else {
+				issues++
+			}
+		}
+		if issues != 2 || prs != 2 {
+			t.Errorf("%s: issues=%d prs=%d, want 2 each", tt.name, issues, prs)
+		}
+	}
+}
+
+// forge requests apne client se: HF ka fixture recorder token wale headers disk pe na likhe,
+// aur jo option forge support nahi karta wo coverage me partial dikhe
+func TestGitForgeFetchUsesProviderClient(t *testing.T) {
+	t.Setenv("INCREMENTAL_RESCAN", "false")
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+	sha := strings.Repeat("a", 40)
+	authorized := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer forge-token" {
+			authorized++
+		}
+		switch r.URL.Path {
+		case "/repos/owner/name":
+			fmt.Fprint(w, `{"default_branch":"main"}`)
+		case "/repos/owner/name/commits/main":
+			fmt.Fprintf(w, `{"sha":%q}`, sha)
+		case "/repos/owner/name/git/trees/" + sha:
+			fmt.Fprint(w, `{"tree":[{"path":".env","type":"blob","sha":"b1","size":40}],"truncated":false}`)
+		case "/repos/owner/name/contents/.env":
+			fmt.Fprintf(w, "GITHUB_TOKEN=ghp_%s\n", strings.Repeat("x", 36))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	withFixtures(t, HTTPModeRecord, dir)
+
+	source := GitForgeSource{name: "github", api: func() forgeAPI {
+		return githubAPI{apiURL: server.URL, webURL: server.URL, token: "forge-token"}
+	}}
+	req := models.AI_REQUEST{}
+	if _, err := source.Fetch(&req, ResourceTypeRepo, "owner/name", FetchOptions{ScanHistory: true, ScanPRDiffs: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Siblings) != 1 || authorized != 4 {
+		t.Fatalf("siblings = %d authorized requests = %d, want 1 and 4", len(req.Siblings), authorized)
+	}
+
+	if recorded, _ := os.ReadDir(dir); len(recorded) != 0 {
+		t.Errorf("forge requests written to the HF fixture archive: %d files", len(recorded))
+	}
+	stats := ProviderHTTPClient("github").Transport.(*RequestScheduler).Stats()
+	if len(stats.Keys) == 0 {
+		t.Error("github requests did not go through the github scheduler")
+	}
+
+	coverage := CoverageFromRequest(req)
+	if coverage.Status != CoveragePartial || len(coverage.HistoryFailed) != 1 || len(coverage.PullRequestsFailed) != 1 ||
+		coverage.HistoryFailed[0].Status != http.StatusNotImplemented {
+		t.Errorf("coverage = %+v, want partial with scan_history and scan_pr_diffs unsupported", coverage)
+	}
+}
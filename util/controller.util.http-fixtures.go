@@ -0,0 +1,206 @@
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type HTTPFixtureMode string
+
+const (
+	HTTPModeLive   HTTPFixtureMode = "live"
+	HTTPModeRecord HTTPFixtureMode = "record"
+	HTTPModeReplay HTTPFixtureMode = "replay"
+)
+
+// fixture archive ek directory hai, har HTTP exchange ek json file,
+// file ka naam request key (method + url + range) ka sha256 hai
+type HTTPFixture struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Range   string              `json:"range,omitempty"`
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	// text body seedha (bug report me padhne layak), binary body base64 me
+	Body       string `json:"body,omitempty"`
+	BodyBase64 []byte `json:"body_base64,omitempty"`
+}
+
+func (f HTTPFixture) bodyBytes() []byte {
+	if f.BodyBase64 != nil {
+		return f.BodyBase64
+	}
+	return []byte(f.Body)
+}
+
+// record mode me har response ko archive me likhta hai, replay mode me network ki jagah archive se jawab deta hai
+type FixtureTransport struct {
+	mode HTTPFixtureMode
+	dir  string
+	next http.RoundTripper
+	mu   sync.Mutex
+}
+
+func NewFixtureTransport(mode HTTPFixtureMode, dir string, next http.RoundTripper) (*FixtureTransport, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("fixture directory is required for %s mode", mode)
+	}
+	if mode == HTTPModeRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if mode == HTTPModeReplay {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("fixture archive not found: %w", err)
+		}
+	}
+	return &FixtureTransport{mode: mode, dir: dir, next: next}, nil
+}
+
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == HTTPModeReplay {
+		return t.replay(req)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || t.mode != HTTPModeRecord {
+		return resp, err
+	}
+
+	// badi file (model weights wagaira) memory me poori mat padho; cap se upar wala response
+	// caller ko waise hi stream hota hai, bas archive me nahi jaata
+	limit := fixtureMaxBodyBytes()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		log.Printf("op=FixtureTransport stage=record_skipped url=%s reason=body_too_large limit=%d", req.URL.String(), limit)
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := HTTPFixture{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Range:   req.Header.Get("Range"),
+		Status:  resp.StatusCode,
+		Headers: resp.Header,
+	}
+	if utf8.Valid(body) {
+		fixture.Body = string(body)
+	} else {
+		fixture.BodyBase64 = body
+	}
+	if err := t.save(fixture); err != nil {
+		log.Printf("op=FixtureTransport stage=record_error url=%s error=%v", fixture.URL, err)
+	}
+	return resp, nil
+}
+
+func (t *FixtureTransport) replay(req *http.Request) (*http.Response, error) {
+	key := FixtureKey(req.Method, req.URL.String(), req.Header.Get("Range"))
+	data, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		log.Printf("op=FixtureTransport stage=replay_miss method=%s url=%s", req.Method, req.URL.String())
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.String())
+	}
+
+	var fixture HTTPFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("corrupt fixture for %s: %w", req.URL.String(), err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(fixture.Headers),
+		Body:          io.NopCloser(bytes.NewReader(fixture.bodyBytes())),
+		ContentLength: int64(len(fixture.bodyBytes())),
+		Request:       req,
+	}, nil
+}
+
+func (t *FixtureTransport) save(fixture HTTPFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	key := FixtureKey(fixture.Method, fixture.URL, fixture.Range)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// pehle temp file, phir rename, taaki adhoori file replay me na aaye
+	tmp := filepath.Join(t.dir, key+".json.tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.dir, key+".json"))
+}
+
+// record mode me ek response ki max body jo archive me likhi jaayegi
+func fixtureMaxBodyBytes() int64 {
+	limit, err := strconv.ParseInt(GetEnv("HF_FIXTURE_MAX_BYTES", "10485760"), 10, 64)
+	if err != nil || limit <= 0 {
+		return 10 << 20
+	}
+	return limit
+}
+
+func FixtureKey(method, url, rangeHeader string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(method) + " " + url + " " + rangeHeader))
+	return hex.EncodeToString(sum[:])
+}
+
+func ParseHTTPFixtureMode(value string) (HTTPFixtureMode, error) {
+	switch mode := HTTPFixtureMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", HTTPModeLive:
+		return HTTPModeLive, nil
+	case HTTPModeRecord, HTTPModeReplay:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid HF_HTTP_MODE %q: use live, record or replay", value)
+	}
+}
+
+// shared client ka transport badalta hai; controller ke replay tests isse archive lagate hai (server / CLI me yahi
+// kaam HF_HTTP_MODE + HF_FIXTURE_DIR se SharedHTTPClient karta hai).
+// replay me scheduler bhi bypass hota hai, network hai hi nahi to rate limit ka kya kaam
+func UseHTTPFixtures(mode HTTPFixtureMode, dir string) error {
+	client := SharedHTTPClient()
+
+	if mode == HTTPModeLive {
+		client.Transport = sharedScheduler
+		return nil
+	}
+
+	transport, err := NewFixtureTransport(mode, dir, sharedScheduler)
+	if err != nil {
+		return err
+	}
+	client.Transport = transport
+	log.Printf("op=UseHTTPFixtures stage=enabled mode=%s dir=%s", mode, dir)
+	return nil
+}
@@ -0,0 +1,390 @@
+package util
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// saare outbound HF requests isi scheduler se guzarte hai:
+// global concurrency + requests-per-second budget, 429/503 pe Retry-After ke hisaab se retry,
+// aur jab slots full ho to har scan key ko round-robin me baari milti hai taaki ek bada repo baaki sab ko starve na kare
+type RequestScheduler struct {
+	base        http.RoundTripper
+	maxInFlight int
+	interval    time.Duration
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	waiters  map[string][]chan struct{}
+	order    []string
+	nextSlot time.Time
+	stats    map[string]*SchedulerKeyStats
+}
+
+type SchedulerKeyStats struct {
+	Requests  int64 `json:"requests"`
+	Retries   int64 `json:"retries"`
+	Throttled int64 `json:"throttled"`
+	Failed    int64 `json:"failed"`
+	Waiting   int   `json:"waiting"`
+
+	lastSeen time.Time
+}
+
+// har scan ki apni key hai, isliye stats map bounded rakhte hai; bhar jaaye to sabse purani idle key hatti hai
+const maxSchedulerStatsKeys = 512
+
+type SchedulerStats struct {
+	MaxInFlight       int                          `json:"max_in_flight"`
+	InFlight          int                          `json:"in_flight"`
+	RequestsPerSecond float64                      `json:"requests_per_second"`
+	MaxRetries        int                          `json:"max_retries"`
+	Keys              map[string]SchedulerKeyStats `json:"keys"`
+}
+
+type scanKeyContextKey struct{}
+
+// request ko kisi scan ke naam se tag karta hai, fairness isi key pe hoti hai
+func WithScanKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, scanKeyContextKey{}, key)
+}
+
+func NewRequestScheduler(base http.RoundTripper, maxInFlight int, requestsPerSecond float64, maxRetries int) *RequestScheduler {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	interval := time.Duration(0)
+	if requestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return &RequestScheduler{
+		base:        base,
+		maxInFlight: maxInFlight,
+		interval:    interval,
+		maxRetries:  maxRetries,
+		baseBackoff: 500 * time.Millisecond,
+		maxBackoff:  30 * time.Second,
+		waiters:     make(map[string][]chan struct{}),
+		stats:       make(map[string]*SchedulerKeyStats),
+	}
+}
+
+func (s *RequestScheduler) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := scanKeyFor(req)
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if err := s.acquire(ctx, key); err != nil {
+			return nil, err
+		}
+		if err := s.waitForRate(ctx); err != nil {
+			s.release()
+			return nil, err
+		}
+
+		s.count(key, func(st *SchedulerKeyStats) { st.Requests++ })
+		resp, err := s.base.RoundTrip(req)
+
+		throttled := err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable)
+		if err == nil && !throttled {
+			// slot tab free hoga jab caller body poori padh le ya close kare
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: s.release}
+			return resp, nil
+		}
+		s.release()
+
+		canRetry := attempt < s.maxRetries && (req.Body == nil || req.GetBody != nil) && ctx.Err() == nil
+		if !canRetry {
+			s.count(key, func(st *SchedulerKeyStats) { st.Failed++ })
+			return resp, err
+		}
+
+		wait := s.backoff(attempt)
+		if throttled {
+			s.count(key, func(st *SchedulerKeyStats) { st.Throttled++ })
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = retryAfter
+			}
+			// HF ne sabko roka hai, sirf is request ko nahi, to global budget bhi peeche khiskate hai
+			s.cooldown(wait)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			log.Printf(
+				"op=RequestScheduler stage=throttled key=%s url=%s status=%d attempt=%d wait=%s",
+				key, req.URL.String(), resp.StatusCode, attempt+1, wait,
+			)
+		} else {
+			log.Printf(
+				"op=RequestScheduler stage=transport_error key=%s url=%s attempt=%d wait=%s error=%v",
+				key, req.URL.String(), attempt+1, wait, err,
+			)
+		}
+		s.count(key, func(st *SchedulerKeyStats) { st.Retries++ })
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// slot milne tak ruko, waiters ko key ke hisaab se queue karte hai
+func (s *RequestScheduler) acquire(ctx context.Context, key string) error {
+	s.mu.Lock()
+	if s.inFlight < s.maxInFlight && len(s.order) == 0 {
+		s.inFlight++
+		s.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	if len(s.waiters[key]) == 0 {
+		s.order = append(s.order, key)
+	}
+	s.waiters[key] = append(s.waiters[key], ch)
+	s.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		removed := s.removeWaiter(key, ch)
+		s.mu.Unlock()
+		if !removed {
+			// slot mil chuka tha, wapas de do
+			<-ch
+			s.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// slot free hone pe agli key ke pehle waiter ko slot de dete hai (round-robin)
+func (s *RequestScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.order) == 0 {
+		s.inFlight--
+		return
+	}
+
+	key := s.order[0]
+	queue := s.waiters[key]
+	ch := queue[0]
+	if len(queue) == 1 {
+		delete(s.waiters, key)
+		s.order = s.order[1:]
+	} else {
+		s.waiters[key] = queue[1:]
+		s.order = append(s.order[1:], key)
+	}
+	close(ch)
+}
+
+func (s *RequestScheduler) removeWaiter(key string, ch chan struct{}) bool {
+	queue := s.waiters[key]
+	for i, waiting := range queue {
+		if waiting != ch {
+			continue
+		}
+		queue = append(queue[:i], queue[i+1:]...)
+		if len(queue) == 0 {
+			delete(s.waiters, key)
+			for j, k := range s.order {
+				if k == key {
+					s.order = append(s.order[:j], s.order[j+1:]...)
+					break
+				}
+			}
+		} else {
+			s.waiters[key] = queue
+		}
+		return true
+	}
+	return false
+}
+
+// requests-per-second budget, har request apna time slot reserve karti hai
+func (s *RequestScheduler) waitForRate(ctx context.Context) error {
+	if s.interval == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	now := time.Now()
+	slot := s.nextSlot
+	if slot.Before(now) {
+		slot = now
+	}
+	s.nextSlot = slot.Add(s.interval)
+	s.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (s *RequestScheduler) cooldown(wait time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until := time.Now().Add(wait)
+	if s.nextSlot.Before(until) {
+		s.nextSlot = until
+	}
+}
+
+// jittered exponential backoff: base * 2^attempt, phir 50-100% random
+func (s *RequestScheduler) backoff(attempt int) time.Duration {
+	wait := s.baseBackoff << attempt
+	if wait <= 0 || wait > s.maxBackoff {
+		wait = s.maxBackoff
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+func (s *RequestScheduler) count(key string, update func(*SchedulerKeyStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= maxSchedulerStatsKeys {
+			s.evictStatsKey()
+		}
+		st = &SchedulerKeyStats{}
+		s.stats[key] = st
+	}
+	st.lastSeen = time.Now()
+	update(st)
+}
+
+// jis key ki koi request queue me nahi aur sabse der se dikhi nahi, wo nikal jaati hai
+func (s *RequestScheduler) evictStatsKey() {
+	oldest := ""
+	var oldestSeen time.Time
+	for key, st := range s.stats {
+		if len(s.waiters[key]) > 0 {
+			continue
+		}
+		if oldest == "" || st.lastSeen.Before(oldestSeen) {
+			oldest, oldestSeen = key, st.lastSeen
+		}
+	}
+	if oldest != "" {
+		delete(s.stats, oldest)
+	}
+}
+
+func (s *RequestScheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rps := 0.0
+	if s.interval > 0 {
+		rps = float64(time.Second) / float64(s.interval)
+	}
+	stats := SchedulerStats{
+		MaxInFlight:       s.maxInFlight,
+		InFlight:          s.inFlight,
+		RequestsPerSecond: rps,
+		MaxRetries:        s.maxRetries,
+		Keys:              make(map[string]SchedulerKeyStats),
+	}
+	for key, st := range s.stats {
+		entry := *st
+		entry.Waiting = len(s.waiters[key])
+		stats.Keys[key] = entry
+	}
+	return stats
+}
+
+// Retry-After seconds me bhi aa sakta hai aur HTTP date me bhi
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// context me scan key ho to wahi, warna url se repo nikal lete hai
+// "/api/models/org/name/..." -> "models/org/name", "/org/name/resolve/..." -> "models/org/name"
+func scanKeyFor(req *http.Request) string {
+	if key, ok := req.Context().Value(scanKeyContextKey{}).(string); ok && key != "" {
+		return key
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) > 0 && parts[0] == "api" {
+		parts = parts[1:]
+	}
+	resourceType := "models"
+	if len(parts) > 0 && (parts[0] == "models" || parts[0] == "datasets" || parts[0] == "spaces" || parts[0] == "collections") {
+		resourceType = parts[0]
+		parts = parts[1:]
+	}
+	if len(parts) >= 2 {
+		return resourceType + "/" + parts[0] + "/" + parts[1]
+	}
+	return resourceType
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// EOF pe hi slot free: caller body padh ke (defer Close se pehle) child requests bhej sakta hai
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,263 @@
+package util
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func okTransport() http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok")), Request: req}, nil
+	})
+}
+
+func newTestRequest(t *testing.T, key string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(WithScanKey(context.Background(), key), http.MethodGet, "https://hub.test/api/models/org/repo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func (s *RequestScheduler) inFlightNow() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight
+}
+
+func (s *RequestScheduler) waitingFor(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiters[key])
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerReleasesSlotAtEOF(t *testing.T) {
+	s := NewRequestScheduler(okTransport(), 1, 0, 0)
+
+	resp, err := s.RoundTrip(newTestRequest(t, "scan-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.inFlightNow(); got != 1 {
+		t.Fatalf("in flight before reading body = %d, want 1", got)
+	}
+	// parent body poori padhi par close nahi ki (defer Close wala pattern), child request atakni nahi chahiye
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.inFlightNow(); got != 0 {
+		t.Fatalf("in flight after EOF = %d, want 0", got)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		child, err := s.RoundTrip(newTestRequest(t, "scan-a"))
+		if err == nil {
+			child.Body.Close()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("child request blocked while parent body was still open")
+	}
+
+	// close ke baad dubara release nahi hona chahiye
+	resp.Body.Close()
+	if got := s.inFlightNow(); got != 0 {
+		t.Fatalf("in flight after close = %d, want 0", got)
+	}
+}
+
+func TestSchedulerHoldsSlotUntilCloseOrEOF(t *testing.T) {
+	s := NewRequestScheduler(okTransport(), 1, 0, 0)
+
+	resp, err := s.RoundTrip(newTestRequest(t, "scan-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := s.RoundTrip(newTestRequest(t, "scan-b"))
+		if err == nil {
+			second.Body.Close()
+		}
+		close(acquired)
+	}()
+	waitUntil(t, "second request to queue", func() bool { return s.waitingFor("scan-b") == 1 })
+
+	select {
+	case <-acquired:
+		t.Fatal("second request ran while the only slot was held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	resp.Body.Close()
+	select {
+	case <-acquired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slot was not handed over on close")
+	}
+	waitUntil(t, "slots to drain", func() bool { return s.inFlightNow() == 0 })
+}
+
+func TestSchedulerRoundRobinAcrossScanKeys(t *testing.T) {
+	var mu sync.Mutex
+	var served []string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		served = append(served, scanKeyFor(req))
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})
+	s := NewRequestScheduler(base, 1, 0, 0)
+
+	holder, err := s.RoundTrip(newTestRequest(t, "holder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	enqueue := func(key string, want int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := s.RoundTrip(newTestRequest(t, key))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		waitUntil(t, key+" to queue", func() bool { return s.waitingFor(key) == want })
+	}
+	// bada scan pehle 3 requests queue karta hai, chhota scan baad me 1
+	enqueue("big", 1)
+	enqueue("big", 2)
+	enqueue("big", 3)
+	enqueue("small", 1)
+
+	holder.Body.Close()
+	wg.Wait()
+
+	want := []string{"holder", "big", "small", "big", "big"}
+	if strings.Join(served, ",") != strings.Join(want, ",") {
+		t.Errorf("served order = %v, want %v", served, want)
+	}
+	if got := s.inFlightNow(); got != 0 {
+		t.Errorf("in flight after all requests = %d, want 0", got)
+	}
+}
+
+func TestSchedulerRetryReleasesSlot(t *testing.T) {
+	attempts := 0
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		status := http.StatusOK
+		if attempts == 1 {
+			status = http.StatusTooManyRequests
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{"Retry-After": []string{"0"}}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	})
+	s := NewRequestScheduler(base, 1, 0, 2)
+
+	resp, err := s.RoundTrip(newTestRequest(t, "scan-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || attempts != 2 {
+		t.Fatalf("status=%d attempts=%d, want 200 after 2 attempts", resp.StatusCode, attempts)
+	}
+	if got := s.inFlightNow(); got != 0 {
+		t.Fatalf("in flight after retry = %d, want 0", got)
+	}
+	stats := s.Stats().Keys["scan-a"]
+	if stats.Requests != 2 || stats.Throttled != 1 || stats.Retries != 1 {
+		t.Errorf("stats = %+v, want 2 requests, 1 throttled, 1 retry", stats)
+	}
+}
+
+func TestSchedulerCancelledWaiterGivesBackSlot(t *testing.T) {
+	s := NewRequestScheduler(okTransport(), 1, 0, 0)
+
+	holder, err := s.RoundTrip(newTestRequest(t, "holder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(WithScanKey(context.Background(), "scan-b"))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://hub.test/api/models/org/repo", nil)
+	failed := make(chan error, 1)
+	go func() {
+		_, err := s.RoundTrip(req)
+		failed <- err
+	}()
+	waitUntil(t, "waiter to queue", func() bool { return s.waitingFor("scan-b") == 1 })
+	cancel()
+	if err := <-failed; err == nil {
+		t.Fatal("cancelled request did not fail")
+	}
+
+	holder.Body.Close()
+	if got := s.inFlightNow(); got != 0 {
+		t.Fatalf("in flight = %d, want 0", got)
+	}
+}
+
+func TestSchedulerStatsAreBounded(t *testing.T) {
+	s := NewRequestScheduler(okTransport(), 1, 0, 0)
+	for i := 0; i < maxSchedulerStatsKeys+100; i++ {
+		s.count("scan-"+strconv.Itoa(i), func(st *SchedulerKeyStats) { st.Requests++ })
+	}
+	stats := s.Stats()
+	if len(stats.Keys) != maxSchedulerStatsKeys {
+		t.Fatalf("tracked keys = %d, want %d", len(stats.Keys), maxSchedulerStatsKeys)
+	}
+	// sabse nayi key bachi rehni chahiye
+	if _, ok := stats.Keys["scan-"+strconv.Itoa(maxSchedulerStatsKeys+99)]; !ok {
+		t.Error("newest key was evicted")
+	}
+}
+
+func TestScanKeyFor(t *testing.T) {
+	tests := []struct {
+		url  string
+		key  string
+		want string
+	}{
+		{"https://hub.test/api/models/org/name/tree/main", "", "models/org/name"},
+		{"https://hub.test/api/datasets/org/name", "", "datasets/org/name"},
+		{"https://hub.test/org/name/resolve/main/config.json", "", "models/org/name"},
+		{"https://hub.test/api/models/org/name", "SG-1", "SG-1"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequestWithContext(scanContext(tt.key), http.MethodGet, tt.url, nil)
+		if got := scanKeyFor(req); got != tt.want {
+			t.Errorf("scanKeyFor(%s, %q) = %q, want %q", tt.url, tt.key, got, tt.want)
+		}
+	}
+}
@@ -1,23 +1,131 @@
 package util
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-// bare bone client, can be good if you want full control over each request
+var (
+	sharedClient     *http.Client
+	sharedScheduler  *RequestScheduler
+	sharedClientOnce sync.Once
+)
+
+// poore process me ek hi client aur ek hi scheduler, util aur controller dono yahi use karte hai
+// taaki nested concurrency (org -> models -> files) bhi ek global budget ke andar rahe
 func SharedHTTPClient() *http.Client {
-	return &http.Client{
-		Timeout: 45 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        200,
-			MaxIdleConnsPerHost: 50,
-			MaxConnsPerHost:     100,
-			IdleConnTimeout:     90 * time.Second,
-			DisableKeepAlives:   false,
-			DisableCompression:  false,
-		},
+	sharedClientOnce.Do(func() {
+		sharedScheduler = NewRequestScheduler(
+			newHTTPTransport(),
+			envInt("HF_MAX_CONCURRENCY", 16),
+			envFloat("HF_REQUESTS_PER_SECOND", 10),
+			envInt("HF_MAX_RETRIES", 4),
+		)
+		sharedClient = &http.Client{
+			// retries aur queue ka wait bhi isi timeout me aata hai, isliye per attempt se zyada rakha hai
+			Timeout:   5 * time.Minute,
+			Transport: sharedScheduler,
+		}
+
+		// HF_HTTP_MODE=record|replay ho to HF_FIXTURE_DIR wala archive beech me lag jaata hai
+		mode, err := ParseHTTPFixtureMode(GetEnv("HF_HTTP_MODE", ""))
+		if err != nil {
+			log.Fatalf("op=SharedHTTPClient stage=config_error error=%v", err)
+		}
+		if mode != HTTPModeLive {
+			fixtures, err := NewFixtureTransport(mode, GetEnv("HF_FIXTURE_DIR", ""), sharedScheduler)
+			if err != nil {
+				log.Fatalf("op=SharedHTTPClient stage=fixture_error mode=%s error=%v", mode, err)
+			}
+			sharedClient.Transport = fixtures
+			log.Printf("op=SharedHTTPClient stage=fixtures_enabled mode=%s dir=%s", mode, GetEnv("HF_FIXTURE_DIR", ""))
+		}
+	})
+	return sharedClient
+}
+
+var (
+	providerClients   = map[string]*http.Client{}
+	providerClientsMu sync.Mutex
+)
+
+// HF ke bahar wale providers (github, gitlab, gitea, s3) ka apna client aur apna scheduler:
+// unki rate limits HF se alag hai ({PROVIDER}_MAX_CONCURRENCY, {PROVIDER}_REQUESTS_PER_SECOND, {PROVIDER}_MAX_RETRIES),
+// HF ka budget inse nahi khapta, aur HF_HTTP_MODE wala fixture recorder inke tokens / signed headers disk pe nahi likhta
+func ProviderHTTPClient(provider string) *http.Client {
+	providerClientsMu.Lock()
+	defer providerClientsMu.Unlock()
+
+	if client, ok := providerClients[provider]; ok {
+		return client
+	}
+	prefix := strings.ToUpper(provider)
+	client := &http.Client{
+		Timeout: 5 * time.Minute,
+		Transport: NewRequestScheduler(
+			newHTTPTransport(),
+			envInt(prefix+"_MAX_CONCURRENCY", 8),
+			envFloat(prefix+"_REQUESTS_PER_SECOND", 10),
+			envInt(prefix+"_MAX_RETRIES", 4),
+		),
+	}
+	providerClients[provider] = client
+	return client
+}
+
+func newHTTPTransport() *http.Transport {
+	return &http.Transport{
+		MaxIdleConns:          200,
+		MaxIdleConnsPerHost:   50,
+		MaxConnsPerHost:       100,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: 45 * time.Second,
+		DisableKeepAlives:     false,
+		DisableCompression:    false,
+	}
+}
+
+// request ke context me scan key, taaki scheduler ek scan ki saari requests ko ek hi queue me rakhe
+func scanContext(scanKey string) context.Context {
+	if scanKey == "" {
+		return context.Background()
+	}
+	return WithScanKey(context.Background(), scanKey)
+}
+
+// shared client se GET, scan key ke saath
+func hubGet(scanKey, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(scanContext(scanKey), http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
+func SharedScheduler() *RequestScheduler {
+	SharedHTTPClient()
+	return sharedScheduler
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(GetEnv(key, ""))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(GetEnv(key, ""), 64)
+	if err != nil || value <= 0 {
+		return fallback
 	}
+	return value
 }
 
 // Q. What is the use case of this file?
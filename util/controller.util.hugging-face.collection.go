@@ -0,0 +1,91 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// collection ka ek item, model / dataset / space / paper kuch bhi ho sakta hai
+type CollectionItem struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// collection item type ("model") ko api resource type ("models") me badalta hai,
+// papers jaise items jinka repo nahi hota unke liye false
+func (item CollectionItem) ResourceType() (ResourceType, bool) {
+	switch item.Type {
+	case "model":
+		return ResourceTypeModel, true
+	case "dataset":
+		return ResourceTypeDataset, true
+	case "space":
+		return ResourceTypeSpace, true
+	}
+	return "", false
+}
+
+// collections api se collection ke items nikalta hai
+// slug looks like "org/collection-name-64f9a1b2c3d4e5f6a7b8c9d0"
+func FetchCollectionItems(slug string) (string, []CollectionItem, error) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	url := fmt.Sprintf("%s/api/collections/%s", HFBaseURL(), slug)
+	log.Printf(
+		"op=FetchCollectionItems stage=start request_id=%s slug=%s url=%s",
+		requestID, slug, url,
+	)
+
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		log.Printf(
+			"op=FetchCollectionItems stage=http_get_error request_id=%s slug=%s error=%v elapsed=%s",
+			requestID, slug, err, time.Since(start),
+		)
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf(
+			"op=FetchCollectionItems stage=not_ok request_id=%s slug=%s status=%d elapsed=%s",
+			requestID, slug, resp.StatusCode, time.Since(start),
+		)
+		return "", nil, &HTTPStatusError{URL: url, Status: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf(
+			"op=FetchCollectionItems stage=read_error request_id=%s slug=%s error=%v elapsed=%s",
+			requestID, slug, err, time.Since(start),
+		)
+		return "", nil, err
+	}
+
+	var collection struct {
+		Title string           `json:"title"`
+		Items []CollectionItem `json:"items"`
+	}
+	if err := json.Unmarshal(body, &collection); err != nil {
+		log.Printf(
+			"op=FetchCollectionItems stage=json_unmarshal_error request_id=%s slug=%s error=%v elapsed=%s",
+			requestID, slug, err, time.Since(start),
+		)
+		return "", nil, err
+	}
+
+	log.Printf(
+		"op=FetchCollectionItems stage=success request_id=%s slug=%s title=%q items=%d total_elapsed=%s",
+		requestID, slug, collection.Title, len(collection.Items), time.Since(start),
+	)
+
+	return collection.Title, collection.Items, nil
+}
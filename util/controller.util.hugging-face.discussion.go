@@ -2,9 +2,12 @@ package util
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,8 +15,9 @@ import (
 	"github.com/google/uuid"
 )
 
-func FetchDiscussions(id, resourceType string, includePRs, includeDiscussion bool) ([]models.DISCUSSION, error) {
+func FetchDiscussions(id, resourceType string, includePRs, includeDiscussion bool, filter DiscussionFilter, scanKey string) ([]models.DISCUSSION, error) {
 	var discussions []models.DISCUSSION
+	var failures []models.FAILED_ITEM
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
@@ -21,26 +25,29 @@ func FetchDiscussions(id, resourceType string, includePRs, includeDiscussion boo
 	requestID := uuid.New().String()
 
 	log.Printf(
-		"op=FetchDiscussions stage=start request_id=%s resource_type=%s id=%s include_prs=%t include_discussion=%t",
-		requestID, resourceType, id, includePRs, includeDiscussion,
+		"op=FetchDiscussions stage=start request_id=%s resource_type=%s id=%s include_prs=%t include_discussion=%t status=%s since=%s",
+		requestID, resourceType, id, includePRs, includeDiscussion, filter.Status, filter.SinceString(),
 	)
 
 	if includePRs {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			url := fmt.Sprintf("https://huggingface.co/api/%s/%s/discussions?types=pr&status=all", resourceType, id)
+			url := DiscussionListURL(resourceType, id, "pr", filter)
 			localStart := time.Now()
 			log.Printf(
 				"op=FetchDiscussions stage=fetch_prs_start request_id=%s resource_type=%s id=%s url=%s",
 				requestID, resourceType, id, url,
 			)
-			prs, err := GetDiscussionsFromURL(url)
+			prs, err := GetDiscussionsFromURL(url, scanKey)
 			if err != nil {
 				log.Printf(
 					"op=FetchDiscussions stage=fetch_prs_error request_id=%s resource_type=%s id=%s url=%s error=%v elapsed=%s",
 					requestID, resourceType, id, url, err, time.Since(localStart),
 				)
+				mu.Lock()
+				failures = append(failures, models.FAILED_ITEM{Name: "pr_list", Status: StatusFromError(err), Error: err.Error()})
+				mu.Unlock()
 				return
 			}
 			mu.Lock()
@@ -57,18 +64,21 @@ func FetchDiscussions(id, resourceType string, includePRs, includeDiscussion boo
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			url := fmt.Sprintf("https://huggingface.co/api/%s/%s/discussions?types=discussion&status=all", resourceType, id)
+			url := DiscussionListURL(resourceType, id, "discussion", filter)
 			localStart := time.Now()
 			log.Printf(
 				"op=FetchDiscussions stage=fetch_discussions_start request_id=%s resource_type=%s id=%s url=%s",
 				requestID, resourceType, id, url,
 			)
-			discs, err := GetDiscussionsFromURL(url)
+			discs, err := GetDiscussionsFromURL(url, scanKey)
 			if err != nil {
 				log.Printf(
 					"op=FetchDiscussions stage=fetch_discussions_error request_id=%s resource_type=%s id=%s url=%s error=%v elapsed=%s",
 					requestID, resourceType, id, url, err, time.Since(localStart),
 				)
+				mu.Lock()
+				failures = append(failures, models.FAILED_ITEM{Name: "discussion_list", Status: StatusFromError(err), Error: err.Error()})
+				mu.Unlock()
 				return
 			}
 			mu.Lock()
@@ -83,15 +93,73 @@ func FetchDiscussions(id, resourceType string, includePRs, includeDiscussion boo
 
 	wg.Wait()
 
+	discussions = FilterDiscussions(discussions, filter)
+	discussions = AttachDiscussionEvents(resourceType, id, discussions, scanKey)
+
 	log.Printf(
-		"op=FetchDiscussions stage=success request_id=%s resource_type=%s id=%s total_count=%d total_elapsed=%s",
-		requestID, resourceType, id, len(discussions), time.Since(start),
+		"op=FetchDiscussions stage=success request_id=%s resource_type=%s id=%s total_count=%d list_failures=%d total_elapsed=%s",
+		requestID, resourceType, id, len(discussions), len(failures), time.Since(start),
 	)
 
+	// jo mila wo bhi wapas, saath me batao ki kaunsi list fetch nahi hui
+	if len(failures) > 0 {
+		return discussions, &DiscussionFetchError{Failures: failures}
+	}
 	return discussions, nil
 }
 
-func GetDiscussionsFromURL(url string) ([]models.DISCUSSION, error) {
+// PR / discussion list fetch fail hui, discussions adhoore hai
+type DiscussionFetchError struct {
+	Failures []models.FAILED_ITEM
+}
+
+func (e *DiscussionFetchError) Error() string {
+	names := []string{}
+	for _, failure := range e.Failures {
+		names = append(names, failure.Name)
+	}
+	return "failed to fetch " + strings.Join(names, ", ")
+}
+
+// list mil gayi par uske kuch hisse (ek PR ki diff, history ka page / commit) nahi mile;
+// jo mila wo result me hai, ye batata hai kya chhoot gaya
+type PartialFetchError struct {
+	Failures []models.FAILED_ITEM
+}
+
+func (e *PartialFetchError) Error() string {
+	names := []string{}
+	for _, failure := range e.Failures {
+		names = append(names, failure.Name)
+	}
+	return "failed to fetch " + strings.Join(names, ", ")
+}
+
+// PR diff / history fetch ke error se coverage failures; poora fetch hi fail hua to name wala ek failure
+func FetchFailures(err error, name string) []models.FAILED_ITEM {
+	if err == nil {
+		return nil
+	}
+	var partial *PartialFetchError
+	if errors.As(err, &partial) {
+		return partial.Failures
+	}
+	return []models.FAILED_ITEM{{Name: name, Status: StatusFromError(err), Error: err.Error()}}
+}
+
+// FetchDiscussions ke error se coverage ke liye failures, koi aur error ho to wahi ek failure
+func DiscussionFailures(err error) []models.FAILED_ITEM {
+	if err == nil {
+		return nil
+	}
+	var fetchErr *DiscussionFetchError
+	if errors.As(err, &fetchErr) {
+		return fetchErr.Failures
+	}
+	return []models.FAILED_ITEM{{Name: "discussions", Status: StatusFromError(err), Error: err.Error()}}
+}
+
+func GetDiscussionsFromURL(url string, scanKey string) ([]models.DISCUSSION, error) {
 	start := time.Now()
 	requestID := uuid.New().String()
 
@@ -100,7 +168,7 @@ func GetDiscussionsFromURL(url string) ([]models.DISCUSSION, error) {
 		requestID, url,
 	)
 
-	resp, err := httpClient.Get(url)
+	resp, err := hubGet(scanKey, url)
 	if err != nil {
 		log.Printf(
 			"op=GetDiscussionsFromURL stage=http_get_error request_id=%s url=%s error=%v elapsed=%s",
@@ -115,6 +183,10 @@ func GetDiscussionsFromURL(url string) ([]models.DISCUSSION, error) {
 		requestID, url, resp.StatusCode, resp.Header.Get("Content-Length"),
 	)
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{URL: url, Status: resp.StatusCode}
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf(
@@ -129,8 +201,14 @@ func GetDiscussionsFromURL(url string) ([]models.DISCUSSION, error) {
 		requestID, url, len(body),
 	)
 
+	// list endpoint {"discussions": [...], "count": N} deta hai, purana format seedha array tha
 	var rawDiscussions []map[string]interface{}
-	if err := json.Unmarshal(body, &rawDiscussions); err != nil {
+	var wrapped struct {
+		Discussions []map[string]interface{} `json:"discussions"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err == nil && wrapped.Discussions != nil {
+		rawDiscussions = wrapped.Discussions
+	} else if err := json.Unmarshal(body, &rawDiscussions); err != nil {
 		log.Printf(
 			"op=GetDiscussionsFromURL stage=json_unmarshal_error request_id=%s url=%s error=%v elapsed=%s",
 			requestID, url, err, time.Since(start),
@@ -182,3 +260,217 @@ func GetDiscussionsFromURL(url string) ([]models.DISCUSSION, error) {
 
 	return discussions, nil
 }
+
+// discussions ko status aur created date se filter karne ke liye
+// Status: "", "all", "open", "closed", "merged" ya "draft"
+type DiscussionFilter struct {
+	Status       string
+	CreatedSince time.Time
+}
+
+var discussionStatuses = map[string]bool{
+	"": true, "all": true, "open": true, "closed": true, "merged": true, "draft": true,
+}
+
+// request se aaye status aur since ko validate karke filter banata hai,
+// since can be RFC3339 ("2024-05-01T00:00:00Z") or a plain date ("2024-05-01")
+func ParseDiscussionFilter(status, since string) (DiscussionFilter, error) {
+	filter := DiscussionFilter{Status: strings.ToLower(strings.TrimSpace(status))}
+	if !discussionStatuses[filter.Status] {
+		return filter, fmt.Errorf("invalid discussion status: %s", status)
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	}
+
+	since = strings.TrimSpace(since)
+	if since == "" {
+		return filter, nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		filter.CreatedSince = t
+		return filter, nil
+	}
+	if t, err := time.Parse("2006-01-02", since); err == nil {
+		filter.CreatedSince = t
+		return filter, nil
+	}
+	return filter, fmt.Errorf("invalid discussions_since date: %s", since)
+}
+
+func (f DiscussionFilter) SinceString() string {
+	if f.CreatedSince.IsZero() {
+		return ""
+	}
+	return f.CreatedSince.Format(time.RFC3339)
+}
+
+// hf api sirf open / closed / all samajhta hai, merged aur draft hum khud filter karte hai
+func DiscussionListURL(resourceType, id, discussionType string, filter DiscussionFilter) string {
+	status := "all"
+	if filter.Status == "open" || filter.Status == "closed" {
+		status = filter.Status
+	}
+	return fmt.Sprintf("%s/api/%s/%s/discussions?types=%s&status=%s", HFBaseURL(), resourceType, id, discussionType, status)
+}
+
+func FilterDiscussions(discussions []models.DISCUSSION, filter DiscussionFilter) []models.DISCUSSION {
+	if filter.Status == "" && filter.CreatedSince.IsZero() {
+		return discussions
+	}
+
+	filtered := []models.DISCUSSION{}
+	for _, disc := range discussions {
+		if filter.Status != "" && !strings.EqualFold(disc.Status, filter.Status) {
+			continue
+		}
+		if !filter.CreatedSince.IsZero() {
+			createdAt, err := time.Parse(time.RFC3339, disc.CreatedAt)
+			if err != nil || createdAt.Before(filter.CreatedSince) {
+				continue
+			}
+		}
+		filtered = append(filtered, disc)
+	}
+	return filtered
+}
+
+// har discussion ka thread fetch karke uske events (comments, edits, title changes) attach karta hai
+func AttachDiscussionEvents(resourceType, id string, discussions []models.DISCUSSION, scanKey string) []models.DISCUSSION {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10)
+
+	for i := range discussions {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			events, err := FetchDiscussionEvents(resourceType, id, discussions[index].Num, scanKey)
+			if err != nil {
+				discussions[index].EventsError = err.Error()
+				return
+			}
+			// har goroutine apna index likhti hai, isliye lock ki zarurat nahi
+			discussions[index].Events = events
+		}(i)
+	}
+	wg.Wait()
+
+	return discussions
+}
+
+// /api/{type}/{id}/discussions/{num} se ek thread ke saare events nikalta hai
+func FetchDiscussionEvents(resourceType, id string, num int64, scanKey string) ([]models.DISCUSSION_EVENT, error) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	url := fmt.Sprintf("%s/api/%s/%s/discussions/%d", HFBaseURL(), resourceType, id, num)
+	log.Printf(
+		"op=FetchDiscussionEvents stage=start request_id=%s url=%s",
+		requestID, url,
+	)
+
+	resp, err := hubGet(scanKey, url)
+	if err != nil {
+		log.Printf(
+			"op=FetchDiscussionEvents stage=http_get_error request_id=%s url=%s error=%v elapsed=%s",
+			requestID, url, err, time.Since(start),
+		)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf(
+			"op=FetchDiscussionEvents stage=not_ok request_id=%s url=%s status=%d elapsed=%s",
+			requestID, url, resp.StatusCode, time.Since(start),
+		)
+		return nil, &HTTPStatusError{URL: url, Status: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf(
+			"op=FetchDiscussionEvents stage=read_error request_id=%s url=%s error=%v elapsed=%s",
+			requestID, url, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	var thread struct {
+		Events []struct {
+			ID        string `json:"id"`
+			Type      string `json:"type"`
+			CreatedAt string `json:"createdAt"`
+			Author    struct {
+				Name string `json:"name"`
+			} `json:"author"`
+			Data struct {
+				Edited bool `json:"edited"`
+				Latest struct {
+					Raw       string `json:"raw"`
+					UpdatedAt string `json:"updatedAt"`
+				} `json:"latest"`
+				History []struct {
+					Raw       string `json:"raw"`
+					UpdatedAt string `json:"updatedAt"`
+				} `json:"history"`
+				From string `json:"from"`
+				To   string `json:"to"`
+			} `json:"data"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(body, &thread); err != nil {
+		log.Printf(
+			"op=FetchDiscussionEvents stage=json_unmarshal_error request_id=%s url=%s error=%v elapsed=%s",
+			requestID, url, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	events := []models.DISCUSSION_EVENT{}
+	for _, ev := range thread.Events {
+		switch ev.Type {
+		case "comment":
+			events = append(events, models.DISCUSSION_EVENT{
+				ID:         ev.ID,
+				Type:       "comment",
+				AuthorName: ev.Author.Name,
+				CreatedAt:  ev.CreatedAt,
+				Content:    ev.Data.Latest.Raw,
+				Edited:     ev.Data.Edited,
+			})
+			// purane edits me bhi secret reh jaata hai, to unko bhi scan karna hai
+			for _, edit := range ev.Data.History {
+				if edit.Raw == "" || edit.Raw == ev.Data.Latest.Raw {
+					continue
+				}
+				events = append(events, models.DISCUSSION_EVENT{
+					ID:         ev.ID,
+					Type:       "comment-edit",
+					AuthorName: ev.Author.Name,
+					CreatedAt:  edit.UpdatedAt,
+					Content:    edit.Raw,
+					Edited:     true,
+				})
+			}
+		case "title-change":
+			events = append(events, models.DISCUSSION_EVENT{
+				ID:         ev.ID,
+				Type:       "title-change",
+				AuthorName: ev.Author.Name,
+				CreatedAt:  ev.CreatedAt,
+				Content:    ev.Data.From + "\n" + ev.Data.To,
+			})
+		}
+	}
+
+	log.Printf(
+		"op=FetchDiscussionEvents stage=success request_id=%s url=%s events=%d total_elapsed=%s",
+		requestID, url, len(events), time.Since(start),
+	)
+
+	return events, nil
+}
@@ -0,0 +1,202 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+func TestParseDiscussionFilter(t *testing.T) {
+	tests := []struct {
+		status  string
+		since   string
+		want    DiscussionFilter
+		wantErr bool
+	}{
+		{status: "", since: "", want: DiscussionFilter{}},
+		{status: "All", since: "", want: DiscussionFilter{}},
+		{status: " Draft ", since: "", want: DiscussionFilter{Status: "draft"}},
+		{status: "open", since: "2024-05-01", want: DiscussionFilter{Status: "open", CreatedSince: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}},
+		{status: "merged", since: "2024-05-01T10:30:00Z", want: DiscussionFilter{Status: "merged", CreatedSince: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)}},
+		{status: "pending", since: "", wantErr: true},
+		{status: "open", since: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDiscussionFilter(tt.status, tt.since)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDiscussionFilter(%q, %q) error = %v, want error %t", tt.status, tt.since, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (got.Status != tt.want.Status || !got.CreatedSince.Equal(tt.want.CreatedSince)) {
+			t.Errorf("ParseDiscussionFilter(%q, %q) = %+v, want %+v", tt.status, tt.since, got, tt.want)
+		}
+	}
+}
+
+// acme/demo ke do discussions aur do PRs; Hub ki tarah status=open|closed upstream hi filter hota hai.
+// #1 ke comment ka purana edit aur #2 / #3 ke comments me token hai, #4 ka thread 500 deta hai
+func discussionHub(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	type listed struct {
+		Num           int64  `json:"num"`
+		Title         string `json:"title"`
+		Status        string `json:"status"`
+		IsPullRequest bool   `json:"isPullRequest"`
+		CreatedAt     string `json:"createdAt"`
+	}
+	all := []listed{
+		{1, "Leaked key", "open", false, "2024-04-01T10:00:00Z"},
+		{2, "Old question", "closed", false, "2024-06-01T10:00:00Z"},
+		{3, "Add weights", "merged", true, "2024-06-15T10:00:00Z"},
+		{4, "WIP", "draft", true, "2024-07-01T10:00:00Z"},
+	}
+	var mu sync.Mutex
+	statuses := []string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/models/acme/demo/discussions" {
+			status := r.URL.Query().Get("status")
+			mu.Lock()
+			statuses = append(statuses, r.URL.Query().Get("types")+"="+status)
+			mu.Unlock()
+			rows := []listed{}
+			for _, disc := range all {
+				if disc.IsPullRequest == (r.URL.Query().Get("types") == "pr") && (status == "all" || status == disc.Status) {
+					rows = append(rows, disc)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"discussions": rows, "count": len(rows)})
+			return
+		}
+		var num int
+		if _, err := fmt.Sscanf(r.URL.Path, "/api/models/acme/demo/discussions/%d", &num); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		latest, history := "thanks", ""
+		switch num {
+		case 1:
+			history = fmt.Sprintf(`,"history":[{"raw":"thanks","updatedAt":"2024-04-03T10:00:00Z"},{"raw":"GITHUB_TOKEN=%s","updatedAt":"2024-04-02T10:00:00Z"}]`, discussionToken(1))
+		case 2, 3:
+			latest = "GITHUB_TOKEN=" + discussionToken(num)
+		case 4:
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"events":[`+
+			`{"id":"c%d","type":"comment","createdAt":"2024-08-0%dT10:00:00Z","author":{"name":"user%d"},"data":{"edited":%t,"latest":{"raw":%q}%s}},`+
+			`{"id":"s%d","type":"status-change","createdAt":"2024-08-09T10:00:00Z","author":{"name":"admin"},"data":{"status":"closed"}}]}`,
+			num, num, num, history != "", latest, history, num)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(statuses)
+		out := statuses
+		statuses = []string{}
+		return out
+	}
+}
+
+func discussionToken(num int) string {
+	return fmt.Sprintf("ghp_Disc%dKeyAbCdEfGhIjKlMnOpQrStUvWxYz12", num)
+}
+
+func discussionNums(discussions []models.DISCUSSION) string {
+	nums := []string{}
+	for _, disc := range discussions {
+		nums = append(nums, fmt.Sprint(disc.Num))
+	}
+	sort.Strings(nums)
+	return strings.Join(nums, ",")
+}
+
+func TestFetchDiscussionsFilters(t *testing.T) {
+	hub, upstream := discussionHub(t)
+	t.Setenv("HF_BASE_URL", hub.URL)
+
+	tests := []struct {
+		status   string
+		since    string
+		nums     string
+		upstream string
+	}{
+		{status: "all", nums: "1,2,3,4", upstream: "discussion=all,pr=all"},
+		// open / closed Hub khud filter karta hai
+		{status: "open", nums: "1", upstream: "discussion=open,pr=open"},
+		{status: "closed", nums: "2", upstream: "discussion=closed,pr=closed"},
+		// merged / draft Hub nahi samajhta, saari list aake yahan filter hoti hai
+		{status: "merged", nums: "3", upstream: "discussion=all,pr=all"},
+		{status: "draft", nums: "4", upstream: "discussion=all,pr=all"},
+		{since: "2024-06-01", nums: "2,3,4", upstream: "discussion=all,pr=all"},
+		{status: "open", since: "2024-05-01", nums: "", upstream: "discussion=open,pr=open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status+"_"+tt.since, func(t *testing.T) {
+			filter, err := ParseDiscussionFilter(tt.status, tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			discussions, err := FetchDiscussions("acme/demo", "models", true, true, filter, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := discussionNums(discussions); got != tt.nums {
+				t.Errorf("discussions = %s, want %s", got, tt.nums)
+			}
+			if got := strings.Join(upstream(), ","); got != tt.upstream {
+				t.Errorf("upstream lists = %s, want %s", got, tt.upstream)
+			}
+		})
+	}
+}
+
+// comments aur unke purane edits scan hote hai; finding pe comment id, author aur waqt wahi jo event ka tha
+func TestAttachDiscussionEventsScansComments(t *testing.T) {
+	hub, _ := discussionHub(t)
+	t.Setenv("HF_BASE_URL", hub.URL)
+
+	listed := []models.DISCUSSION{{Num: 1, Title: "Leaked key"}, {Num: 2}, {Num: 3, IsPullRequest: true}, {Num: 4, IsPullRequest: true}}
+	discussions := AttachDiscussionEvents("models", "acme/demo", listed, "")
+	if discussions[3].EventsError == "" || len(discussions[3].Events) != 0 {
+		t.Errorf("#4 events = %+v error = %q, want the thread failure recorded", discussions[3].Events, discussions[3].EventsError)
+	}
+	// #1: latest comment aur uska ek alag purana edit; status-change events scan nahi hote
+	if len(discussions[0].Events) != 2 || discussions[0].Events[1].Type != "comment-edit" {
+		t.Errorf("#1 events = %+v, want comment and one edit", discussions[0].Events)
+	}
+
+	type commentFinding struct {
+		num       int64
+		commentID string
+		author    string
+		created   string
+		secret    string
+	}
+	want := []commentFinding{
+		{1, "c1", "user1", "2024-04-02T10:00:00Z", discussionToken(1)},
+		{2, "c2", "user2", "2024-08-02T10:00:00Z", discussionToken(2)},
+		{3, "c3", "user3", "2024-08-03T10:00:00Z", discussionToken(3)},
+	}
+	got := []commentFinding{}
+	for _, disc := range discussions {
+		for _, finding := range ScanDiscussion(disc, ActiveRuleSet().Patterns, "models", "acme/demo") {
+			got = append(got, commentFinding{finding.DiscussionNum, finding.CommentID, finding.CommentAuthor, finding.CommentCreated, finding.Secret})
+			if !strings.HasSuffix(finding.URL, "#"+finding.CommentID) {
+				t.Errorf("#%d finding url = %s, want the comment anchor", finding.DiscussionNum, finding.URL)
+			}
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("findings = %+v\nwant %+v", got, want)
+	}
+}
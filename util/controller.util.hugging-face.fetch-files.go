@@ -2,8 +2,8 @@ package util
 
 import (
 	"fmt"
-	"io"
 	"log"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -21,130 +21,265 @@ func init() {
 var httpClient = SharedHTTPClient()
 
 // fetches only readable file content, (sirf padhne layak siblings)
-func FetchFileContent(resourceID, filename string) models.SIBLING {
+// fetch policy ke action ke hisaab se poori file, pehle N bytes (Range) ya stream;
+// content khud LFS pointer ya binary nikla to skip reason ke saath wapas, fetch fail hua to error
+func FetchFileContent(resourceType, resourceID string, file models.SIBLING, policy FetchPolicy, action FetchAction, scanKey string) (models.SIBLING, string, error) {
 	start := time.Now()
 	requestID := uuid.New().String()
+	filename := file.RFilename
 
 	// ye ek sample siling hai jisme fetched content of a file content save karte hai ham loog
-	sibling := models.SIBLING{
-		RFilename:   filename,
-		FileContent: "",
-	}
+	sibling := file
+	sibling.FileContent = ""
+	sibling.FetchMode = string(action)
 
-	fileURL := fmt.Sprintf("https://huggingface.co/%s/resolve/main/%s", resourceID, filename)
+	fileURL := fmt.Sprintf("%s/%s/resolve/main/%s", HFBaseURL(), HuggingFaceRepoPath(resourceType, resourceID), filename)
 
 	log.Printf(
-		"op=FetchFileContent stage=start request_id=%s resource_id=%s filename=%s url=%s",
-		requestID, resourceID, filename, fileURL,
+		"op=FetchFileContent stage=start request_id=%s resource_id=%s filename=%s url=%s action=%s size=%d",
+		requestID, resourceID, filename, fileURL, action, file.Size,
 	)
 
-	resp, err := httpClient.Get(fileURL)
+	req, err := http.NewRequestWithContext(scanContext(scanKey), http.MethodGet, fileURL, nil)
+	if err != nil {
+		return sibling, "", err
+	}
+	if action == FetchSample {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", policy.SampleBytes))
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		log.Printf(
 			"op=FetchFileContent stage=http_get_error request_id=%s resource_id=%s filename=%s url=%s error=%v elapsed=%s",
 			requestID, resourceID, filename, fileURL, err, time.Since(start),
 		)
-		return sibling
+		return sibling, "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		log.Printf(
 			"op=FetchFileContent stage=not_ok request_id=%s resource_id=%s filename=%s status=%d elapsed=%s",
 			requestID, resourceID, filename, resp.StatusCode, time.Since(start),
 		)
-		return sibling
+		return sibling, "", &HTTPStatusError{URL: fileURL, Status: resp.StatusCode}
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	content, truncated, err := policy.ReadBody(resp.Body, action)
 	if err != nil {
 		log.Printf(
 			"op=FetchFileContent stage=read_error request_id=%s resource_id=%s filename=%s error=%v elapsed=%s",
 			requestID, resourceID, filename, err, time.Since(start),
 		)
-		return sibling
+		return sibling, "", err
+	}
+
+	// repo me asli file ki jagah pointer hi commit hua hai, isme scan karne layak kuch nahi
+	if oid, size, ok := ParseLFSPointer(content); ok {
+		log.Printf(
+			"op=FetchFileContent stage=lfs_pointer request_id=%s resource_id=%s filename=%s oid=%s size=%d elapsed=%s",
+			requestID, resourceID, filename, oid, size, time.Since(start),
+		)
+		sibling.LFSSha256 = oid
+		sibling.Size = size
+		return sibling, SkipReasonLFSPointer, nil
+	}
+
+	// extension text wala hai par andar binary data hai
+	if LooksBinary(content) {
+		log.Printf(
+			"op=FetchFileContent stage=binary request_id=%s resource_id=%s filename=%s elapsed=%s",
+			requestID, resourceID, filename, time.Since(start),
+		)
+		return sibling, SkipReasonBinary, nil
 	}
 
 	log.Printf(
-		"op=FetchFileContent stage=success request_id=%s resource_id=%s filename=%s bytes=%d total_elapsed=%s",
-		requestID, resourceID, filename, len(body), time.Since(start),
+		"op=FetchFileContent stage=success request_id=%s resource_id=%s filename=%s bytes=%d truncated=%t total_elapsed=%s",
+		requestID, resourceID, filename, len(content), truncated, time.Since(start),
 	)
 
-	sibling.FileContent = string(body)
-	return sibling
+	sibling.FileContent = content
+	sibling.Truncated = truncated
+	return sibling, "", nil
 }
 
 // the siblings have the files names and we need to fetch their content,
 // we use above helper function to fetch content of readable files concurrently
-func FetchFilesFromSiblings(resourceID string, siblings []interface{}) []models.SIBLING {
+func FetchFilesFromSiblings(resourceType, resourceID string, siblings []interface{}, scanKey string) []models.SIBLING {
+	files, _, _ := FetchSiblingFiles(resourceType, resourceID, siblings, scanKey, nil)
+	return files
+}
+
+// FetchFilesFromSiblings jaisa hi, bas fetch policy ke skip decisions (extension, size, lfs, binary)
+// aur fail hui files (status / error ke saath) bhi deta hai taaki coverage report ban sake
+func FetchSiblingFiles(resourceType, resourceID string, siblings []interface{}, scanKey string, budget *ByteBudget) ([]models.SIBLING, []models.SKIPPED_FILE, []models.FAILED_ITEM) {
+	candidates := []models.SIBLING{}
+	for _, sib := range siblings {
+		if sibMap, ok := sib.(map[string]interface{}); ok {
+			if _, ok := sibMap["rfilename"].(string); ok {
+				candidates = append(candidates, siblingMetadata(sibMap))
+			}
+		}
+	}
+	return FetchFilesWith(resourceID, candidates, budget, func(file models.SIBLING, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error) {
+		return FetchFileContent(resourceType, resourceID, file, policy, action, scanKey)
+	})
+}
+
+// ek file ka content laane ka tareeka provider pe depend karta hai (HF resolve url, forge raw api, ...);
+// skip reason tab jab content LFS pointer / binary nikle
+type FileFetcher func(file models.SIBLING, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error)
+
+// candidates (naam + blob metadata) me se readable files concurrently laata hai:
+// pehle blob cache, phir fetch policy, phir byte budget, phir provider ka fetcher
+func FetchFilesWith(resourceID string, candidates []models.SIBLING, budget *ByteBudget, fetch FileFetcher) ([]models.SIBLING, []models.SKIPPED_FILE, []models.FAILED_ITEM) {
 	start := time.Now()
 	requestID := uuid.New().String()
 
 	var result []models.SIBLING
+	skipped := []models.SKIPPED_FILE{}
+	failed := []models.FAILED_ITEM{}
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	semaphore := make(chan struct{}, 20)
+	policy := DefaultFetchPolicy()
 
 	log.Printf(
 		"op=FetchFilesFromSiblings stage=start request_id=%s resource_id=%s total_candidates=%d",
-		requestID, resourceID, len(siblings),
+		requestID, resourceID, len(candidates),
 	)
 
-	total := len(siblings)
+	total := len(candidates)
 
-	for idx, sib := range siblings {
-		if sibMap, ok := sib.(map[string]interface{}); ok {
-			if filename, ok := sibMap["rfilename"].(string); ok {
-				ext := strings.ToLower(filepath.Ext(filename))
-
-				// agar wo files readable nahi hai toh skip kar denge
-				if !TextExtensions[ext] {
-					log.Printf(
-						"op=FetchFilesFromSiblings stage=skip_non_readable request_id=%s resource_id=%s filename=%s ext=%s",
-						requestID, resourceID, filename, ext,
-					)
-					continue
-				}
-				wg.Add(1)
-				go func(fname string, index int, totalN int) {
-					defer wg.Done()
-
-					// semaphore basically is amde using buffered channel
-					// adds  some thing to a semaphore so it's size becomes one less (limits concurrent goroutines)
-					semaphore <- struct{}{}
-
-					// removes from semaphore to free up space (makes size one more)
-					defer func() { <-semaphore }()
-
-					// defer is a treated like a stack
-
-					localStart := time.Now()
-					log.Printf(
-						"op=FetchFilesFromSiblings stage=fetch_start request_id=%s resource_id=%s index=%d total=%d filename=%s",
-						requestID, resourceID, index+1, totalN, fname,
-					)
-
-					sibling := FetchFileContent(resourceID, fname)
-
-					// sibling jo extract hua hai usko add karenge atomic tareh se
-					mu.Lock()
-					result = append(result, sibling)
-					mu.Unlock()
-					log.Printf(
-						"op=FetchFilesFromSiblings stage=fetch_done request_id=%s resource_id=%s index=%d total=%d filename=%s elapsed=%s",
-						requestID, resourceID, index+1, totalN, fname, time.Since(localStart),
-					)
-				}(filename, idx, total)
-			}
+	for idx, candidate := range candidates {
+		filename := candidate.RFilename
+		ext := strings.ToLower(filepath.Ext(filename))
+		meta := candidate
+
+		// agar wo files readable nahi hai toh skip kar denge
+		if !TextExtensions[ext] {
+			log.Printf(
+				"op=FetchFilesFromSiblings stage=skip_non_readable request_id=%s resource_id=%s filename=%s ext=%s",
+				requestID, resourceID, filename, ext,
+			)
+			skipped = append(skipped, models.SKIPPED_FILE{RFilename: filename, Reason: SkipReasonExtension, Size: meta.Size})
+			continue
 		}
+		wg.Add(1)
+		go func(fname string, index int, totalN int) {
+			defer wg.Done()
+
+			// semaphore basically is amde using buffered channel
+			// adds  some thing to a semaphore so it's size becomes one less (limits concurrent goroutines)
+			semaphore <- struct{}{}
+
+			// removes from semaphore to free up space (makes size one more)
+			defer func() { <-semaphore }()
+
+			// defer is a treated like a stack
+
+			localStart := time.Now()
+			log.Printf(
+				"op=FetchFilesFromSiblings stage=fetch_start request_id=%s resource_id=%s index=%d total=%d filename=%s",
+				requestID, resourceID, index+1, totalN, fname,
+			)
+
+			// same blob pehle scan ho chuka hai to download ki zaroorat nahi
+			if matches, ok := LookupBlobScan(BlobCacheKey(meta)); ok {
+				meta.Cached = true
+				meta.CachedMatches = append([]models.BLOB_MATCH{}, matches...)
+				mu.Lock()
+				result = append(result, meta)
+				mu.Unlock()
+				log.Printf(
+					"op=FetchFilesFromSiblings stage=cache_hit request_id=%s resource_id=%s index=%d total=%d filename=%s blob_key=%s",
+					requestID, resourceID, index+1, totalN, fname, BlobCacheKey(meta),
+				)
+				return
+			}
+
+			action, reason := policy.Decide(meta)
+			if action == FetchSkip {
+				log.Printf(
+					"op=FetchFilesFromSiblings stage=skip_policy request_id=%s resource_id=%s filename=%s reason=%s size=%d",
+					requestID, resourceID, fname, reason, meta.Size,
+				)
+				mu.Lock()
+				skipped = append(skipped, models.SKIPPED_FILE{RFilename: fname, Reason: reason, Size: meta.Size})
+				mu.Unlock()
+				return
+			}
+
+			// budget har file pe check hota hai, poora repo download hone ke baad nahi
+			reserved := policy.ExpectedBytes(meta, action)
+			if !budget.Reserve(reserved) {
+				log.Printf(
+					"op=FetchFilesFromSiblings stage=skip_budget request_id=%s resource_id=%s filename=%s size=%d used=%d",
+					requestID, resourceID, fname, meta.Size, budget.Used(),
+				)
+				mu.Lock()
+				skipped = append(skipped, models.SKIPPED_FILE{RFilename: fname, Reason: SkipReasonBudget, Size: meta.Size})
+				mu.Unlock()
+				return
+			}
+
+			sibling, reason, err := fetch(meta, policy, action)
+			budget.Adjust(int64(len(sibling.FileContent)) - reserved)
+			if err != nil {
+				mu.Lock()
+				failed = append(failed, models.FAILED_ITEM{Name: fname, Status: StatusFromError(err), Error: err.Error()})
+				mu.Unlock()
+				return
+			}
+			if reason != "" {
+				mu.Lock()
+				skipped = append(skipped, models.SKIPPED_FILE{RFilename: fname, Reason: reason, Size: sibling.Size})
+				mu.Unlock()
+				return
+			}
+
+			// sibling jo extract hua hai usko add karenge atomic tareh se
+			mu.Lock()
+			result = append(result, sibling)
+			mu.Unlock()
+			log.Printf(
+				"op=FetchFilesFromSiblings stage=fetch_done request_id=%s resource_id=%s index=%d total=%d filename=%s action=%s elapsed=%s",
+				requestID, resourceID, index+1, totalN, fname, action, time.Since(localStart),
+			)
+		}(filename, idx, total)
 	}
 
 	wg.Wait()
 
 	log.Printf(
-		"op=FetchFilesFromSiblings stage=success request_id=%s resource_id=%s fetched=%d total_candidates=%d total_elapsed=%s",
-		requestID, resourceID, len(result), len(siblings), time.Since(start),
+		"op=FetchFilesFromSiblings stage=success request_id=%s resource_id=%s fetched=%d skipped=%d failed=%d total_candidates=%d total_elapsed=%s",
+		requestID, resourceID, len(result), len(skipped), len(failed), len(candidates), time.Since(start),
 	)
 
-	return result
+	return result, skipped, failed
+}
+
+// info endpoint ?blobs=true pe har sibling ke saath blobId, size aur lfs.sha256 bhi deta hai
+func siblingMetadata(sibMap map[string]interface{}) models.SIBLING {
+	sibling := models.SIBLING{}
+	if filename, ok := sibMap["rfilename"].(string); ok {
+		sibling.RFilename = filename
+	}
+	if blobID, ok := sibMap["blobId"].(string); ok {
+		sibling.BlobID = blobID
+	}
+	if size, ok := sibMap["size"].(float64); ok {
+		sibling.Size = int64(size)
+	}
+	if lfs, ok := sibMap["lfs"].(map[string]interface{}); ok {
+		if sha, ok := lfs["sha256"].(string); ok {
+			sibling.LFSSha256 = sha
+		}
+		if size, ok := lfs["size"].(float64); ok {
+			sibling.Size = int64(size)
+		}
+	}
+	return sibling
 }
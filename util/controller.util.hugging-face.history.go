@@ -0,0 +1,137 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// history mode me kitne commits tak peeche jaana hai
+func HistoryMaxCommits() int {
+	maxCommits, err := strconv.Atoi(GetEnv("HISTORY_MAX_COMMITS", "100"))
+	if err != nil || maxCommits <= 0 {
+		return 100
+	}
+	return maxCommits
+}
+
+// repo ki commit list (newest first aati hai, pages me) nikal ke har commit ki diff le aata hai,
+// result oldest first hota hai taaki secret ka first / last commit seedha track ho sake
+func FetchCommitHistory(id, resourceType string, maxCommits int, scanKey string) ([]models.COMMIT_DIFF, error) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	log.Printf(
+		"op=FetchCommitHistory stage=start request_id=%s resource_type=%s id=%s max_commits=%d",
+		requestID, resourceType, id, maxCommits,
+	)
+
+	var commits []models.COMMIT_DIFF
+	var failures []models.FAILED_ITEM
+	for page := 0; len(commits) < maxCommits; page++ {
+		pageCommits, err := fetchCommitPage(resourceType, id, page, scanKey)
+		if err != nil {
+			log.Printf(
+				"op=FetchCommitHistory stage=list_error request_id=%s resource_type=%s id=%s page=%d error=%v elapsed=%s",
+				requestID, resourceType, id, page, err, time.Since(start),
+			)
+			if len(commits) == 0 {
+				return nil, err
+			}
+			// aage ke (purane) commits nahi dekhe gaye
+			failures = append(failures, models.FAILED_ITEM{Name: fmt.Sprintf("history page %d", page), Status: StatusFromError(err), Error: err.Error()})
+			break
+		}
+		if len(pageCommits) == 0 {
+			break
+		}
+		commits = append(commits, pageCommits...)
+	}
+	if len(commits) > maxCommits {
+		commits = commits[:maxCommits]
+	}
+
+	// oldest first
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, 5)
+	for i := range commits {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			diff, err := FetchCommitDiff(resourceType, id, commits[index].OID, scanKey)
+			if err != nil {
+				mu.Lock()
+				failures = append(failures, models.FAILED_ITEM{Name: "commit " + commits[index].OID, Status: StatusFromError(err), Error: err.Error()})
+				mu.Unlock()
+				commits[index].Failed = true
+				return
+			}
+			commits[index].Diff = diff
+		}(i)
+	}
+	wg.Wait()
+
+	log.Printf(
+		"op=FetchCommitHistory stage=success request_id=%s resource_type=%s id=%s commits=%d failed=%d total_elapsed=%s",
+		requestID, resourceType, id, len(commits), len(failures), time.Since(start),
+	)
+
+	if len(failures) > 0 {
+		return commits, &PartialFetchError{Failures: failures}
+	}
+	return commits, nil
+}
+
+func fetchCommitPage(resourceType, id string, page int, scanKey string) ([]models.COMMIT_DIFF, error) {
+	url := fmt.Sprintf("%s/api/%s/%s/commits/main?p=%d", HFBaseURL(), resourceType, id, page)
+
+	resp, err := hubGet(scanKey, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list commits: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawCommits []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Date  string `json:"date"`
+	}
+	if err := json.Unmarshal(body, &rawCommits); err != nil {
+		return nil, err
+	}
+
+	commits := []models.COMMIT_DIFF{}
+	for _, c := range rawCommits {
+		commits = append(commits, models.COMMIT_DIFF{
+			OID:   c.ID,
+			Title: c.Title,
+			Date:  c.Date,
+		})
+	}
+	return commits, nil
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+func TestFetchCommitHistoryReportsPartialFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/models/org/repo/commits/main" && r.URL.Query().Get("p") == "0":
+			fmt.Fprint(w, `[{"id":"bbb","title":"second"},{"id":"aaa","title":"first"}]`)
+		case r.URL.Path == "/api/models/org/repo/commits/main":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		case r.URL.Path == "/org/repo/commit/aaa.diff":
+			fmt.Fprint(w, "diff --git a/x b/x\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("HF_BASE_URL", server.URL)
+
+	commits, err := FetchCommitHistory("org/repo", "models", 10, "")
+	if len(commits) != 2 || commits[0].OID != "aaa" || commits[0].Diff == "" || commits[0].Failed || !commits[1].Failed {
+		t.Fatalf("commits = %+v, want aaa (with diff) then bbb (failed)", commits)
+	}
+
+	failures := FetchFailures(err, "commit history")
+	names := []string{}
+	for _, failure := range failures {
+		names = append(names, failure.Name)
+	}
+	sort.Strings(names)
+	if want := "commit bbb,history page 1"; strings.Join(names, ",") != want {
+		t.Fatalf("failures = %v, want %s", names, want)
+	}
+}
+
+func TestFetchFailures(t *testing.T) {
+	if got := FetchFailures(nil, "pull requests"); got != nil {
+		t.Errorf("nil error gave failures %v", got)
+	}
+	got := FetchFailures(fmt.Errorf("failed to list: 503"), "pull requests")
+	if len(got) != 1 || got[0].Name != "pull requests" || got[0].Error == "" {
+		t.Errorf("whole fetch failure = %+v, want one pull requests item", got)
+	}
+}
+
+func TestCoverageStatusCountsHistoryFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.AI_REQUEST
+		want string
+	}{
+		{"clean", models.AI_REQUEST{}, CoverageComplete},
+		{"pr diff failed", models.AI_REQUEST{PullRequestsFailed: []models.FAILED_ITEM{{Name: "PR #3"}}}, CoveragePartial},
+		{"history failed", models.AI_REQUEST{HistoryFailed: []models.FAILED_ITEM{{Name: "commit abc"}}}, CoveragePartial},
+	}
+	for _, tt := range tests {
+		if got := CoverageStatus(CoverageFromRequest(tt.req)); got != tt.want {
+			t.Errorf("%s: status = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
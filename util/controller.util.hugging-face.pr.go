@@ -0,0 +1,214 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// sirf open aur merged PRs ki diff chahiye, closed / draft PRs repo me kabhi aaye hi nahi
+var scannablePRStatuses = map[string]bool{
+	"open":   true,
+	"merged": true,
+}
+
+// repo ke PRs list karke har open / merged PR ki diff aur commits le aata hai
+func FetchPullRequestDiffs(id, resourceType string, filter DiscussionFilter, scanKey string) ([]models.PULL_REQUEST, error) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	url := DiscussionListURL(resourceType, id, "pr", filter)
+	log.Printf(
+		"op=FetchPullRequestDiffs stage=start request_id=%s resource_type=%s id=%s url=%s",
+		requestID, resourceType, id, url,
+	)
+
+	prs, err := GetDiscussionsFromURL(url, scanKey)
+	if err != nil {
+		log.Printf(
+			"op=FetchPullRequestDiffs stage=list_error request_id=%s resource_type=%s id=%s error=%v elapsed=%s",
+			requestID, resourceType, id, err, time.Since(start),
+		)
+		return nil, err
+	}
+	prs = FilterDiscussions(prs, filter)
+
+	var pullRequests []models.PULL_REQUEST
+	var failures []models.FAILED_ITEM
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, 5)
+
+	for _, pr := range prs {
+		if !pr.IsPullRequest || !scannablePRStatuses[strings.ToLower(pr.Status)] {
+			continue
+		}
+		wg.Add(1)
+		go func(disc models.DISCUSSION) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			pullRequest, err := FetchPullRequestDetails(resourceType, id, disc, scanKey)
+			mu.Lock()
+			defer mu.Unlock()
+			if pullRequest == nil {
+				failures = append(failures, models.FAILED_ITEM{Name: fmt.Sprintf("PR #%d", disc.Num), Status: StatusFromError(err), Error: err.Error()})
+				return
+			}
+			// PR mila par kuch commits ki diff nahi; wo PR ki poori diff se scan honge, coverage me phir bhi dikhe
+			failures = append(failures, FetchFailures(err, fmt.Sprintf("PR #%d", disc.Num))...)
+			pullRequests = append(pullRequests, *pullRequest)
+		}(pr)
+	}
+	wg.Wait()
+
+	log.Printf(
+		"op=FetchPullRequestDiffs stage=success request_id=%s resource_type=%s id=%s listed=%d fetched=%d failed=%d total_elapsed=%s",
+		requestID, resourceType, id, len(prs), len(pullRequests), len(failures), time.Since(start),
+	)
+
+	if len(failures) > 0 {
+		return pullRequests, &PartialFetchError{Failures: failures}
+	}
+	return pullRequests, nil
+}
+
+// ek PR ki details (?diff=1) se poori diff, commits aur merge commit nikalta hai,
+// phir har commit ki apni diff try karta hai taaki finding sahi commit pe point kare;
+// kisi commit ki diff na mile to PR ke saath PartialFetchError bhi aata hai
+func FetchPullRequestDetails(resourceType, id string, disc models.DISCUSSION, scanKey string) (*models.PULL_REQUEST, error) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	url := fmt.Sprintf("%s/api/%s/%s/discussions/%d?diff=1", HFBaseURL(), resourceType, id, disc.Num)
+	log.Printf(
+		"op=FetchPullRequestDetails stage=start request_id=%s url=%s",
+		requestID, url,
+	)
+
+	resp, err := hubGet(scanKey, url)
+	if err != nil {
+		log.Printf(
+			"op=FetchPullRequestDetails stage=http_get_error request_id=%s url=%s error=%v elapsed=%s",
+			requestID, url, err, time.Since(start),
+		)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf(
+			"op=FetchPullRequestDetails stage=not_ok request_id=%s url=%s status=%d elapsed=%s",
+			requestID, url, resp.StatusCode, time.Since(start),
+		)
+		return nil, fmt.Errorf("failed to fetch pull request %d: %d", disc.Num, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf(
+			"op=FetchPullRequestDetails stage=read_error request_id=%s url=%s error=%v elapsed=%s",
+			requestID, url, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	var details struct {
+		Diff           string `json:"diff"`
+		MergeCommitOID string `json:"mergeCommitOid"`
+		Changes        struct {
+			Base string `json:"base"`
+		} `json:"changes"`
+		Events []struct {
+			Type string `json:"type"`
+			Data struct {
+				OID     string `json:"oid"`
+				Subject string `json:"subject"`
+			} `json:"data"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(body, &details); err != nil {
+		log.Printf(
+			"op=FetchPullRequestDetails stage=json_unmarshal_error request_id=%s url=%s error=%v elapsed=%s",
+			requestID, url, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	pullRequest := &models.PULL_REQUEST{
+		Num:            disc.Num,
+		Title:          disc.Title,
+		Status:         disc.Status,
+		AuthorName:     disc.AuthorName,
+		TargetBranch:   details.Changes.Base,
+		MergeCommitOID: details.MergeCommitOID,
+		Diff:           details.Diff,
+		Commits:        []models.PR_COMMIT{},
+	}
+
+	var failures []models.FAILED_ITEM
+	for _, ev := range details.Events {
+		if ev.Type != "commit" || ev.Data.OID == "" {
+			continue
+		}
+		commit := models.PR_COMMIT{
+			OID:     ev.Data.OID,
+			Subject: ev.Data.Subject,
+		}
+		// commit ki diff na mile to bhi chalega, tab PR ki poori diff scan hogi
+		commitDiff, err := FetchCommitDiff(resourceType, id, ev.Data.OID, scanKey)
+		if err != nil {
+			failures = append(failures, models.FAILED_ITEM{
+				Name:   fmt.Sprintf("PR #%d commit %s", disc.Num, ev.Data.OID),
+				Status: StatusFromError(err),
+				Error:  err.Error(),
+			})
+		}
+		commit.Diff = commitDiff
+		pullRequest.Commits = append(pullRequest.Commits, commit)
+	}
+
+	log.Printf(
+		"op=FetchPullRequestDetails stage=success request_id=%s url=%s commits=%d failed_commits=%d diff_bytes=%d total_elapsed=%s",
+		requestID, url, len(pullRequest.Commits), len(failures), len(pullRequest.Diff), time.Since(start),
+	)
+
+	if len(failures) > 0 {
+		return pullRequest, &PartialFetchError{Failures: failures}
+	}
+	return pullRequest, nil
+}
+
+// https://huggingface.co/{repo}/commit/{oid}.diff se ek commit ki raw diff
+func FetchCommitDiff(resourceType, id, oid string, scanKey string) (string, error) {
+	url := fmt.Sprintf("%s/%s/commit/%s.diff", HFBaseURL(), HuggingFaceRepoPath(resourceType, id), oid)
+
+	resp, err := hubGet(scanKey, url)
+	if err != nil {
+		log.Printf("op=FetchCommitDiff stage=http_get_error url=%s error=%v", url, err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("op=FetchCommitDiff stage=not_ok url=%s status=%d", url, resp.StatusCode)
+		return "", fmt.Errorf("failed to fetch commit diff %s: %d", oid, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("op=FetchCommitDiff stage=read_error url=%s error=%v", url, err)
+		return "", err
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,34 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchPullRequestDiffsReportsFailedCommitDiffs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/models/org/repo/discussions":
+			fmt.Fprint(w, `{"discussions":[{"num":4,"title":"add env","status":"open","isPullRequest":true}],"count":1}`)
+		case "/api/models/org/repo/discussions/4":
+			fmt.Fprint(w, `{"diff":"diff --git a/x b/x\n","events":[{"type":"commit","data":{"oid":"aaa"}},{"type":"commit","data":{"oid":"bbb"}}]}`)
+		case "/org/repo/commit/aaa.diff":
+			fmt.Fprint(w, "diff --git a/x b/x\n")
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("HF_BASE_URL", server.URL)
+
+	prs, err := FetchPullRequestDiffs("org/repo", "models", DiscussionFilter{}, "")
+	if len(prs) != 1 || len(prs[0].Commits) != 2 || prs[0].Commits[1].Diff != "" {
+		t.Fatalf("pull requests = %+v, want PR 4 with commit bbb missing its diff", prs)
+	}
+	failures := FetchFailures(err, "pull requests")
+	if len(failures) != 1 || failures[0].Name != "PR #4 commit bbb" {
+		t.Fatalf("failures = %+v, want PR #4 commit bbb", failures)
+	}
+}
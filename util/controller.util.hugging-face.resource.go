@@ -0,0 +1,43 @@
+package util
+
+import (
+	"log"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// fetches one model, dataset ya space ka info + readable files (+ discussions agar chahiye)
+// and returns an AI_REQUEST that is ready to be saved and scanned, plus the rescan plan for it
+func FetchResourceRequest(resourceType ResourceType, resourceID string, includePRs, includeDiscussion bool) (*models.AI_REQUEST, *RescanPlan, error) {
+	start := time.Now()
+	requestID := uuid.New().String()
+
+	aiRequest := &models.AI_REQUEST{
+		RequestID:    requestID,
+		ResourceType: string(resourceType),
+		ResourceID:   resourceID,
+		Siblings:     []models.SIBLING{},
+		Discussions:  []models.DISCUSSION{},
+	}
+
+	plan, err := HuggingFaceSource{}.Fetch(aiRequest, string(resourceType), resourceID, FetchOptions{
+		IncludePRs:         includePRs,
+		IncludeDiscussions: includeDiscussion,
+	})
+	if err != nil {
+		log.Printf(
+			"op=FetchResourceRequest stage=fetch_error request_id=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
+			requestID, resourceType, resourceID, err, time.Since(start),
+		)
+		return nil, nil, err
+	}
+
+	log.Printf(
+		"op=FetchResourceRequest stage=success request_id=%s resource_type=%s resource_id=%s files=%d discussions=%d scan_mode=%s total_elapsed=%s",
+		requestID, resourceType, resourceID, len(aiRequest.Siblings), len(aiRequest.Discussions), plan.Mode, time.Since(start),
+	)
+
+	return aiRequest, plan, nil
+}
@@ -0,0 +1,221 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// HF search ka ek result, sha list me hi aa jaata hai to dedupe ke liye info call nahi karni padti
+type SearchHit struct {
+	ResourceID   string
+	CommitSHA    string
+	LastModified string
+}
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 1000
+)
+
+// recent / trending jaise user friendly naam -> HF api ka sort field
+var searchSortFields = map[string]string{
+	"":          "lastModified",
+	"recent":    "lastModified",
+	"trending":  "trendingScore",
+	"downloads": "downloads",
+	"likes":     "likes",
+}
+
+func ValidateDiscoveryQuery(q models.DISCOVERY_QUERY) error {
+	switch ResourceType(q.ResourceType) {
+	case ResourceTypeModel, ResourceTypeDataset, ResourceTypeSpace:
+	default:
+		return fmt.Errorf("invalid resource_type %q: use models, datasets or spaces", q.ResourceType)
+	}
+	if _, ok := searchSortFields[strings.ToLower(q.Sort)]; !ok {
+		return fmt.Errorf("invalid sort %q: use recent, trending, downloads or likes", q.Sort)
+	}
+	if q.Search == "" && q.Author == "" && len(q.Tags) == 0 {
+		return fmt.Errorf("at least one of search, author or tags is required")
+	}
+	if isAuthorPattern(q.Author) {
+		if _, err := path.Match(q.Author, ""); err != nil {
+			return fmt.Errorf("invalid author pattern %q: %v", q.Author, err)
+		}
+	}
+	return nil
+}
+
+func isAuthorPattern(author string) bool {
+	return strings.ContainsAny(author, "*?[")
+}
+
+// HFBaseURL()/api/{type}?search=..&author=..&filter=tag&sort=..; author pattern ho to HF ko nahi bhejte
+// (wo sirf exact author samajhta hai), result aane ke baad yahi filter karte hai
+func BuildHubSearchURL(q models.DISCOVERY_QUERY) string {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	params := url.Values{}
+	if q.Search != "" {
+		params.Set("search", q.Search)
+	}
+	if q.Author != "" {
+		if isAuthorPattern(q.Author) {
+			// pattern me kaafi results filter ho jaate hai, isliye zyada mangwa lo
+			limit *= 5
+		} else {
+			params.Set("author", q.Author)
+		}
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	for _, tag := range q.Tags {
+		params.Add("filter", tag)
+	}
+	params.Set("sort", searchSortFields[strings.ToLower(q.Sort)])
+	params.Set("direction", "-1")
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	params.Set("full", "true")
+	return fmt.Sprintf("%s/api/%s?%s", HFBaseURL(), q.ResourceType, params.Encode())
+}
+
+func SearchHub(q models.DISCOVERY_QUERY) ([]SearchHit, error) {
+	start := time.Now()
+	searchURL := BuildHubSearchURL(q)
+	log.Printf(
+		"op=SearchHub stage=start resource_type=%s search=%q author=%q tags=%v sort=%s url=%s",
+		q.ResourceType, q.Search, q.Author, q.Tags, q.Sort, searchURL,
+	)
+
+	rows, _, err := fetchSearchPage(searchURL)
+	if err != nil {
+		log.Printf("op=SearchHub stage=error url=%s error=%v elapsed=%s", searchURL, err, time.Since(start))
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	hits := []SearchHit{}
+	for _, row := range rows {
+		hit, ok := searchHitFromRow(row, q.Author)
+		if !ok {
+			continue
+		}
+		hits = append(hits, hit)
+		if len(hits) >= limit {
+			break
+		}
+	}
+
+	log.Printf(
+		"op=SearchHub stage=success url=%s listed=%d matched=%d elapsed=%s",
+		searchURL, len(rows), len(hits), time.Since(start),
+	)
+	return hits, nil
+}
+
+// author / org ke saare repos; HF ek page me maxSearchLimit tak hi deta hai, aage ke pages Link header (rel="next") me.
+// SearchHub ki tarah pehle page pe ruke to bade orgs chupchaap aadhe scan hote
+func ListHubRepos(resourceType, author string) ([]SearchHit, error) {
+	start := time.Now()
+	firstURL := BuildHubSearchURL(models.DISCOVERY_QUERY{ResourceType: resourceType, Author: author, Limit: maxSearchLimit})
+
+	hits := []SearchHit{}
+	pages := 0
+	for pageURL := firstURL; pageURL != ""; pages++ {
+		rows, next, err := fetchSearchPage(pageURL)
+		if err != nil {
+			log.Printf("op=ListHubRepos stage=error url=%s page=%d error=%v elapsed=%s", pageURL, pages, err, time.Since(start))
+			return nil, err
+		}
+		for _, row := range rows {
+			if hit, ok := searchHitFromRow(row, author); ok {
+				hits = append(hits, hit)
+			}
+		}
+		if len(rows) == 0 {
+			break
+		}
+		if next != "" && !sameHost(next, firstURL) {
+			return nil, fmt.Errorf("refusing to follow pagination link to another host: %s", next)
+		}
+		pageURL = next
+	}
+
+	log.Printf(
+		"op=ListHubRepos stage=success resource_type=%s author=%q pages=%d repos=%d elapsed=%s",
+		resourceType, author, pages, len(hits), time.Since(start),
+	)
+	return hits, nil
+}
+
+// search api ka ek page aur agle page ka url (Link header se, na ho to khaali)
+func fetchSearchPage(pageURL string) ([]map[string]interface{}, string, error) {
+	resp, err := httpClient.Get(pageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", &HTTPStatusError{URL: pageURL, Status: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read search response: %w", err)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, "", fmt.Errorf("failed to parse search response: %w", err)
+	}
+	return rows, nextPageLink(resp.Header), nil
+}
+
+func sameHost(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	return errA == nil && errB == nil && ua.Scheme == ub.Scheme && ua.Host == ub.Host
+}
+
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func nextPageLink(header http.Header) string {
+	match := linkNextPattern.FindStringSubmatch(header.Get("Link"))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// author pattern ho to owner us glob se match hona chahiye
+func searchHitFromRow(row map[string]interface{}, author string) (SearchHit, bool) {
+	id, _ := row["id"].(string)
+	if id == "" {
+		return SearchHit{}, false
+	}
+	if isAuthorPattern(author) {
+		if ok, _ := path.Match(author, ExtractOrgFromResourceID(id)); !ok {
+			return SearchHit{}, false
+		}
+	}
+	sha, _ := row["sha"].(string)
+	lastModified, _ := row["lastModified"].(string)
+	return SearchHit{ResourceID: id, CommitSHA: sha, LastModified: lastModified}, true
+}
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+func TestBuildHubSearchURL(t *testing.T) {
+	t.Setenv("HF_BASE_URL", "https://hub.test")
+	tests := []struct {
+		name  string
+		query models.DISCOVERY_QUERY
+		want  url.Values
+	}{
+		{
+			name:  "exact author goes to the api",
+			query: models.DISCOVERY_QUERY{ResourceType: "models", Author: "acme", Sort: "trending"},
+			want:  url.Values{"author": {"acme"}, "sort": {"trendingScore"}, "direction": {"-1"}, "limit": {"50"}, "full": {"true"}},
+		},
+		{
+			// pattern HF nahi samajhta: author param nahi jaata aur zyada results mangte hai
+			name:  "author pattern is filtered locally",
+			query: models.DISCOVERY_QUERY{ResourceType: "datasets", Search: "key", Author: "acme-*", Tags: []string{"a", "b"}, Limit: 300},
+			want:  url.Values{"search": {"key"}, "filter": {"a", "b"}, "sort": {"lastModified"}, "direction": {"-1"}, "limit": {"1000"}, "full": {"true"}},
+		},
+	}
+	for _, tt := range tests {
+		parsed, err := url.Parse(BuildHubSearchURL(tt.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Path != "/api/"+tt.query.ResourceType || parsed.Query().Encode() != tt.want.Encode() {
+			t.Errorf("%s: url = %s, want /api/%s?%s", tt.name, parsed, tt.query.ResourceType, tt.want.Encode())
+		}
+	}
+}
+
+func TestSearchHubAuthorPattern(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":"acme-labs/a","sha":"1"},{"id":"acme/b"},{"id":"other/c"},{"id":"acme-corp/d","lastModified":"2024-01-01"},{"id":"acme-x/e"}]`)
+	}))
+	defer server.Close()
+	t.Setenv("HF_BASE_URL", server.URL)
+
+	hits, err := SearchHub(models.DISCOVERY_QUERY{ResourceType: "models", Author: "acme-*", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 || hits[0] != (SearchHit{ResourceID: "acme-labs/a", CommitSHA: "1"}) || hits[1].ResourceID != "acme-corp/d" {
+		t.Errorf("hits = %+v, want acme-labs/a and acme-corp/d (limit 2)", hits)
+	}
+}
+
+func TestListHubReposFollowsLinkHeader(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			if r.URL.Query().Get("author") != "acme" {
+				http.Error(w, "author missing", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/models?author=acme&cursor=p2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id":"acme/one"},{"id":"acme/two"}]`)
+		case "p2":
+			fmt.Fprint(w, `[{"id":"acme/three"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("HF_BASE_URL", server.URL)
+
+	hits, err := ListHubRepos("models", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, hit := range hits {
+		ids = append(ids, hit.ResourceID)
+	}
+	if strings.Join(ids, ",") != "acme/one,acme/two,acme/three" {
+		t.Errorf("items = %v, want all three pages' repos", ids)
+	}
+}
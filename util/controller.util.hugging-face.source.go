@@ -0,0 +1,148 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// Hugging Face Hub as a Source: models / datasets / spaces, discussions, PR diffs aur commit history ke saath
+type HuggingFaceSource struct{}
+
+func init() {
+	RegisterSource(HuggingFaceSource{})
+}
+
+func (HuggingFaceSource) Name() string { return ProviderHuggingFace }
+
+func (HuggingFaceSource) ResourceTypes() []string {
+	return []string{string(ResourceTypeModel), string(ResourceTypeDataset), string(ResourceTypeSpace)}
+}
+
+func (HuggingFaceSource) ListItems(resourceType, owner string) ([]SourceItem, error) {
+	hits, err := ListHubRepos(resourceType, owner)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]SourceItem, 0, len(hits))
+	for _, hit := range hits {
+		items = append(items, SourceItem{
+			Provider:     ProviderHuggingFace,
+			ResourceType: resourceType,
+			ResourceID:   hit.ResourceID,
+			CommitSHA:    hit.CommitSHA,
+			LastModified: hit.LastModified,
+		})
+	}
+	return items, nil
+}
+
+// info + files + (options ke hisaab se) discussions / PR diffs / history aiRequest me bharta hai,
+// files pichle scan ke against incremental aati hai aur plan batata hai ki kya scan karna hai
+func (HuggingFaceSource) Fetch(aiRequest *models.AI_REQUEST, resourceType, resourceID string, opts FetchOptions) (*RescanPlan, error) {
+	start := time.Now()
+	traceID := uuid.New().String()
+
+	url := fmt.Sprintf("%s/api/%s/%s?blobs=true", HFBaseURL(), resourceType, resourceID)
+	log.Printf(
+		"op=HuggingFaceSource.Fetch stage=start trace_id=%s resource_type=%s resource_id=%s url=%s include_prs=%t include_discussions=%t scan_pr_diffs=%t",
+		traceID, resourceType, resourceID, url, opts.IncludePRs, opts.IncludeDiscussions, opts.ScanPRDiffs,
+	)
+
+	resp, err := hubGet(opts.ScanKey, url)
+	if err != nil {
+		log.Printf(
+			"op=HuggingFaceSource.Fetch stage=http_get_error trace_id=%s resource_type=%s resource_id=%s url=%s error=%v elapsed=%s",
+			traceID, resourceType, resourceID, url, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		log.Printf(
+			"op=HuggingFaceSource.Fetch stage=not_ok trace_id=%s resource_type=%s resource_id=%s status=%d elapsed=%s",
+			traceID, resourceType, resourceID, resp.StatusCode, time.Since(start),
+		)
+		return nil, &HTTPStatusError{URL: url, Status: resp.StatusCode}
+	}
+
+	// files / discussions isi scheduler se aate hai, info ka slot unse pehle free hona chahiye
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		log.Printf(
+			"op=HuggingFaceSource.Fetch stage=read_error trace_id=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
+			traceID, resourceType, resourceID, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	var resourceData map[string]interface{}
+	if err := json.Unmarshal(body, &resourceData); err != nil {
+		log.Printf(
+			"op=HuggingFaceSource.Fetch stage=json_unmarshal_error trace_id=%s resource_type=%s resource_id=%s error=%v elapsed=%s",
+			traceID, resourceType, resourceID, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	aiRequest.Provider = ProviderHuggingFace
+	aiRequest.ResourceType = resourceType
+	aiRequest.ResourceID = resourceID
+
+	plan := FetchSiblingsIncremental(aiRequest, resourceType, resourceID, resourceData, opts)
+	log.Printf(
+		"op=HuggingFaceSource.Fetch stage=fetch_files_done trace_id=%s resource_type=%s resource_id=%s files=%d scan_mode=%s changed=%d",
+		traceID, resourceType, resourceID, len(aiRequest.Siblings), plan.Mode, len(plan.ChangedFiles),
+	)
+
+	if opts.IncludePRs || opts.IncludeDiscussions {
+		discussions, err := FetchDiscussions(resourceID, resourceType, opts.IncludePRs, opts.IncludeDiscussions, opts.DiscussionFilter, opts.ScanKey)
+		aiRequest.Discussions = discussions
+		aiRequest.DiscussionsFailed = DiscussionFailures(err)
+		log.Printf(
+			"op=HuggingFaceSource.Fetch stage=fetch_discussions_done trace_id=%s resource_type=%s resource_id=%s discussions=%d",
+			traceID, resourceType, resourceID, len(discussions),
+		)
+	}
+
+	if opts.ScanPRDiffs {
+		pullRequests, err := FetchPullRequestDiffs(resourceID, resourceType, opts.DiscussionFilter, opts.ScanKey)
+		aiRequest.PullRequests = pullRequests
+		aiRequest.PullRequestsFailed = FetchFailures(err, "pull requests")
+		log.Printf(
+			"op=HuggingFaceSource.Fetch stage=fetch_pr_diffs_done trace_id=%s resource_type=%s resource_id=%s pull_requests=%d failed=%d",
+			traceID, resourceType, resourceID, len(pullRequests), len(aiRequest.PullRequestsFailed),
+		)
+	}
+
+	if opts.ScanHistory && !plan.SkipHistory {
+		history, err := FetchCommitHistory(resourceID, resourceType, HistoryMaxCommits(), opts.ScanKey)
+		aiRequest.History = history
+		aiRequest.HistoryFailed = FetchFailures(err, "commit history")
+		log.Printf(
+			"op=HuggingFaceSource.Fetch stage=fetch_history_done trace_id=%s resource_type=%s resource_id=%s commits=%d failed=%d",
+			traceID, resourceType, resourceID, len(history), len(aiRequest.HistoryFailed),
+		)
+	}
+
+	log.Printf(
+		"op=HuggingFaceSource.Fetch stage=success trace_id=%s resource_type=%s resource_id=%s elapsed=%s",
+		traceID, resourceType, resourceID, time.Since(start),
+	)
+	return plan, nil
+}
+
+func (HuggingFaceSource) FileURL(resourceType, resourceID, revision, fileName string, line int) string {
+	if revision == "" {
+		return BuildHuggingFaceFileURL(resourceType, resourceID, fileName, line)
+	}
+	return BuildHuggingFaceRevisionFileURL(resourceType, resourceID, revision, fileName, line)
+}
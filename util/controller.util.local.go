@@ -0,0 +1,414 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// apni checkout / kisi bhi local directory ko push se pehle scan karna; resource id directory ka absolute path
+const (
+	ProviderLocal   = "local"
+	ResourceTypeDir = "dirs"
+)
+
+// git_scope: khaali = poori directory (.gitignore ke saath), staged = index me jo commit hone wala hai,
+// modified = staged + unstaged + untracked (pre-commit / pre-push ke liye)
+const (
+	GitScopeStaged   = "staged"
+	GitScopeModified = "modified"
+)
+
+// API se chale to sirf LOCAL_SCAN_ROOTS ke andar ke paths padhe ja sakte hai;
+// AllowAnyPath CLI jaise callers ke liye hai jo khud user ki di hui directory scan karte hai
+type LocalSource struct {
+	AllowAnyPath bool
+}
+
+func init() {
+	RegisterSource(LocalSource{})
+}
+
+func (LocalSource) Name() string { return ProviderLocal }
+
+func (LocalSource) ResourceTypes() []string { return []string{ResourceTypeDir} }
+
+func (s LocalSource) ListItems(resourceType, owner string) ([]SourceItem, error) {
+	root, err := s.resolveRoot(owner)
+	if err != nil {
+		return nil, err
+	}
+	return []SourceItem{{Provider: ProviderLocal, ResourceType: ResourceTypeDir, ResourceID: root}}, nil
+}
+
+// directory walk (ya git ki changed files) -> FetchFilesWith, file disk se ya staged scope me index se padhi jaati hai
+func (s LocalSource) Fetch(aiRequest *models.AI_REQUEST, resourceType, resourceID string, opts FetchOptions) (*RescanPlan, error) {
+	start := time.Now()
+	traceID := uuid.New().String()
+
+	log.Printf(
+		"op=LocalSource.Fetch stage=start trace_id=%s path=%s git_scope=%s",
+		traceID, resourceID, opts.GitScope,
+	)
+
+	root, err := s.resolveRoot(resourceID)
+	if err != nil {
+		log.Printf(
+			"op=LocalSource.Fetch stage=resolve_error trace_id=%s path=%s error=%v",
+			traceID, resourceID, err,
+		)
+		return nil, err
+	}
+
+	var candidates []models.SIBLING
+	truncated := false
+	if opts.GitScope == "" {
+		candidates, truncated, err = walkLocalDir(root)
+	} else {
+		candidates, truncated, err = gitChangedFiles(root, opts.GitScope)
+	}
+	if err != nil {
+		log.Printf(
+			"op=LocalSource.Fetch stage=list_error trace_id=%s path=%s git_scope=%s error=%v elapsed=%s",
+			traceID, root, opts.GitScope, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	aiRequest.Provider = ProviderLocal
+	aiRequest.ResourceType = ResourceTypeDir
+	aiRequest.ResourceID = root
+
+	aiRequest.Siblings, aiRequest.Skipped, aiRequest.Failed = FetchFilesWith(root, candidates, opts.ByteBudget, func(file models.SIBLING, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error) {
+		sibling := file
+		sibling.FetchMode = string(action)
+		// staged scope me wahi content scan hota hai jo commit hoga, working tree wala nahi
+		if opts.GitScope == GitScopeStaged {
+			out, err := runGit(root, "cat-file", "blob", file.BlobID)
+			if err != nil {
+				return sibling, "", err
+			}
+			return siblingFromBody(sibling, bytes.NewReader(out), policy, action)
+		}
+		f, err := os.Open(filepath.Join(root, filepath.FromSlash(file.RFilename)))
+		if err != nil {
+			return sibling, "", err
+		}
+		defer f.Close()
+		return siblingFromBody(sibling, f, policy, action)
+	})
+	if truncated {
+		aiRequest.Failed = append(aiRequest.Failed, models.FAILED_ITEM{Name: "walk", Error: fmt.Sprintf("file list stopped at LOCAL_MAX_FILES=%d", localMaxFiles())})
+	}
+
+	log.Printf(
+		"op=LocalSource.Fetch stage=success trace_id=%s path=%s git_scope=%s candidates=%d files=%d skipped=%d failed=%d elapsed=%s",
+		traceID, root, opts.GitScope, len(candidates), len(aiRequest.Siblings), len(aiRequest.Skipped), len(aiRequest.Failed), time.Since(start),
+	)
+	return NewRescanPlan("", "", opts), nil
+}
+
+func (LocalSource) FileURL(resourceType, resourceID, revision, fileName string, line int) string {
+	fileURL := url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(resourceID, filepath.FromSlash(fileName)))}
+	return fileURL.String() + lineAnchor(line)
+}
+
+func (s LocalSource) resolveRoot(dir string) (string, error) {
+	return resolveLocalPath(dir, true, s.AllowAnyPath)
+}
+
+// absolute + symlinks resolve karke path, phir allowed roots ka check (local dirs aur image tarballs dono ke liye)
+func resolveLocalPath(dir string, wantDir, allowAnyPath bool) (string, error) {
+	if dir == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", err
+	}
+	if wantDir && !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	if !wantDir && !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", dir)
+	}
+	if allowAnyPath {
+		return root, nil
+	}
+
+	allowed := localScanRoots()
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("local scanning is disabled: set LOCAL_SCAN_ROOTS to the directories that may be scanned")
+	}
+	for _, allowedRoot := range allowed {
+		if rel, err := filepath.Rel(allowedRoot, root); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return root, nil
+		}
+	}
+	return "", fmt.Errorf("%s is outside LOCAL_SCAN_ROOTS", dir)
+}
+
+// comma separated directories, unke symlinks bhi resolve karke
+func localScanRoots() []string {
+	roots := []string{}
+	for _, entry := range strings.Split(GetEnv("LOCAL_SCAN_ROOTS", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(entry); err == nil {
+			if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+				roots = append(roots, resolved)
+			}
+		}
+	}
+	return roots
+}
+
+func localMaxFiles() int {
+	return envInt("LOCAL_MAX_FILES", 50000)
+}
+
+// root ke neeche ki regular files; .git aur .gitignore / .git/info/exclude wale paths chhod ke.
+// symlinks follow nahi hote taaki scan root ke bahar na jaaye
+func walkLocalDir(root string) ([]models.SIBLING, bool, error) {
+	files := []models.SIBLING{}
+	ignore := &GitIgnore{}
+	ignore.LoadFile("", filepath.Join(root, ".git", "info", "exclude"))
+	truncated := false
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// padhne layak nahi (permission) to us hisse ko chhod do, poora walk fail nahi karna
+			log.Printf("op=walkLocalDir stage=walk_error path=%s error=%v", p, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel == "." {
+				ignore.LoadFile("", filepath.Join(p, ".gitignore"))
+				return nil
+			}
+			if d.Name() == ".git" || ignore.Ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			ignore.LoadFile(rel, filepath.Join(p, ".gitignore"))
+			return nil
+		}
+		if !d.Type().IsRegular() || ignore.Ignored(rel, false) {
+			return nil
+		}
+		if len(files) >= localMaxFiles() {
+			truncated = true
+			return filepath.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, models.SIBLING{RFilename: rel, Size: info.Size()})
+		return nil
+	})
+	return files, truncated, err
+}
+
+// git scope wali files, directory ke relative paths; index ka blob id aur size bhi (staged content isi blob se padha jaata hai,
+// aur same blob pehle scan hua ho to blob cache se); directory walk jaisa hi LOCAL_MAX_FILES ka cap
+func gitChangedFiles(root, scope string) ([]models.SIBLING, bool, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, false, fmt.Errorf("git_scope=%s needs git on PATH", scope)
+	}
+	if _, err := runGit(root, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, false, fmt.Errorf("git_scope=%s needs a git working tree: %w", scope, err)
+	}
+
+	// deleted files ka scan karne layak kuch nahi, isliye ACMR. index vs HEAD sirf blobs compare karta hai, koi filter nahi chalta
+	lists := [][]string{{"diff", "--cached", "--name-only", "--relative", "-z", "--diff-filter=ACMR", "--no-ext-diff", "--no-textconv"}}
+	if scope == GitScopeModified {
+		lists = append(lists, []string{"ls-files", "--others", "--exclude-standard", "-z"})
+	}
+	seen := map[string]bool{}
+	paths := []string{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			paths = append(paths, name)
+		}
+	}
+	for _, args := range lists {
+		out, err := runGit(root, args...)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, name := range strings.Split(string(out), "\x00") {
+			add(name)
+		}
+	}
+	if scope == GitScopeModified {
+		modified, err := workTreeChanges(root)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, name := range modified {
+			add(name)
+		}
+	}
+	truncated := false
+	if len(paths) > localMaxFiles() {
+		paths = paths[:localMaxFiles()]
+		truncated = true
+	}
+
+	if scope == GitScopeStaged {
+		files, err := stagedBlobs(root, paths)
+		return files, truncated, err
+	}
+	files := []models.SIBLING{}
+	for _, name := range paths {
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, models.SIBLING{RFilename: name, Size: info.Size()})
+	}
+	return files, truncated, nil
+}
+
+// working tree me badli tracked files. "git diff" stat dirty file ko repo ke clean filter (filter.<driver>.clean,
+// .gitattributes se) se dobara hash karta hai, jo scan ki ja rahi repo ka koi bhi command ho sakta hai; isliye index ke
+// blob ko file ke raw content ke hash (--no-filters) se khud compare karte hai. Filter wali files (lfs, eol) isme
+// modified dikh sakti hai, scan zyada hona chalega
+func workTreeChanges(root string) ([]string, error) {
+	out, err := runGit(root, "ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, err
+	}
+	indexed := map[string]string{}
+	conflicted := map[string]bool{}
+	names := []string{}
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// "<mode> <object> <stage>\t<path>"
+		meta, name, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || fields[0] == "160000" || fields[0] == "120000" {
+			continue
+		}
+		if fields[2] != "0" {
+			conflicted[name] = true
+		}
+		if _, dup := indexed[name]; dup {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name)))
+		// hash-object stdin pe line based paths leta hai
+		if err != nil || !info.Mode().IsRegular() || strings.ContainsAny(name, "\n\r") {
+			continue
+		}
+		indexed[name] = fields[1]
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	cmd := gitCommand(root, "hash-object", "--no-filters", "--stdin-paths")
+	cmd.Stdin = strings.NewReader(strings.Join(names, "\n") + "\n")
+	hashes, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git hash-object failed: %w", err)
+	}
+	changed := []string{}
+	for i, hash := range strings.Fields(string(hashes)) {
+		if i < len(names) && (hash != indexed[names[i]] || conflicted[names[i]]) {
+			changed = append(changed, names[i])
+		}
+	}
+	return changed, nil
+}
+
+// "git cat-file --batch-check" se har staged path ka blob sha aur size
+func stagedBlobs(root string, paths []string) ([]models.SIBLING, error) {
+	prefix, err := runGit(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	var input strings.Builder
+	names := []string{}
+	for _, name := range paths {
+		// batch input line based hai
+		if strings.ContainsAny(name, "\n\r") {
+			continue
+		}
+		names = append(names, name)
+		input.WriteString(":" + strings.TrimSpace(string(prefix)) + name + "\n")
+	}
+
+	cmd := gitCommand(root, "cat-file", "--batch-check=%(objectname) %(objecttype) %(objectsize)")
+	cmd.Stdin = strings.NewReader(input.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file failed: %w", err)
+	}
+
+	files := []models.SIBLING{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for i := 0; scanner.Scan() && i < len(names); i++ {
+		fields := strings.Fields(scanner.Text())
+		// submodule / missing entries blob nahi hote
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[2], 10, 64)
+		files = append(files, models.SIBLING{RFilename: names[i], BlobID: fields[0], Size: size})
+	}
+	return files, scanner.Err()
+}
+
+// scan ki ja rahi repo ka config bharosemand nahi: core.fsmonitor / hooks se us repo ka koi bhi command chal sakta hai,
+// isliye wo band, system / global config aur attributes file nahi padhte. Repo ke filter / textconv drivers ke naam pehle se
+// pata nahi hote, unse bachne ke liye hum koi aisa command chalate hi nahi jo working tree ka content filter kare
+func gitCommand(dir string, args ...string) *exec.Cmd {
+	base := []string{"-c", "core.fsmonitor=", "-c", "core.hooksPath=/dev/null", "-c", "core.attributesFile=/dev/null", "-C", dir}
+	cmd := exec.Command("git", append(base, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null")
+	return cmd
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := gitCommand(dir, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
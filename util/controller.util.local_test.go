@@ -0,0 +1,122 @@
+package util
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not on PATH")
+	}
+	root := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return root
+}
+
+func TestGitChangedFilesIgnoresRepoFsmonitor(t *testing.T) {
+	root := testGitRepo(t)
+	marker := filepath.Join(t.TempDir(), "pwned")
+	hook := filepath.Join(root, "fsmonitor.sh")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "-C", root, "config", "core.fsmonitor", hook).CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.env"), []byte("TOKEN=x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := gitChangedFiles(root, GitScopeModified); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("repo's core.fsmonitor command ran during the scan")
+	}
+}
+
+func TestGitChangedFilesRespectsMaxFiles(t *testing.T) {
+	t.Setenv("LOCAL_MAX_FILES", "2")
+	root := testGitRepo(t)
+	for _, name := range []string{"a.env", "b.env", "c.env"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("TOKEN=x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		scope string
+		stage bool
+	}{
+		{GitScopeModified, false},
+		{GitScopeStaged, true},
+	}
+	for _, tt := range tests {
+		if tt.stage {
+			if out, err := exec.Command("git", "-C", root, "add", ".").CombinedOutput(); err != nil {
+				t.Fatalf("git add: %v: %s", err, out)
+			}
+		}
+		files, truncated, err := gitChangedFiles(root, tt.scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 2 || !truncated {
+			t.Errorf("%s: files=%d truncated=%v, want 2 true", tt.scope, len(files), truncated)
+		}
+	}
+}
+
+// repo ka apna clean filter (.git/config + .gitattributes) modified files dhundhte waqt nahi chalna chahiye
+func TestGitChangedFilesIgnoresRepoCleanFilter(t *testing.T) {
+	root := testGitRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	for name, content := range map[string]string{"a.env": "TOKEN=x\n", "b.env": "TOKEN=y\n", ".gitattributes": "* filter=evil diff=evil\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", ".")
+	git("commit", "-qm", "init")
+
+	marker := filepath.Join(t.TempDir(), "pwned")
+	git("config", "filter.evil.clean", "touch "+marker+"; cat")
+	git("config", "diff.evil.textconv", "touch "+marker+"; cat")
+	// a.env badli, b.env ka sirf mtime (stat dirty, content same)
+	if err := os.WriteFile(filepath.Join(root, "a.env"), []byte("TOKEN=changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(root, "b.env"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _, err := gitChangedFiles(root, GitScopeModified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("repo's clean filter ran during the scan")
+	}
+	if len(files) != 1 || files[0].RFilename != "a.env" {
+		t.Errorf("modified files = %+v, want only a.env", files)
+	}
+}
@@ -36,8 +36,7 @@ func FetchOrgResources(
 	start := time.Now()
 	requestID := uuid.New().String()
 
-	url := fmt.Sprintf("https://huggingface.co/api/%s?author=%s&full=true", resourceType, org)
-	httpClient := SharedHTTPClient()
+	url := fmt.Sprintf("%s/api/%s?author=%s&full=true", HFBaseURL(), resourceType, org)
 
 	log.Printf(
 		"op=FetchOrgResources stage=start request_id=%s method=%s path=%s org=%s resource_type=%s ip=%s user_agent=%q include_prs=%t include_discussion=%t url=%s",
@@ -3,96 +3,278 @@ package util
 import (
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/MishraShardendu22/Scanner/models"
 
 	"github.com/kamva/mgm/v3"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// org scan ka summary, kaunse resources reuse hue, kaunse refetch karne pade
+type OrgScanReport struct {
+	Resources []string          `json:"resources"`
+	Reused    []string          `json:"reused"`
+	Stale     []string          `json:"stale"`
+	Missing   []string          `json:"missing"`
+	Failed    map[string]string `json:"failed"`
+	BlobCache BlobCacheStats    `json:"blob_cache"`
+	// har resource ka scan mode: full, incremental ya reused
+	ScanModes map[string]RescanMode `json:"scan_modes"`
+	Coverage  *models.SCAN_COVERAGE `json:"coverage"`
+	// saare resources isi rule set se scan hue, SCAN_RESULT pe yahi likha jaata hai
+	RuleSet *RuleSet `json:"-"`
+}
 
-func ScanOrgResources(
-	org string,
-	resourceType ResourceType,
-) ([]models.Finding, int, error) {
-	log.Printf("🏢 Starting organization %s scan: %s\n", resourceType, org)
+// stored content isse purana hai to dobara fetch karenge
+func OrgScanMaxAge() time.Duration {
+	maxAge, err := time.ParseDuration(GetEnv("ORG_SCAN_MAX_AGE", "24h"))
+	if err != nil || maxAge <= 0 {
+		return 24 * time.Hour
+	}
+	return maxAge
+}
+
+// registered resources aur unke stored requests kahan se aate hai; tests me memory wala store lagta hai
+type orgResourceStore interface {
+	Models(org string) ([]models.AI_Models, error)
+	Datasets(org string) ([]models.AI_DATASETS, error)
+	Spaces(org string) ([]models.AI_SPACES, error)
+	// resource ka latest stored AI_REQUEST, nahi mila to nil
+	LatestRequest(resourceType ResourceType, resourceID string) *models.AI_REQUEST
+	SaveRequest(aiRequest *models.AI_REQUEST) error
+}
+
+var orgResources orgResourceStore = mongoOrgResourceStore{}
+
+type mongoOrgResourceStore struct{}
+
+func (mongoOrgResourceStore) Models(org string) ([]models.AI_Models, error) {
+	var rows []models.AI_Models
+	err := mgm.Coll(&models.AI_Models{}).SimpleFind(&rows, bson.M{"org": org})
+	return rows, err
+}
+
+func (mongoOrgResourceStore) Datasets(org string) ([]models.AI_DATASETS, error) {
+	var rows []models.AI_DATASETS
+	err := mgm.Coll(&models.AI_DATASETS{}).SimpleFind(&rows, bson.M{"org": org})
+	return rows, err
+}
+
+func (mongoOrgResourceStore) Spaces(org string) ([]models.AI_SPACES, error) {
+	var rows []models.AI_SPACES
+	err := mgm.Coll(&models.AI_SPACES{}).SimpleFind(&rows, bson.M{"org": org})
+	return rows, err
+}
+
+func (mongoOrgResourceStore) LatestRequest(resourceType ResourceType, resourceID string) *models.AI_REQUEST {
+	aiRequest := &models.AI_REQUEST{}
+	opts := options.FindOne().SetSort(bson.M{"created_at": -1})
+	err := mgm.Coll(aiRequest).First(bson.M{
+		"resource_type": string(resourceType),
+		"resource_id":   resourceID,
+	}, aiRequest, opts)
+	if err != nil {
+		return nil
+	}
+	return aiRequest
+}
+
+func (mongoOrgResourceStore) SaveRequest(aiRequest *models.AI_REQUEST) error {
+	return mgm.Coll(aiRequest).Create(aiRequest)
+}
 
-	var count int64
-	var err error
+// org ke registered resources (AI_Models / AI_DATASETS / AI_SPACES) nikalta hai,
+// same id multiple baar register ho sakti hai isliye dedupe karte hai
+func orgRegisteredResources(org string, resourceType ResourceType) (map[string]models.BaseAI, error) {
+	registered := make(map[string]models.BaseAI)
 
 	switch resourceType {
 	case ResourceTypeModel:
-		count, err = mgm.Coll(&models.AI_Models{}).CountDocuments(mgm.Ctx(), bson.M{"org": org})
+		rows, err := orgResources.Models(org)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			registered[row.Model_ID] = row.BaseAI
+		}
 	case ResourceTypeDataset:
-		count, err = mgm.Coll(&models.AI_DATASETS{}).CountDocuments(mgm.Ctx(), bson.M{"org": org})
+		rows, err := orgResources.Datasets(org)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			registered[row.Dataset_ID] = row.BaseAI
+		}
 	case ResourceTypeSpace:
-		count, err = mgm.Coll(&models.AI_SPACES{}).CountDocuments(mgm.Ctx(), bson.M{"org": org})
+		rows, err := orgResources.Spaces(org)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			registered[row.Space_ID] = row.BaseAI
+		}
 	}
 
+	delete(registered, "")
+	return registered, nil
+}
+
+// sirf org ke registered resources scan karta hai,
+// stored content missing ya stale ho to pehle fetch karke save karta hai
+func ScanOrgResources(
+	org string,
+	resourceType ResourceType,
+) ([]models.Finding, *OrgScanReport, error) {
+	log.Printf("🏢 Starting organization %s scan: %s\n", resourceType, org)
+
+	registered, err := orgRegisteredResources(org, resourceType)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch organization %s", resourceType)
+		return nil, nil, fmt.Errorf("failed to fetch organization %s", resourceType)
 	}
-	if count == 0 {
-		return nil, 0, fmt.Errorf("no %s found for this organization", resourceType)
+	if len(registered) == 0 {
+		return nil, nil, fmt.Errorf("no %s found for this organization", resourceType)
 	}
 
-	log.Printf("✅ Found %d %s for organization\n", count, resourceType)
-
-	var allFindings []models.Finding
-	var scannedCount int
+	log.Printf("✅ Found %d %s for organization\n", len(registered), resourceType)
 
-	aiRequests := []models.AI_REQUEST{}
-	mgm.Coll(&models.AI_REQUEST{}).SimpleFind(&aiRequests, bson.M{})
+	report := &OrgScanReport{
+		Resources: []string{},
+		Reused:    []string{},
+		Stale:     []string{},
+		Missing:   []string{},
+		Failed:    map[string]string{},
+		ScanModes: map[string]RescanMode{},
+		Coverage:  NewScanCoverage(),
+		RuleSet:   ActiveRuleSet(),
+	}
 
-	log.Printf("🔍 Scanning %d requests...\n", len(aiRequests))
+	// goroutines jis order me khatam ho us order me nahi, resource id ke order me jodte hai taaki report har baar same aaye
+	scanned := map[string][]models.Finding{}
+	coverages := map[string]*models.SCAN_COVERAGE{}
+	unresolved := map[string]error{}
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	semaphore := make(chan struct{}, 10)
+	maxAge := OrgScanMaxAge()
+	total := len(registered)
+	index := 0
 
-	for idx, req := range aiRequests {
+	for resourceID, base := range registered {
+		index++
 		wg.Add(1)
-		go func(r models.AI_REQUEST, index int) {
+		go func(id string, base models.BaseAI, index int) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			log.Printf("  [%d/%d] Scanning request: %s\n", index+1, len(aiRequests), r.RequestID)
+			log.Printf("  [%d/%d] Resolving %s: %s\n", index, total, resourceType, id)
+
+			aiRequest := orgResources.LatestRequest(resourceType, id)
+			state := "reused"
+			if aiRequest == nil {
+				state = "missing"
+			} else if time.Since(aiRequest.CreatedAt) > maxAge {
+				state = "stale"
+			}
 
-			// Use resourceType and resourceID from the request, or use defaults
-			resType := r.ResourceType
-			resID := r.ResourceID
-			if resType == "" {
-				resType = string(resourceType)
+			var plan *RescanPlan
+			if state != "reused" {
+				fetched, fetchedPlan, err := FetchResourceRequest(resourceType, id, base.IncludePRS, base.IncludeDiscussion)
+				if err == nil {
+					err = orgResources.SaveRequest(fetched)
+				}
+				if err != nil {
+					log.Printf("    ❌ Failed to fetch %s: %v\n", id, err)
+					mu.Lock()
+					report.Failed[id] = err.Error()
+					mu.Unlock()
+					// stale content is still better than nothing
+					if aiRequest == nil {
+						mu.Lock()
+						unresolved[id] = err
+						mu.Unlock()
+						return
+					}
+				} else {
+					aiRequest = fetched
+					plan = fetchedPlan
+				}
 			}
-			if resID == "" {
-				resID = "unknown"
+			// stored content hi scan ho raha hai, pichla scan isi pe hua tha to findings wahi
+			if plan == nil {
+				plan = PlanForStoredRequest(aiRequest, false)
 			}
 
-			findings := ScanAIRequest(r, SecretConfig, resType, resID)
+			findings := ScanWithPlan(*aiRequest, plan, report.RuleSet.Patterns, string(resourceType), id)
+
 			mu.Lock()
-			allFindings = append(allFindings, findings...)
-			scannedCount++
+			scanned[id] = findings
+			report.Resources = append(report.Resources, id)
+			report.BlobCache.Add(CountBlobCache(aiRequest.Siblings))
+			report.ScanModes[id] = plan.Mode
+			coverages[id] = CoverageFromRequest(*aiRequest)
+			switch state {
+			case "reused":
+				report.Reused = append(report.Reused, id)
+			case "stale":
+				report.Stale = append(report.Stale, id)
+			case "missing":
+				report.Missing = append(report.Missing, id)
+			}
 			mu.Unlock()
 			if len(findings) > 0 {
-				log.Printf("    ⚠️  Found %d secrets\n", len(findings))
+				log.Printf("    ⚠️  %s: Found %d secrets\n", id, len(findings))
 			}
-		}(req, idx)
+		}(resourceID, base, index)
 	}
 	wg.Wait()
 
-	log.Printf("✅ Scan complete! Total findings: %d\n", len(allFindings))
-	return allFindings, scannedCount, nil
+	sort.Strings(report.Resources)
+	sort.Strings(report.Reused)
+	sort.Strings(report.Stale)
+	sort.Strings(report.Missing)
+
+	var allFindings []models.Finding
+	for _, id := range report.Resources {
+		allFindings = append(allFindings, scanned[id]...)
+		MergeCoverage(report.Coverage, coverages[id], id)
+	}
+	failedIDs := make([]string, 0, len(unresolved))
+	for id := range unresolved {
+		failedIDs = append(failedIDs, id)
+	}
+	sort.Strings(failedIDs)
+	for _, id := range failedIDs {
+		AddFailedResource(report.Coverage, id, unresolved[id])
+	}
+
+	log.Printf("✅ Scan complete! Resources: %d, total findings: %d\n", len(report.Resources), len(allFindings))
+	return allFindings, report, nil
 }
 
 // SaveScanResults saves scan results to database
-func SaveScanResults(requestID string, scannedResources []models.SCANNED_RESOURCE) (*models.SCAN_RESULT, error) {
+func SaveScanResults(requestID string, scannedResources []models.SCANNED_RESOURCE, coverage *models.SCAN_COVERAGE, ruleSet *RuleSet) (*models.SCAN_RESULT, error) {
 	scanResult := &models.SCAN_RESULT{
 		RequestID:        requestID,
 		ScannedResources: scannedResources,
+		Coverage:         coverage,
+		Status:           coverageStatus(coverage),
+		RuleSetVersion:   ruleSet.Version,
+		RuleSetRevision:  ruleSet.Revision,
 	}
 	if err := mgm.Coll(scanResult).Create(scanResult); err != nil {
 		return nil, fmt.Errorf("failed to save scan results")
 	}
 	return scanResult, nil
 }
+
+func coverageStatus(coverage *models.SCAN_COVERAGE) string {
+	if coverage == nil {
+		return ""
+	}
+	return coverage.Status
+}
@@ -0,0 +1,192 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+type memoryOrgResourceStore struct {
+	mu       sync.Mutex
+	models   []models.AI_Models
+	datasets []models.AI_DATASETS
+	requests map[string]models.AI_REQUEST
+	saved    []string
+}
+
+func (s *memoryOrgResourceStore) Models(org string) ([]models.AI_Models, error) {
+	rows := []models.AI_Models{}
+	for _, row := range s.models {
+		if row.Org == org {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (s *memoryOrgResourceStore) Datasets(org string) ([]models.AI_DATASETS, error) {
+	rows := []models.AI_DATASETS{}
+	for _, row := range s.datasets {
+		if row.Org == org {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (s *memoryOrgResourceStore) Spaces(org string) ([]models.AI_SPACES, error) {
+	return nil, nil
+}
+
+func (s *memoryOrgResourceStore) LatestRequest(resourceType ResourceType, resourceID string) *models.AI_REQUEST {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	request, ok := s.requests[string(resourceType)+"/"+resourceID]
+	if !ok {
+		return nil
+	}
+	return &request
+}
+
+func (s *memoryOrgResourceStore) SaveRequest(aiRequest *models.AI_REQUEST) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, aiRequest.ResourceID)
+	return nil
+}
+
+func orgToken(seed string) string {
+	return "ghp_" + strings.Repeat(seed, 36)
+}
+
+func storedOrgRequest(resourceType ResourceType, id, token string, age time.Duration) models.AI_REQUEST {
+	request := models.AI_REQUEST{ResourceType: string(resourceType), ResourceID: id, Siblings: []models.SIBLING{
+		{RFilename: ".env", FileContent: "GITHUB_TOKEN=" + token + "\n"},
+	}}
+	request.CreatedAt = time.Now().Add(-age)
+	return request
+}
+
+// acme aur beta dono ke resources ek hi store me; acme/old stale hai, acme/new aur acme/gone kabhi fetch hi nahi hue
+func TestScanOrgResources(t *testing.T) {
+	t.Setenv("INCREMENTAL_RESCAN", "false")
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/models/acme/old", "/api/models/acme/new":
+			fmt.Fprint(w, `{"sha":"s1","siblings":[{"rfilename":".env","blobId":"b1","size":60}]}`)
+		case "/acme/old/resolve/main/.env":
+			fmt.Fprintf(w, "GITHUB_TOKEN=%s\n", orgToken("o"))
+		case "/acme/new/resolve/main/.env":
+			fmt.Fprintf(w, "GITHUB_TOKEN=%s\n", orgToken("n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer hub.Close()
+	t.Setenv("HF_BASE_URL", hub.URL)
+
+	store := &memoryOrgResourceStore{
+		models: []models.AI_Models{
+			{BaseAI: models.BaseAI{Org: "acme"}, Model_ID: "acme/stored"},
+			// ek hi model do baar register
+			{BaseAI: models.BaseAI{Org: "acme"}, Model_ID: "acme/stored"},
+			{BaseAI: models.BaseAI{Org: "acme"}, Model_ID: "acme/old"},
+			{BaseAI: models.BaseAI{Org: "acme"}, Model_ID: "acme/new"},
+			{BaseAI: models.BaseAI{Org: "acme"}, Model_ID: "acme/gone"},
+			{BaseAI: models.BaseAI{Org: "acme"}, Model_ID: ""},
+			{BaseAI: models.BaseAI{Org: "beta"}, Model_ID: "beta/model"},
+		},
+		datasets: []models.AI_DATASETS{
+			{BaseAI: models.BaseAI{Org: "acme"}, Dataset_ID: "acme/data"},
+		},
+		requests: map[string]models.AI_REQUEST{
+			"models/acme/stored": storedOrgRequest(ResourceTypeModel, "acme/stored", orgToken("s"), time.Hour),
+			"models/acme/old":    storedOrgRequest(ResourceTypeModel, "acme/old", orgToken("x"), 48*time.Hour),
+			"models/beta/model":  storedOrgRequest(ResourceTypeModel, "beta/model", orgToken("b"), time.Hour),
+			"datasets/acme/data": storedOrgRequest(ResourceTypeDataset, "acme/data", orgToken("d"), time.Hour),
+		},
+	}
+	previous := orgResources
+	orgResources = store
+	t.Cleanup(func() { orgResources = previous })
+
+	tests := []struct {
+		name         string
+		org          string
+		resourceType ResourceType
+		resources    string
+		reused       string
+		stale        string
+		missing      string
+		failed       string
+		secrets      string
+		saved        string
+	}{
+		{name: "acme models", org: "acme", resourceType: ResourceTypeModel,
+			resources: "acme/new,acme/old,acme/stored", reused: "acme/stored", stale: "acme/old", missing: "acme/new", failed: "acme/gone",
+			secrets: "acme/new=" + orgToken("n") + ",acme/old=" + orgToken("o") + ",acme/stored=" + orgToken("s"), saved: "acme/new,acme/old"},
+		{name: "acme datasets", org: "acme", resourceType: ResourceTypeDataset,
+			resources: "acme/data", reused: "acme/data", secrets: "acme/data=" + orgToken("d")},
+		{name: "beta models", org: "beta", resourceType: ResourceTypeModel,
+			resources: "beta/model", reused: "beta/model", secrets: "beta/model=" + orgToken("b")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.saved = nil
+			findings, report, err := ScanOrgResources(tt.org, tt.resourceType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lists := map[string][2]string{
+				"resources": {strings.Join(report.Resources, ","), tt.resources},
+				"reused":    {strings.Join(report.Reused, ","), tt.reused},
+				"stale":     {strings.Join(report.Stale, ","), tt.stale},
+				"missing":   {strings.Join(report.Missing, ","), tt.missing},
+			}
+			for name, got := range lists {
+				if got[0] != got[1] {
+					t.Errorf("%s = %q, want %q", name, got[0], got[1])
+				}
+			}
+			failed := []string{}
+			for id := range report.Failed {
+				failed = append(failed, id)
+			}
+			if strings.Join(failed, ",") != tt.failed {
+				t.Errorf("failed = %v, want %q", report.Failed, tt.failed)
+			}
+			if (tt.failed != "") != (report.Coverage.Status == CoveragePartial) {
+				t.Errorf("coverage status = %s with failed %q", report.Coverage.Status, tt.failed)
+			}
+
+			// doosre org ka ek bhi finding nahi, aur stale acme/old ka purana token bhi nahi
+			secrets := []string{}
+			for _, finding := range findings {
+				if !strings.HasPrefix(finding.ResourceID, tt.org+"/") {
+					t.Errorf("finding from %s in %s scan", finding.ResourceID, tt.org)
+				}
+				secrets = append(secrets, finding.ResourceID+"="+finding.Secret)
+			}
+			if strings.Join(secrets, ",") != tt.secrets {
+				t.Errorf("findings = %v, want %s", secrets, tt.secrets)
+			}
+			sort.Strings(store.saved)
+			if strings.Join(store.saved, ",") != tt.saved {
+				t.Errorf("saved requests = %v, want %q", store.saved, tt.saved)
+			}
+		})
+	}
+
+	if _, _, err := ScanOrgResources("nobody", ResourceTypeModel); err == nil {
+		t.Error("org without registered models did not fail")
+	}
+}
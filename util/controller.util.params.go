@@ -8,6 +8,10 @@ func ParseIncludeFlags(c *fiber.Ctx) (includePRs bool, includeDiscussion bool) {
 	return
 }
 
+func ParseDiscussionQuery(c *fiber.Ctx) (DiscussionFilter, error) {
+	return ParseDiscussionFilter(c.Query("discussion_status", ""), c.Query("discussions_since", ""))
+}
+
 func ParsePagination(c *fiber.Ctx) (page int, limit int) {
 	page = c.QueryInt("page", 1)
 	limit = c.QueryInt("limit", 10)
@@ -0,0 +1,401 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// S3 / MinIO / koi bhi S3-compatible store; resource id "bucket" ya "bucket/prefix"
+const (
+	ProviderS3         = "s3"
+	ResourceTypeBucket = "buckets"
+)
+
+// bucket ke objects as a Source; config har call pe env se (init ke waqt .env load nahi hota)
+type S3Source struct{}
+
+func init() {
+	RegisterSource(S3Source{})
+}
+
+func (S3Source) Name() string { return ProviderS3 }
+
+func (S3Source) ResourceTypes() []string { return []string{ResourceTypeBucket} }
+
+// owner yahan bucket (ya bucket/prefix) hai, poora bucket ek hi item hai
+func (S3Source) ListItems(resourceType, owner string) ([]SourceItem, error) {
+	bucket, _ := splitBucketPrefix(owner)
+	if bucket == "" {
+		return nil, fmt.Errorf("bucket name is required")
+	}
+	return []SourceItem{{Provider: ProviderS3, ResourceType: ResourceTypeBucket, ResourceID: strings.TrimRight(owner, "/")}}, nil
+}
+
+// prefix ke neeche ke latest objects list karke text objects FetchFilesWith se laata hai.
+// S3 me commit nahi hota, isliye har baar full scan hota hai (blob cache bhi nahi, ETag content hash ki guarantee nahi)
+func (S3Source) Fetch(aiRequest *models.AI_REQUEST, resourceType, resourceID string, opts FetchOptions) (*RescanPlan, error) {
+	start := time.Now()
+	traceID := uuid.New().String()
+	client := newS3Client()
+	bucket, prefix := splitBucketPrefix(resourceID)
+
+	log.Printf(
+		"op=S3Source.Fetch stage=start trace_id=%s endpoint=%s bucket=%s prefix=%s",
+		traceID, client.endpoint, bucket, prefix,
+	)
+
+	if bucket == "" {
+		return nil, fmt.Errorf("bucket name is required")
+	}
+
+	objects, truncated, err := client.listObjects(bucket, prefix)
+	if err != nil {
+		log.Printf(
+			"op=S3Source.Fetch stage=list_error trace_id=%s bucket=%s prefix=%s error=%v elapsed=%s",
+			traceID, bucket, prefix, err, time.Since(start),
+		)
+		return nil, err
+	}
+
+	aiRequest.Provider = ProviderS3
+	aiRequest.ResourceType = ResourceTypeBucket
+	aiRequest.ResourceID = resourceID
+	candidates := make([]models.SIBLING, 0, len(objects))
+	for _, object := range objects {
+		candidates = append(candidates, models.SIBLING{RFilename: object.Key, Size: object.Size, VersionID: object.VersionID})
+		if object.LastModified > aiRequest.LastModified {
+			aiRequest.LastModified = object.LastModified
+		}
+	}
+
+	aiRequest.Siblings, aiRequest.Skipped, aiRequest.Failed = FetchFilesWith(resourceID, candidates, opts.ByteBudget, func(file models.SIBLING, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error) {
+		req, err := client.objectRequest(bucket, file.RFilename, file.VersionID)
+		if err != nil {
+			return file, "", err
+		}
+		return fetchForgeFile(ProviderHTTPClient(ProviderS3), req, file, policy, action)
+	})
+	if truncated {
+		aiRequest.Failed = append(aiRequest.Failed, models.FAILED_ITEM{Name: "listing", Error: fmt.Sprintf("object listing stopped at S3_MAX_OBJECTS=%d", s3MaxObjects())})
+	}
+
+	if opts.IncludeDiscussions || opts.IncludePRs || opts.ScanPRDiffs || opts.ScanHistory {
+		// bucket me discussions / PRs / history hote hi nahi; maanga gaya to coverage partial
+		if opts.IncludeDiscussions {
+			aiRequest.DiscussionsFailed = append(aiRequest.DiscussionsFailed, UnsupportedOption("include_discussions", ProviderS3))
+		}
+		if opts.IncludePRs {
+			aiRequest.PullRequestsFailed = append(aiRequest.PullRequestsFailed, UnsupportedOption("include_prs", ProviderS3))
+		}
+		if opts.ScanPRDiffs {
+			aiRequest.PullRequestsFailed = append(aiRequest.PullRequestsFailed, UnsupportedOption("scan_pr_diffs", ProviderS3))
+		}
+		if opts.ScanHistory {
+			aiRequest.HistoryFailed = append(aiRequest.HistoryFailed, UnsupportedOption("scan_history", ProviderS3))
+		}
+		log.Printf(
+			"op=S3Source.Fetch stage=unsupported_options trace_id=%s bucket=%s include_discussions=%t include_prs=%t scan_pr_diffs=%t scan_history=%t",
+			traceID, bucket, opts.IncludeDiscussions, opts.IncludePRs, opts.ScanPRDiffs, opts.ScanHistory,
+		)
+	}
+
+	log.Printf(
+		"op=S3Source.Fetch stage=success trace_id=%s bucket=%s prefix=%s objects=%d files=%d skipped=%d failed=%d elapsed=%s",
+		traceID, bucket, prefix, len(objects), len(aiRequest.Siblings), len(aiRequest.Skipped), len(aiRequest.Failed), time.Since(start),
+	)
+	return NewRescanPlan("", aiRequest.LastModified, opts), nil
+}
+
+// revision yahan object ka versionId hai; line ka anchor object url me nahi hota
+func (S3Source) FileURL(resourceType, resourceID, revision, fileName string, line int) string {
+	bucket, _ := splitBucketPrefix(resourceID)
+	objectURL := fmt.Sprintf("%s/%s/%s", s3PublicURL(), bucket, awsEscape(fileName, true))
+	if revision != "" {
+		objectURL += "?versionId=" + awsEscape(revision, false)
+	}
+	return objectURL
+}
+
+func splitBucketPrefix(resourceID string) (string, string) {
+	resourceID = strings.TrimLeft(resourceID, "/")
+	if i := strings.Index(resourceID, "/"); i >= 0 {
+		return resourceID[:i], resourceID[i+1:]
+	}
+	return resourceID, ""
+}
+
+func s3MaxObjects() int {
+	return envInt("S3_MAX_OBJECTS", 10000)
+}
+
+// findings ke links ke liye; private endpoint ho to S3_PUBLIC_URL se console / proxy url de sakte hai
+func s3PublicURL() string {
+	return forgeBaseURL("S3_PUBLIC_URL", newS3Client().endpoint)
+}
+
+type s3Client struct {
+	endpoint     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// path-style urls (endpoint/bucket/key), MinIO aur baaki S3-compatible stores yahi samajhte hai.
+// keys na ho to anonymous requests (public bucket)
+func newS3Client() s3Client {
+	region := GetEnv("S3_REGION", GetEnv("AWS_REGION", "us-east-1"))
+	return s3Client{
+		endpoint:     forgeBaseURL("S3_ENDPOINT", fmt.Sprintf("https://s3.%s.amazonaws.com", region)),
+		region:       region,
+		accessKey:    GetEnv("S3_ACCESS_KEY_ID", GetEnv("AWS_ACCESS_KEY_ID", "")),
+		secretKey:    GetEnv("S3_SECRET_ACCESS_KEY", GetEnv("AWS_SECRET_ACCESS_KEY", "")),
+		sessionToken: GetEnv("S3_SESSION_TOKEN", GetEnv("AWS_SESSION_TOKEN", "")),
+	}
+}
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool   `xml:"IsLatest"`
+	LastModified string `xml:"LastModified"`
+	Size         int64  `xml:"Size"`
+}
+
+// har key ka latest version; versions listing ki permission / support na ho to ListObjectsV2 (tab versionId nahi milta)
+func (s s3Client) listObjects(bucket, prefix string) ([]s3Object, bool, error) {
+	objects, truncated, err := s.listObjectVersions(bucket, prefix)
+	if status := StatusFromError(err); err != nil && status != 0 && status != http.StatusNotFound {
+		log.Printf("op=S3Source.listObjects stage=versions_fallback bucket=%s status=%d", bucket, status)
+		return s.listObjectsV2(bucket, prefix)
+	}
+	return objects, truncated, err
+}
+
+func (s s3Client) listObjectVersions(bucket, prefix string) ([]s3Object, bool, error) {
+	files := []s3Object{}
+	keyMarker, versionMarker := "", ""
+	for {
+		var page struct {
+			IsTruncated         bool       `xml:"IsTruncated"`
+			NextKeyMarker       string     `xml:"NextKeyMarker"`
+			NextVersionIDMarker string     `xml:"NextVersionIdMarker"`
+			Versions            []s3Object `xml:"Version"`
+		}
+		query := url.Values{"versions": {""}, "prefix": {prefix}}
+		if keyMarker != "" {
+			query.Set("key-marker", keyMarker)
+			query.Set("version-id-marker", versionMarker)
+		}
+		if err := s.getXML(bucket, query, &page); err != nil {
+			return files, false, err
+		}
+		// delete markers alag element me aate hai, to deleted keys ka koi latest Version nahi milta
+		for _, object := range page.Versions {
+			if !object.IsLatest || strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			// cap pe pahunch ke bhi aur object bacha ho tabhi listing adhoori hai
+			if len(files) >= s3MaxObjects() {
+				return files, true, nil
+			}
+			// unversioned bucket me versionId "null" aata hai
+			if object.VersionID == "null" {
+				object.VersionID = ""
+			}
+			files = append(files, object)
+		}
+		if !page.IsTruncated || page.NextKeyMarker == "" {
+			return files, false, nil
+		}
+		keyMarker, versionMarker = page.NextKeyMarker, page.NextVersionIDMarker
+	}
+}
+
+func (s s3Client) listObjectsV2(bucket, prefix string) ([]s3Object, bool, error) {
+	files := []s3Object{}
+	token := ""
+	for {
+		var page struct {
+			IsTruncated           bool       `xml:"IsTruncated"`
+			NextContinuationToken string     `xml:"NextContinuationToken"`
+			Contents              []s3Object `xml:"Contents"`
+		}
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		if err := s.getXML(bucket, query, &page); err != nil {
+			return files, false, err
+		}
+		for _, object := range page.Contents {
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			if len(files) >= s3MaxObjects() {
+				return files, true, nil
+			}
+			files = append(files, object)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return files, false, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s s3Client) getXML(bucket string, query url.Values, out interface{}) error {
+	req, err := s.newRequest(bucket, "", query)
+	if err != nil {
+		return err
+	}
+	resp, err := ProviderHTTPClient(ProviderS3).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &HTTPStatusError{URL: req.URL.String(), Status: resp.StatusCode}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse listing of bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+func (s s3Client) objectRequest(bucket, key, versionID string) (*http.Request, error) {
+	query := url.Values{}
+	if versionID != "" {
+		query.Set("versionId", versionID)
+	}
+	return s.newRequest(bucket, key, query)
+}
+
+// signed GET; Range jaise headers baad me lagaye ja sakte hai kyunki wo signature me nahi jaate
+func (s s3Client) newRequest(bucket, key string, query url.Values) (*http.Request, error) {
+	rawURL := s.endpoint + "/" + awsEscape(bucket, false)
+	if key != "" {
+		rawURL += "/" + awsEscape(key, true)
+	}
+	if len(query) > 0 {
+		rawURL += "?" + awsCanonicalQuery(query)
+	}
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.accessKey != "" && s.secretKey != "" {
+		s.sign(req, time.Now().UTC(), "UNSIGNED-PAYLOAD")
+	}
+	return req, nil
+}
+
+// AWS Signature Version 4; hamare GET ki body hamesha khaali hai isliye caller UNSIGNED-PAYLOAD deta hai
+func (s s3Client) sign(req *http.Request, now time.Time, payloadHash string) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if s.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.sessionToken)
+		headers["x-amz-security-token"] = s.sessionToken
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// SigV4 wala encoding: unreserved chars ke alawa sab %XX, url.PathEscape isse kam escape karta hai
+func awsEscape(value string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// keys sorted, "versions" jaise khaali value wale params "versions=" bante hai
+func awsCanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := []string{}
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, awsEscape(key, false)+"="+awsEscape(value, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -1,6 +1,34 @@
 package util_model
 
+import "regexp"
+
 type SecretPattern struct {
 	Name  string
 	Regex string
+	// koi bhi keyword (case-insensitive) text me na ho to regex chalta hi nahi
+	Keywords []string
+	Severity string
+	// gitleaks jaise: secret regex ka ye capture group (0 = pehla non-empty group, group na ho to poora match),
+	// secret ki Shannon entropy isse kam ho to finding nahi, Path regex file path pe (set ho to sirf matching files)
+	SecretGroup int
+	Entropy     float64
+	Path        string
+	Allowlists  []PatternAllowlist
+	// rule set load hote hi compile (NewRuleSet); nil ho (SecretConfig, tests) to scanner khud compile karta hai
+	Compiled     *regexp.Regexp `json:"-"`
+	PathCompiled *regexp.Regexp `json:"-"`
+}
+
+// inme se koi (Condition "AND" ho to saare) criteria match kare to finding allowlisted hai
+type PatternAllowlist struct {
+	Condition string
+	Commits   []string
+	Paths     []string
+	Regexes   []string
+	// Regexes kis pe chalte hai: secret (default), match ya line
+	RegexTarget string
+	StopWords   []string
+	// Paths / Regexes ke compiled regex, same order me; hash me nahi jaate
+	PathsCompiled   []*regexp.Regexp `json:"-"`
+	RegexesCompiled []*regexp.Regexp `json:"-"`
 }
@@ -0,0 +1,205 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	util_model "github.com/MishraShardendu22/Scanner/util/model"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scan response me dikhane ke liye cache ka hisaab
+type BlobCacheStats struct {
+	Hits     int `json:"hits"`
+	Misses   int `json:"misses"`
+	Uncached int `json:"uncached"`
+}
+
+func (s *BlobCacheStats) Add(other BlobCacheStats) {
+	s.Hits += other.Hits
+	s.Misses += other.Misses
+	s.Uncached += other.Uncached
+}
+
+func HashRuleSet(patterns []util_model.SecretPattern) string {
+	h := sha256.New()
+	for _, pattern := range patterns {
+		h.Write([]byte(pattern.Name))
+		h.Write([]byte{0})
+		h.Write([]byte(pattern.Regex))
+		h.Write([]byte{0})
+		// keywords bhi matches badalte hai; bina keywords wale rules ka hash pehle jaisa
+		if len(pattern.Keywords) > 0 {
+			h.Write([]byte(strings.Join(pattern.Keywords, "\x00")))
+			h.Write([]byte{0})
+		}
+		// gitleaks wale fields bhi sirf set hone pe, taaki purane rule sets ka version na badle
+		if pattern.SecretGroup != 0 || pattern.Entropy != 0 || pattern.Path != "" || len(pattern.Allowlists) > 0 {
+			extra, _ := json.Marshal([]interface{}{pattern.SecretGroup, pattern.Entropy, pattern.Path, pattern.Allowlists})
+			h.Write(extra)
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func BlobCacheEnabled() bool {
+	return GetEnv("BLOB_CACHE_ENABLED", "true") == "true"
+}
+
+// LFS file ho to uska content sha256, warna git blob id
+// path wale rules active ho to same blob alag path pe alag outcome de sakta hai, isliye key me path bhi
+func BlobCacheKey(file models.SIBLING) string {
+	key := ""
+	if file.LFSSha256 != "" {
+		key = "sha256:" + file.LFSSha256
+	} else if file.BlobID != "" {
+		key = "git:" + file.BlobID
+	}
+	if key != "" && ActiveRuleSet().PathDependent() {
+		key += ":" + file.RFilename
+	}
+	return key
+}
+
+// cache me is blob ka outcome hai to matches aur true
+func LookupBlobScan(blobKey string) ([]models.BLOB_MATCH, bool) {
+	if blobKey == "" || !BlobCacheEnabled() {
+		return nil, false
+	}
+	entry := &models.BLOB_SCAN_CACHE{}
+	err := mgm.Coll(entry).First(bson.M{
+		"blob_key":         blobKey,
+		"rule_set_version": RuleSetVersion(),
+	}, entry)
+	if err != nil {
+		return nil, false
+	}
+	return entry.Matches, true
+}
+
+func StoreBlobScan(blobKey string, findings []models.Finding) {
+	if blobKey == "" || !BlobCacheEnabled() {
+		return
+	}
+	matches := []models.BLOB_MATCH{}
+	for _, finding := range findings {
+		matches = append(matches, models.BLOB_MATCH{
+			SecretType: finding.SecretType,
+			Pattern:    finding.Pattern,
+			Secret:     finding.Secret,
+			Line:       finding.Line,
+			Cell:       finding.Cell,
+			CellLine:   finding.CellLine,
+		})
+	}
+
+	entry := &models.BLOB_SCAN_CACHE{}
+	_, err := mgm.Coll(entry).UpdateOne(mgm.Ctx(),
+		bson.M{"blob_key": blobKey, "rule_set_version": RuleSetVersion()},
+		bson.M{"$setOnInsert": bson.M{
+			"blob_key":         blobKey,
+			"rule_set_version": RuleSetVersion(),
+			"matches":          matches,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("op=StoreBlobScan stage=db_error blob_key=%s error=%v", blobKey, err)
+	}
+}
+
+// cached matches ko is resource aur file ke findings me badalta hai
+func FindingsFromBlobMatches(matches []models.BLOB_MATCH, file models.SIBLING, resourceType, resourceID string) []models.Finding {
+	var findings []models.Finding
+	organization := ExtractOrgFromResourceID(resourceID)
+	for _, match := range matches {
+		findings = append(findings, models.Finding{
+			SecretType:   match.SecretType,
+			Pattern:      match.Pattern,
+			Secret:       match.Secret,
+			SourceType:   "file",
+			Organization: organization,
+			ResourceID:   resourceID,
+			ResourceType: resourceType,
+			FileName:     file.RFilename,
+			Line:         match.Line,
+			Cell:         match.Cell,
+			CellLine:     match.CellLine,
+			VersionID:    file.VersionID,
+			LayerDigest:  file.LayerDigest,
+			URL:          BuildHuggingFaceFileURL(resourceType, resourceID, file.RFilename, match.Line),
+		})
+	}
+	return findings
+}
+
+// cache ke saath file scan: hit pe stored outcome, miss pe normal scan aur phir store
+func ScanFileCached(file models.SIBLING, patterns []util_model.SecretPattern, resourceType, resourceID string) []models.Finding {
+	blobKey := BlobCacheKey(file)
+	if file.Cached {
+		if file.CachedMatches != nil {
+			return FindingsFromBlobMatches(file.CachedMatches, file, resourceType, resourceID)
+		}
+		if matches, ok := LookupBlobScan(blobKey); ok {
+			return FindingsFromBlobMatches(matches, file, resourceType, resourceID)
+		}
+		// content kabhi download hi nahi hua; khaali string scan karke "clean" bolna galat hoga.
+		// ResolveCachedSiblings aisi files ko coverage me skipped karta hai
+		log.Printf("op=ScanFileCached stage=cache_miss resource_id=%s filename=%s blob_key=%s", resourceID, file.RFilename, blobKey)
+		return nil
+	}
+
+	findings := ScanFile(file, patterns, resourceType, resourceID)
+	// khaali content ka matlab fetch fail bhi ho sakta hai, usko cache nahi karte
+	// truncated (sampled / streamed) content ka outcome poori file ka nahi hai
+	if !file.Cached && !file.Truncated && (file.FileContent != "" || file.Size == 0) && samePatterns(patterns) {
+		StoreBlobScan(blobKey, findings)
+	}
+	return findings
+}
+
+// stored request dobara scan karne se pehle: cached siblings ka outcome cache se utha lo,
+// jinka outcome ab cache me nahi (evict ya rule set badla) wo skipped (cache_miss) me chale jaate hai
+func ResolveCachedSiblings(req *models.AI_REQUEST) {
+	siblings := req.Siblings[:0:0]
+	for _, sibling := range req.Siblings {
+		if !sibling.Cached || sibling.CachedMatches != nil {
+			siblings = append(siblings, sibling)
+			continue
+		}
+		if matches, ok := LookupBlobScan(BlobCacheKey(sibling)); ok {
+			sibling.CachedMatches = append([]models.BLOB_MATCH{}, matches...)
+			siblings = append(siblings, sibling)
+			continue
+		}
+		req.Skipped = append(req.Skipped, models.SKIPPED_FILE{RFilename: sibling.RFilename, Reason: SkipReasonCacheMiss, Size: sibling.Size})
+	}
+	req.Siblings = siblings
+}
+
+// cache sirf default rule set ke liye hai, custom patterns ke outcome alag hote hai
+func samePatterns(patterns []util_model.SecretPattern) bool {
+	return HashRuleSet(patterns) == RuleSetVersion()
+}
+
+func CountBlobCache(siblings []models.SIBLING) BlobCacheStats {
+	stats := BlobCacheStats{}
+	for _, sibling := range siblings {
+		switch {
+		case sibling.Cached:
+			stats.Hits++
+		case strings.TrimSpace(BlobCacheKey(sibling)) != "":
+			stats.Misses++
+		default:
+			stats.Uncached++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+func TestResolveCachedSiblingsMarksMissesSkipped(t *testing.T) {
+	// cache band: har lookup miss hai, Mongo ki zaroorat nahi
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+
+	req := &models.AI_REQUEST{
+		Siblings: []models.SIBLING{
+			{RFilename: "config.json", FileContent: `{"a": 1}`},
+			{RFilename: "cached.env", BlobID: "abc123", Size: 42, Cached: true},
+			{RFilename: "resolved.env", BlobID: "def456", Cached: true, CachedMatches: []models.BLOB_MATCH{}},
+		},
+	}
+	ResolveCachedSiblings(req)
+
+	if len(req.Siblings) != 2 || req.Siblings[0].RFilename != "config.json" || req.Siblings[1].RFilename != "resolved.env" {
+		t.Fatalf("siblings = %+v, want config.json and resolved.env", req.Siblings)
+	}
+	if len(req.Skipped) != 1 || req.Skipped[0].RFilename != "cached.env" || req.Skipped[0].Reason != SkipReasonCacheMiss {
+		t.Fatalf("skipped = %+v, want cached.env as cache_miss", req.Skipped)
+	}
+	coverage := CoverageFromRequest(*req)
+	if coverage.Status != CoveragePartial || coverage.FilesScanned != 2 {
+		t.Errorf("coverage status=%s scanned=%d, want partial and 2", coverage.Status, coverage.FilesScanned)
+	}
+}
+
+func TestScanFileCachedUsesFetchedMatches(t *testing.T) {
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+
+	file := models.SIBLING{
+		RFilename: ".env",
+		BlobID:    "abc123",
+		Cached:    true,
+		CachedMatches: []models.BLOB_MATCH{
+			{SecretType: "Test Token", Pattern: testPatterns[0].Regex, Secret: "tok_abcdef123456", Line: 3},
+		},
+	}
+	findings := ScanFileCached(file, testPatterns, "models", "org/repo")
+	if len(findings) != 1 || findings[0].Line != 3 || findings[0].FileName != ".env" {
+		t.Fatalf("findings = %+v, want the cached match on line 3", findings)
+	}
+
+	// outcome na fetch pe mila na cache me: khaali content scan karke clean nahi bolna
+	file.CachedMatches = nil
+	file.FileContent = ""
+	if findings := ScanFileCached(file, testPatterns, "models", "org/repo"); len(findings) != 0 {
+		t.Fatalf("findings = %+v, want none", findings)
+	}
+}
@@ -0,0 +1,140 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+const (
+	CoverageComplete = "complete"
+	CoveragePartial  = "partial"
+)
+
+// in reasons se skip hui file ka content kabhi scan hi nahi hua, to coverage adhoori hai;
+// extension / binary / lfs_pointer wali files me scan karne layak text tha hi nahi
+var incompleteSkipReasons = map[string]bool{
+	SkipReasonSize:      true,
+	SkipReasonLFS:       true,
+	SkipReasonCacheMiss: true,
+	SkipReasonBudget:    true,
+}
+
+func NewScanCoverage() *models.SCAN_COVERAGE {
+	return &models.SCAN_COVERAGE{
+		Status:             CoverageComplete,
+		Skipped:            []models.SKIPPED_FILE{},
+		Truncated:          []models.SKIPPED_FILE{},
+		Failed:             []models.FAILED_ITEM{},
+		DiscussionsFailed:  []models.FAILED_ITEM{},
+		PullRequestsFailed: []models.FAILED_ITEM{},
+		HistoryFailed:      []models.FAILED_ITEM{},
+	}
+}
+
+// request me kitni files / discussions dekhi gayi, kaunsi skip, truncate ya fail hui
+func CoverageFromRequest(req models.AI_REQUEST) *models.SCAN_COVERAGE {
+	coverage := NewScanCoverage()
+	coverage.Skipped = append(coverage.Skipped, req.Skipped...)
+	coverage.Failed = append(coverage.Failed, req.Failed...)
+	coverage.FilesScanned = len(req.Siblings)
+	coverage.FilesConsidered = len(req.Siblings) + len(req.Skipped) + len(req.Failed)
+	for _, sibling := range req.Siblings {
+		if sibling.Truncated {
+			coverage.Truncated = append(coverage.Truncated, models.SKIPPED_FILE{
+				RFilename: sibling.RFilename,
+				Reason:    sibling.FetchMode,
+				Size:      sibling.Size,
+			})
+		}
+	}
+
+	coverage.DiscussionsFailed = append(coverage.DiscussionsFailed, req.DiscussionsFailed...)
+	coverage.PullRequestsFailed = append(coverage.PullRequestsFailed, req.PullRequestsFailed...)
+	coverage.HistoryFailed = append(coverage.HistoryFailed, req.HistoryFailed...)
+	for _, disc := range req.Discussions {
+		if disc.EventsError != "" {
+			coverage.DiscussionsFailed = append(coverage.DiscussionsFailed, models.FAILED_ITEM{
+				Name:  fmt.Sprintf("#%d", disc.Num),
+				Error: disc.EventsError,
+			})
+			continue
+		}
+		coverage.DiscussionsFetched++
+	}
+
+	coverage.Status = CoverageStatus(coverage)
+	return coverage
+}
+
+func CoverageStatus(coverage *models.SCAN_COVERAGE) string {
+	if len(coverage.Failed) > 0 || len(coverage.Truncated) > 0 || len(coverage.DiscussionsFailed) > 0 ||
+		len(coverage.PullRequestsFailed) > 0 || len(coverage.HistoryFailed) > 0 {
+		return CoveragePartial
+	}
+	for _, file := range coverage.Skipped {
+		if incompleteSkipReasons[file.Reason] {
+			return CoveragePartial
+		}
+	}
+	return CoverageComplete
+}
+
+// org / collection scan me har resource ki coverage ek jagah, resource id ke saath
+func MergeCoverage(dst, src *models.SCAN_COVERAGE, resourceID string) {
+	if dst == nil || src == nil {
+		return
+	}
+	dst.FilesConsidered += src.FilesConsidered
+	dst.FilesScanned += src.FilesScanned
+	dst.DiscussionsFetched += src.DiscussionsFetched
+	for _, file := range src.Skipped {
+		file.ResourceID = resourceID
+		dst.Skipped = append(dst.Skipped, file)
+	}
+	for _, file := range src.Truncated {
+		file.ResourceID = resourceID
+		dst.Truncated = append(dst.Truncated, file)
+	}
+	for _, item := range src.Failed {
+		item.ResourceID = resourceID
+		dst.Failed = append(dst.Failed, item)
+	}
+	for _, item := range src.DiscussionsFailed {
+		item.ResourceID = resourceID
+		dst.DiscussionsFailed = append(dst.DiscussionsFailed, item)
+	}
+	for _, item := range src.PullRequestsFailed {
+		item.ResourceID = resourceID
+		dst.PullRequestsFailed = append(dst.PullRequestsFailed, item)
+	}
+	for _, item := range src.HistoryFailed {
+		item.ResourceID = resourceID
+		dst.HistoryFailed = append(dst.HistoryFailed, item)
+	}
+	dst.Status = CoverageStatus(dst)
+}
+
+// source ye option support nahi karta; sirf log me likhne se report "complete" dikhti, isliye 501 ke saath coverage me
+func UnsupportedOption(option, provider string) models.FAILED_ITEM {
+	return models.FAILED_ITEM{
+		Name:   option,
+		Status: http.StatusNotImplemented,
+		Error:  fmt.Sprintf("%s is not supported for %s resources", option, provider),
+	}
+}
+
+// poora resource hi fetch nahi hua (org / collection me), wo bhi coverage me failure hai
+func AddFailedResource(dst *models.SCAN_COVERAGE, resourceID string, err error) {
+	if dst == nil || err == nil {
+		return
+	}
+	dst.Failed = append(dst.Failed, models.FAILED_ITEM{
+		ResourceID: resourceID,
+		Name:       resourceID,
+		Status:     StatusFromError(err),
+		Error:      err.Error(),
+	})
+	dst.Status = CoveragePartial
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// diff ki ek line, new file (added) ya old file (removed) ke line number ke saath
+type DiffLine struct {
+	Line    int
+	Content string
+}
+
+type DiffFile struct {
+	Path    string
+	OldPath string
+	BlobID  string
+	Deleted bool
+	Added   []DiffLine
+	Removed []DiffLine
+}
+
+// unified git diff parse karta hai aur har file ki added / removed lines nikalta hai
+// "index abc..def" line se new blob id bhi mil jaati hai, history scan me dedupe ke kaam aati hai.
+// Hunk header ki line counts se pata hai hunk kab khatam hua; hunk ke andar "+++ " / "--- " wali line
+// bhi content hai (jaise "++ x" add hua), file header sirf hunk ke bahar
+func ParseUnifiedDiff(diff string) []DiffFile {
+	var files []DiffFile
+	var current *DiffFile
+	newLine, oldLine := 0, 0
+	newLeft, oldLeft := 0, 0
+
+	flush := func() {
+		if current != nil && current.Path != "" && (len(current.Added) > 0 || len(current.Removed) > 0) {
+			files = append(files, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if newLeft > 0 || oldLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			case strings.HasPrefix(line, "+"):
+				if current != nil {
+					current.Added = append(current.Added, DiffLine{Line: newLine, Content: line[1:]})
+				}
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				if current != nil {
+					current.Removed = append(current.Removed, DiffLine{Line: oldLine, Content: line[1:]})
+				}
+				oldLine++
+				oldLeft--
+			default:
+				// context line (khaali line bhi context hai, kuch tools leading space kaat dete hai)
+				newLine++
+				oldLine++
+				newLeft--
+				oldLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			current = &DiffFile{}
+			// "diff --git a/path b/path", +++ line aane tak b/ wala path use karte hai
+			if idx := strings.LastIndex(line, " b/"); idx >= 0 {
+				current.Path = line[idx+3:]
+				current.OldPath = strings.TrimPrefix(line[len("diff --git "):idx], "a/")
+			}
+		case strings.HasPrefix(line, "index ") && current != nil:
+			// "index 1a2b3c..4d5e6f 100644"
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				if dots := strings.Index(fields[1], ".."); dots >= 0 {
+					current.BlobID = fields[1][dots+2:]
+				}
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if current == nil {
+				current = &DiffFile{}
+			}
+			path := strings.TrimPrefix(line, "+++ ")
+			if path == "/dev/null" {
+				// file delete hui hai, path purana wala rakhte hai
+				current.Deleted = true
+				if current.OldPath != "" {
+					current.Path = current.OldPath
+				}
+			} else {
+				current.Path = strings.TrimPrefix(path, "b/")
+			}
+		case strings.HasPrefix(line, "--- "):
+			// bina "diff --git" wale diff me nayi file "--- " se hi shuru hoti hai
+			if current != nil && len(current.Added)+len(current.Removed) > 0 {
+				flush()
+			}
+			if current == nil {
+				current = &DiffFile{}
+			}
+			if line != "--- /dev/null" {
+				current.OldPath = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+			}
+		case strings.HasPrefix(line, "@@"):
+			oldLine, newLine, oldLeft, newLeft = parseHunkHeader(line)
+		}
+	}
+	flush()
+
+	return files
+}
+
+// "@@ -12,7 +14,9 @@ func x" se (12, 14, 7, 9); count na ho to 1
+func parseHunkHeader(header string) (oldStart, newStart, oldCount, newCount int) {
+	oldStart, oldCount = parseHunkRange(header, "-")
+	newStart, newCount = parseHunkRange(header, "+")
+	return oldStart, newStart, oldCount, newCount
+}
+
+func parseHunkRange(header, sign string) (int, int) {
+	idx := strings.Index(header, " "+sign)
+	if idx < 0 {
+		return 1, 0
+	}
+	rest := header[idx+2:]
+	if end := strings.IndexAny(rest, " @"); end >= 0 {
+		rest = rest[:end]
+	}
+	startText, countText, hasCount := strings.Cut(rest, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 1, 0
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return start, 0
+		}
+	}
+	return start, count
+}
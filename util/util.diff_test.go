@@ -0,0 +1,99 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want []DiffFile
+	}{
+		{
+			name: "added and removed lines with line numbers",
+			diff: "diff --git a/app.py b/app.py\n" +
+				"index 1a2b3c..4d5e6f 100644\n" +
+				"--- a/app.py\n" +
+				"+++ b/app.py\n" +
+				"@@ -10,3 +10,3 @@ def main():\n" +
+				" x = 1\n" +
+				"-token = 'old'\n" +
+				"+token = 'new'\n" +
+				" y = 2\n",
+			want: []DiffFile{{
+				Path:    "app.py",
+				OldPath: "app.py",
+				BlobID:  "4d5e6f",
+				Added:   []DiffLine{{Line: 11, Content: "token = 'new'"}},
+				Removed: []DiffLine{{Line: 11, Content: "token = 'old'"}},
+			}},
+		},
+		{
+			// "++ x" add hua to diff me "+++ x" aata hai, ye file header nahi hai
+			name: "added line that looks like a file header",
+			diff: "diff --git a/notes.md b/notes.md\n" +
+				"--- a/notes.md\n" +
+				"+++ b/notes.md\n" +
+				"@@ -1,2 +1,4 @@\n" +
+				" intro\n" +
+				"+++ key=abc\n" +
+				"--- old=xyz\n" +
+				"+after\n" +
+				" outro\n",
+			want: []DiffFile{{
+				Path:    "notes.md",
+				OldPath: "notes.md",
+				Added:   []DiffLine{{Line: 2, Content: "++ key=abc"}, {Line: 3, Content: "after"}},
+				Removed: []DiffLine{{Line: 2, Content: "-- old=xyz"}},
+			}},
+		},
+		{
+			name: "two files, second deleted, counts omitted",
+			diff: "diff --git a/a.txt b/a.txt\n" +
+				"--- a/a.txt\n" +
+				"+++ b/a.txt\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+first\n" +
+				"diff --git a/gone.env b/gone.env\n" +
+				"deleted file mode 100644\n" +
+				"--- a/gone.env\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-SECRET=1\n" +
+				"\\ No newline at end of file\n",
+			want: []DiffFile{
+				{Path: "a.txt", OldPath: "a.txt", Added: []DiffLine{{Line: 1, Content: "first"}}},
+				{Path: "gone.env", OldPath: "gone.env", Deleted: true, Removed: []DiffLine{{Line: 1, Content: "SECRET=1"}}},
+			},
+		},
+		{
+			name: "multiple hunks",
+			diff: "--- a/cfg.yml\n" +
+				"+++ b/cfg.yml\n" +
+				"@@ -1,1 +1,2 @@\n" +
+				" a: 1\n" +
+				"+b: 2\n" +
+				"@@ -20,2 +21,2 @@\n" +
+				"-c: 3\n" +
+				"+c: 4\n" +
+				" d: 5\n",
+			want: []DiffFile{{
+				Path:    "cfg.yml",
+				OldPath: "cfg.yml",
+				Added:   []DiffLine{{Line: 2, Content: "b: 2"}, {Line: 21, Content: "c: 4"}},
+				Removed: []DiffLine{{Line: 20, Content: "c: 3"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseUnifiedDiff(tt.diff)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseUnifiedDiff() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
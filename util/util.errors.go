@@ -1,6 +1,9 @@
 package util
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrOrgRequired        = errors.New("organization name is required")
@@ -9,3 +12,22 @@ var (
 	ErrDatasetIDRequired  = errors.New("dataset ID is required")
 	ErrSpaceIDRequired    = errors.New("space ID is required")
 )
+
+// HF ne non-200 diya, caller ko status code chahiye (404 vs 5xx) isliye alag type
+type HTTPStatusError struct {
+	URL    string
+	Status int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d from %s", e.Status, e.URL)
+}
+
+// error me HTTP status ho to wahi, warna 0
+func StatusFromError(err error) int {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Status
+	}
+	return 0
+}
@@ -0,0 +1,577 @@
+package util
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// exports (CSV / NDJSON / JUnit / SARIF) me secret kaise dikhe, SECRET_DISPLAY se server wide:
+// full = poora, redacted = pehle 4 characters (default), hidden = secret column hi nahi
+const (
+	SecretDisplayFull     = "full"
+	SecretDisplayRedacted = "redacted"
+	SecretDisplayHidden   = "hidden"
+)
+
+func SecretDisplayPolicy() string {
+	policy := strings.ToLower(strings.TrimSpace(GetEnv("SECRET_DISPLAY", SecretDisplayRedacted)))
+	switch policy {
+	case SecretDisplayFull, SecretDisplayRedacted, SecretDisplayHidden:
+		return policy
+	}
+	// galat value pe sabse safe ke bajaye default, taaki export band na ho jaaye
+	log.Printf("op=SecretDisplayPolicy stage=invalid_value value=%q fallback=%s", policy, SecretDisplayRedacted)
+	return SecretDisplayRedacted
+}
+
+func DisplaySecret(secret, policy string) string {
+	switch policy {
+	case SecretDisplayFull:
+		return secret
+	case SecretDisplayHidden:
+		return ""
+	}
+	return RedactSecret(secret)
+}
+
+// export ki ek row: finding + kis scan me mili
+type ExportRow struct {
+	ScanID      string
+	ScannedAt   time.Time
+	Severity    string
+	Fingerprint string
+	Finding     models.Finding
+}
+
+type ExportColumn struct {
+	Name  string
+	Value func(ExportRow) any
+}
+
+// column order hi CSV header / NDJSON keys ka order hai; secret ki value policy ke hisaab se row banate waqt hi set hoti hai
+var exportColumns = []ExportColumn{
+	{"scan_id", func(r ExportRow) any { return r.ScanID }},
+	{"scanned_at", func(r ExportRow) any {
+		if r.ScannedAt.IsZero() {
+			return nil
+		}
+		return r.ScannedAt.UTC().Format(time.RFC3339)
+	}},
+	{"severity", func(r ExportRow) any { return r.Severity }},
+	{"secret_type", func(r ExportRow) any { return r.Finding.SecretType }},
+	{"secret", func(r ExportRow) any { return r.Finding.Secret }},
+	{"fingerprint", func(r ExportRow) any { return r.Fingerprint }},
+	{"source_type", func(r ExportRow) any { return r.Finding.SourceType }},
+	{"provider", func(r ExportRow) any { return r.Finding.Provider }},
+	{"resource_type", func(r ExportRow) any { return r.Finding.ResourceType }},
+	{"resource_id", func(r ExportRow) any { return r.Finding.ResourceID }},
+	{"organization", func(r ExportRow) any { return r.Finding.Organization }},
+	{"file_name", func(r ExportRow) any { return r.Finding.FileName }},
+	{"line", func(r ExportRow) any { return r.Finding.Line }},
+	{"cell", func(r ExportRow) any { return r.Finding.Cell }},
+	{"cell_line", func(r ExportRow) any { return r.Finding.CellLine }},
+	{"url", func(r ExportRow) any { return r.Finding.URL }},
+	{"discussion_num", func(r ExportRow) any { return r.Finding.DiscussionNum }},
+	{"comment_id", func(r ExportRow) any { return r.Finding.CommentID }},
+	{"pr_num", func(r ExportRow) any { return r.Finding.PRNum }},
+	{"commit_sha", func(r ExportRow) any { return r.Finding.CommitSHA }},
+	{"version_id", func(r ExportRow) any { return r.Finding.VersionID }},
+	{"layer_digest", func(r ExportRow) any { return r.Finding.LayerDigest }},
+	{"first_commit", func(r ExportRow) any { return r.Finding.FirstCommit }},
+	{"last_commit", func(r ExportRow) any { return r.Finding.LastCommit }},
+	{"still_at_head", func(r ExportRow) any {
+		if r.Finding.StillAtHead == nil {
+			return nil
+		}
+		return *r.Finding.StillAtHead
+	}},
+	{"pattern", func(r ExportRow) any { return r.Finding.Pattern }},
+}
+
+func ExportColumnNames() []string {
+	names := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// "columns=secret_type,file_name,line" -> unhi columns me, us order me; khaali = saare.
+// hidden policy me secret column maanga hi nahi ja sakta
+func ParseExportColumns(requested, policy string) ([]ExportColumn, error) {
+	byName := map[string]ExportColumn{}
+	for _, column := range exportColumns {
+		byName[column.Name] = column
+	}
+
+	columns := []ExportColumn{}
+	if strings.TrimSpace(requested) == "" {
+		for _, column := range exportColumns {
+			if column.Name == "secret" && policy == SecretDisplayHidden {
+				continue
+			}
+			columns = append(columns, column)
+		}
+		return columns, nil
+	}
+
+	seen := map[string]bool{}
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		column, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q: use %s", name, strings.Join(ExportColumnNames(), ", "))
+		}
+		if name == "secret" && policy == SecretDisplayHidden {
+			return nil, fmt.Errorf("the secret column is disabled by the server's secret display policy")
+		}
+		seen[name] = true
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return columns, nil
+}
+
+// scans ke across findings ka filter; DB query sirf scans chhatne ke liye, exact match finding pe
+type ExportFilter struct {
+	SecretTypes  []string
+	MinSeverity  string
+	Provider     string
+	ResourceType string
+	ResourceID   string
+	SourceType   string
+	Since        time.Time
+	Until        time.Time
+}
+
+func ParseExportFilter(c *fiber.Ctx) (ExportFilter, error) {
+	filter := ExportFilter{
+		Provider:     strings.TrimSpace(c.Query("provider")),
+		ResourceType: strings.TrimSpace(c.Query("resource_type")),
+		ResourceID:   strings.TrimSpace(c.Query("resource_id")),
+		SourceType:   strings.TrimSpace(c.Query("source_type")),
+	}
+	for _, secretType := range strings.Split(c.Query("secret_type"), ",") {
+		if secretType = strings.TrimSpace(secretType); secretType != "" {
+			filter.SecretTypes = append(filter.SecretTypes, secretType)
+		}
+	}
+	if value := c.Query("severity"); value != "" {
+		severity, err := ParseSeverity(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinSeverity = severity
+	}
+
+	var err error
+	if filter.Since, err = parseExportTime("since", c.Query("since")); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseExportTime("until", c.Query("until")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// RFC3339 ya sirf date (2006-01-02)
+func parseExportTime(name, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %s date: %s", name, value)
+}
+
+func (f ExportFilter) MongoQuery() bson.M {
+	query := bson.M{}
+	createdAt := bson.M{}
+	if !f.Since.IsZero() {
+		createdAt["$gte"] = f.Since
+	}
+	if !f.Until.IsZero() {
+		createdAt["$lte"] = f.Until
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+	if len(f.SecretTypes) > 0 {
+		query["scanned_resources.findings.secret_type"] = bson.M{"$in": f.SecretTypes}
+	}
+	if f.ResourceType != "" {
+		query["scanned_resources.type"] = f.ResourceType
+	}
+	if f.ResourceID != "" {
+		query["scanned_resources.id"] = f.ResourceID
+	}
+	return query
+}
+
+// resource level filters; inse bahar ke resources JUnit me passing suite bhi nahi bante
+func (f ExportFilter) MatchResource(resource models.SCANNED_RESOURCE) bool {
+	if f.Provider != "" && resource.Provider != "" && resource.Provider != f.Provider {
+		return false
+	}
+	if f.ResourceType != "" && resource.Type != f.ResourceType {
+		return false
+	}
+	if f.ResourceID != "" && resource.ID != f.ResourceID {
+		return false
+	}
+	return true
+}
+
+func (f ExportFilter) Match(finding models.Finding) bool {
+	if len(f.SecretTypes) > 0 {
+		found := false
+		for _, secretType := range f.SecretTypes {
+			if finding.SecretType == secretType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinSeverity != "" && SeverityRank(SeverityOf(finding)) < SeverityRank(f.MinSeverity) {
+		return false
+	}
+	if f.Provider != "" && finding.Provider != f.Provider {
+		return false
+	}
+	if f.ResourceType != "" && finding.ResourceType != f.ResourceType {
+		return false
+	}
+	if f.ResourceID != "" && finding.ResourceID != f.ResourceID {
+		return false
+	}
+	if f.SourceType != "" && finding.SourceType != f.SourceType {
+		return false
+	}
+	return true
+}
+
+// resource ke filter wale findings ki rows; purane scans me finding pe provider / resource nahi hota to resource se
+func ExportRows(scan models.SCAN_RESULT, resource models.SCANNED_RESOURCE, filter ExportFilter, policy string) []ExportRow {
+	rows := []ExportRow{}
+	for _, finding := range resource.Findings {
+		if finding.Provider == "" {
+			finding.Provider = resource.Provider
+		}
+		if finding.ResourceType == "" {
+			finding.ResourceType = resource.Type
+		}
+		if finding.ResourceID == "" {
+			finding.ResourceID = resource.ID
+		}
+		if !filter.Match(finding) {
+			continue
+		}
+		row := ExportRow{
+			ScanID:    scan.ID.Hex(),
+			ScannedAt: scan.CreatedAt,
+			Severity:  SeverityOf(finding),
+			// fingerprint asli secret se, display ke liye badalne se pehle
+			Fingerprint: FindingFingerprint(finding),
+			Finding:     finding,
+		}
+		row.Finding.Secret = DisplaySecret(finding.Secret, policy)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ek export format: resource by resource likhta hai, taaki poora result memory me na banana pade
+type FindingExporter interface {
+	WriteResource(scan models.SCAN_RESULT, resource models.SCANNED_RESOURCE, rows []ExportRow) error
+	Close() error
+}
+
+type ExportFormat struct {
+	ContentType string
+	Extension   string
+	New         func(w io.Writer, columns []ExportColumn) (FindingExporter, error)
+}
+
+var ExportFormats = map[string]ExportFormat{
+	"csv":    {ContentType: "text/csv; charset=utf-8", Extension: "csv", New: newCSVExporter},
+	"ndjson": {ContentType: "application/x-ndjson", Extension: "ndjson", New: newNDJSONExporter},
+	"junit":  {ContentType: "application/xml; charset=utf-8", Extension: "xml", New: newJUnitExporter},
+}
+
+func ExportFormatNames() []string {
+	names := make([]string, 0, len(ExportFormats))
+	for name := range ExportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ---- CSV ----
+
+type csvExporter struct {
+	writer  *csv.Writer
+	columns []ExportColumn
+}
+
+func newCSVExporter(w io.Writer, columns []ExportColumn) (FindingExporter, error) {
+	exporter := &csvExporter{writer: csv.NewWriter(w), columns: columns}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := exporter.writer.Write(header); err != nil {
+		return nil, err
+	}
+	return exporter, nil
+}
+
+func (e *csvExporter) WriteResource(scan models.SCAN_RESULT, resource models.SCANNED_RESOURCE, rows []ExportRow) error {
+	record := make([]string, len(e.columns))
+	for _, row := range rows {
+		for i, column := range e.columns {
+			record[i] = csvCell(column.Value(row))
+		}
+		if err := e.writer.Write(record); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// =, +, -, @ se shuru hone wali value spreadsheet me formula na bane isliye ' prefix
+func csvCell(value any) string {
+	cell := exportText(value)
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// zero values khaali
+func exportText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int:
+		if v == 0 {
+			return ""
+		}
+	case int64:
+		if v == 0 {
+			return ""
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// ---- NDJSON ----
+
+type ndjsonExporter struct {
+	w       io.Writer
+	columns []ExportColumn
+}
+
+func newNDJSONExporter(w io.Writer, columns []ExportColumn) (FindingExporter, error) {
+	return &ndjsonExporter{w: w, columns: columns}, nil
+}
+
+// har finding ek line; keys column order me, khaali values null (pipelines ko fixed schema milta hai)
+func (e *ndjsonExporter) WriteResource(scan models.SCAN_RESULT, resource models.SCANNED_RESOURCE, rows []ExportRow) error {
+	var line strings.Builder
+	for _, row := range rows {
+		line.Reset()
+		line.WriteByte('{')
+		for i, column := range e.columns {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			key, _ := json.Marshal(column.Name)
+			value, err := json.Marshal(ndjsonValue(column.Value(row)))
+			if err != nil {
+				return err
+			}
+			line.Write(key)
+			line.WriteByte(':')
+			line.Write(value)
+		}
+		line.WriteString("}\n")
+		if _, err := io.WriteString(e.w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *ndjsonExporter) Close() error { return nil }
+
+func ndjsonValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+	case int:
+		if v == 0 {
+			return nil
+		}
+	case int64:
+		if v == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+// download ka Content-Disposition: purane clients ke liye ASCII safe filename, baaki ke liye RFC 6266 filename*
+func AttachmentDisposition(fileName string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_') {
+			return r
+		}
+		return '_'
+	}, fileName)
+	// RFC 5987 attr-char ke alawa har byte %XX (PathEscape ";" aur "," chhod deta hai jo header tod dete)
+	var encoded strings.Builder
+	for _, c := range []byte(fileName) {
+		if c < 0x80 && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte("!#$&+-.^_`|~", c) >= 0) {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return `attachment; filename="` + safe + `"; filename*=UTF-8''` + encoded.String()
+}
+
+// ---- JUnit XML ----
+
+// har scanned resource ek testsuite, har finding ek failed testcase; clean resource ka ek passing testcase,
+// aur jiske saare findings filter ne hata diye uska ek skipped testcase
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr,omitempty"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	ID        string          `xml:"id,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+type junitExporter struct {
+	w       io.Writer
+	columns []ExportColumn
+}
+
+func newJUnitExporter(w io.Writer, columns []ExportColumn) (FindingExporter, error) {
+	if _, err := io.WriteString(w, xml.Header+"<testsuites name=\"secret-scan\">\n"); err != nil {
+		return nil, err
+	}
+	return &junitExporter{w: w, columns: columns}, nil
+}
+
+func (e *junitExporter) WriteResource(scan models.SCAN_RESULT, resource models.SCANNED_RESOURCE, rows []ExportRow) error {
+	name := strings.TrimSpace(resource.Provider + " " + resource.Type + "/" + resource.ID)
+	suite := junitTestSuite{
+		Name:     name,
+		Tests:    len(rows),
+		Failures: len(rows),
+		ID:       scan.ID.Hex(),
+	}
+	if !scan.CreatedAt.IsZero() {
+		suite.Timestamp = scan.CreatedAt.UTC().Format("2006-01-02T15:04:05")
+	}
+	for _, row := range rows {
+		var body strings.Builder
+		for _, column := range e.columns {
+			if value := exportText(column.Value(row)); value != "" {
+				fmt.Fprintf(&body, "%s: %s\n", column.Name, value)
+			}
+		}
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      fmt.Sprintf("%s in %s", row.Finding.SecretType, FindingLocationText(row.Finding)),
+			ClassName: name,
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("%s severity %s", row.Severity, row.Finding.SecretType),
+				Type:    row.Severity,
+				Body:    body.String(),
+			},
+		})
+	}
+	// findings the par sab filter ne hata diye: "no secrets found" pass dikhana jhooth hoga, isliye skipped
+	if len(rows) == 0 && len(resource.Findings) > 0 {
+		suite.Tests = 1
+		suite.Skipped = 1
+		suite.TestCases = []junitTestCase{{
+			Name:      "findings excluded by export filter",
+			ClassName: name,
+			Skipped:   &junitSkipped{Message: fmt.Sprintf("%d findings did not match the export filter", len(resource.Findings))},
+		}}
+	} else if len(rows) == 0 {
+		suite.Tests = 1
+		suite.TestCases = []junitTestCase{{Name: "no secrets found", ClassName: name}}
+	}
+
+	out, err := xml.MarshalIndent(suite, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(append(out, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *junitExporter) Close() error {
+	_, err := io.WriteString(e.w, "</testsuites>\n")
+	return err
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+func TestJUnitExporterResourceCases(t *testing.T) {
+	withFinding := models.SCANNED_RESOURCE{
+		Type: "models", ID: "org/repo",
+		Findings: []models.Finding{{SecretType: "Test Token", Secret: "tok_abcdef123456", FileName: ".env", Line: 1}},
+	}
+	clean := models.SCANNED_RESOURCE{Type: "models", ID: "org/clean", Findings: []models.Finding{}}
+
+	tests := []struct {
+		name     string
+		resource models.SCANNED_RESOURCE
+		filter   ExportFilter
+		want     []string
+		notWant  []string
+	}{
+		{"finding", withFinding, ExportFilter{}, []string{`failures="1"`, "<failure"}, []string{"no secrets found", "<skipped"}},
+		{"clean resource", clean, ExportFilter{}, []string{"no secrets found", `failures="0"`}, []string{"<skipped", "<failure"}},
+		{"all findings filtered", withFinding, ExportFilter{SecretTypes: []string{"Other Key"}}, []string{`skipped="1"`, "<skipped", "did not match the export filter"}, []string{"no secrets found", "<failure"}},
+	}
+	for _, tt := range tests {
+		columns, err := ParseExportColumns("", SecretDisplayRedacted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		exporter, err := newJUnitExporter(&out, columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scan := models.SCAN_RESULT{}
+		rows := ExportRows(scan, tt.resource, tt.filter, SecretDisplayRedacted)
+		if err := exporter.WriteResource(scan, tt.resource, rows); err != nil {
+			t.Fatal(err)
+		}
+		exporter.Close()
+
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: output missing %q:\n%s", tt.name, want, out.String())
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(out.String(), notWant) {
+				t.Errorf("%s: output has %q:\n%s", tt.name, notWant, out.String())
+			}
+		}
+	}
+}
+
+func TestAttachmentDisposition(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"scan-report-abc.html", `attachment; filename="scan-report-abc.html"; filename*=UTF-8''scan-report-abc.html`},
+		{"a\"b\r\nSet-Cookie: x;y.md", `attachment; filename="a_b__Set-Cookie__x_y.md"; filename*=UTF-8''a%22b%0D%0ASet-Cookie%3A%20x%3By.md`},
+		{"rapport-é.md", `attachment; filename="rapport-_.md"; filename*=UTF-8''rapport-%C3%A9.md`},
+	}
+	for _, tt := range tests {
+		if got := AttachmentDisposition(tt.name); got != tt.want {
+			t.Errorf("AttachmentDisposition(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// ek resource fetch karte waqt kya kya saath me lana hai
+// (discussions, PRs, PR diffs, commit history), sab ek jagah taaki har function me naya bool na jodna pade
+type FetchOptions struct {
+	IncludePRs         bool
+	IncludeDiscussions bool
+	DiscussionFilter   DiscussionFilter
+	ScanPRDiffs        bool
+	ScanHistory        bool
+	// pichle scan ko ignore karke poora repo dobara fetch + scan
+	FullRescan bool
+	// scheduler isi key pe fairness karta hai (scan id / job id); khaali ho to url se repo
+	ScanKey string
+	// kai repos me share hone wala download budget (discovery); nil matlab koi limit nahi
+	ByteBudget *ByteBudget
+	// branch / tag / commit (git forges); khaali matlab default branch
+	Ref string
+	// local source: staged / modified files hi (khaali = poori directory)
+	GitScope string
+}
+
+// scan request body se options banata hai, filter galat ho to error
+func FetchOptionsFromRequest(req models.ScanRequestBody) (FetchOptions, error) {
+	filter, err := ParseDiscussionFilter(req.DiscussionStatus, req.DiscussionsSince)
+	if err != nil {
+		return FetchOptions{}, err
+	}
+	switch req.GitScope {
+	case "", GitScopeStaged, GitScopeModified:
+	default:
+		return FetchOptions{}, fmt.Errorf("invalid git_scope %q: use %s or %s", req.GitScope, GitScopeStaged, GitScopeModified)
+	}
+	return FetchOptions{
+		IncludePRs:         req.IncludePRs,
+		IncludeDiscussions: req.IncludeDiscussions,
+		DiscussionFilter:   filter,
+		ScanPRDiffs:        req.ScanPRDiffs,
+		ScanHistory:        req.ScanHistory,
+		FullRescan:         req.FullRescan,
+		Ref:                req.Ref,
+		GitScope:           req.GitScope,
+	}, nil
+}
@@ -0,0 +1,257 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+type FetchAction string
+
+const (
+	FetchFull   FetchAction = "full"
+	FetchSample FetchAction = "sample"
+	FetchStream FetchAction = "stream"
+	FetchSkip   FetchAction = "skip"
+)
+
+// skip reasons jo coverage report me jaate hai
+const (
+	SkipReasonExtension  = "extension"
+	SkipReasonSize       = "size"
+	SkipReasonLFS        = "lfs"
+	SkipReasonLFSPointer = "lfs_pointer"
+	SkipReasonBinary     = "binary"
+	// content download nahi hua tha (blob cache hit) aur ab cache me outcome nahi hai
+	SkipReasonCacheMiss = "cache_miss"
+	// job ka byte budget (discovery max_bytes) khatam ho gaya tha
+	SkipReasonBudget = "budget"
+)
+
+// kai repos / files me share hone wala download budget; nil matlab koi limit nahi
+type ByteBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+func NewByteBudget(limit int64) *ByteBudget {
+	return &ByteBudget{limit: limit}
+}
+
+// file download karne se pehle uske expected bytes le leta hai; jagah na ho to false
+func (b *ByteBudget) Reserve(n int64) bool {
+	if b == nil || b.limit <= 0 {
+		return true
+	}
+	for {
+		used := b.used.Load()
+		if used >= b.limit || (n > 0 && used+n > b.limit) {
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+n) {
+			return true
+		}
+	}
+}
+
+// download ke baad asli bytes se reservation theek karta hai (size pata nahi tha / sample hi aaya)
+func (b *ByteBudget) Adjust(delta int64) {
+	if b == nil {
+		return
+	}
+	b.used.Add(delta)
+}
+
+func (b *ByteBudget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.used.Load()
+}
+
+func (b *ByteBudget) Exhausted() bool {
+	return b != nil && b.limit > 0 && b.used.Load() >= b.limit
+}
+
+// policy ke hisaab se file ke kitne bytes aayenge
+func (p FetchPolicy) ExpectedBytes(file models.SIBLING, action FetchAction) int64 {
+	switch action {
+	case FetchSample:
+		return min(file.Size, p.SampleBytes)
+	case FetchStream:
+		return min(file.Size, p.MaxStreamBytes)
+	default:
+		return min(file.Size, p.MaxFullBytes)
+	}
+}
+
+// badi aur LFS files ke saath kya karna hai:
+// MaxFullBytes tak poori file memory me, usse badi LargeFileAction ke hisaab se,
+// LFS tracked files LFSAction ke hisaab se (weights / data ko by default chhod dete hai)
+type FetchPolicy struct {
+	MaxFullBytes    int64
+	SampleBytes     int64
+	MaxStreamBytes  int64
+	LargeFileAction FetchAction
+	LFSAction       FetchAction
+}
+
+func DefaultFetchPolicy() FetchPolicy {
+	return FetchPolicy{
+		MaxFullBytes:    envInt64("FETCH_MAX_FILE_BYTES", 10<<20),
+		SampleBytes:     envInt64("FETCH_SAMPLE_BYTES", 1<<20),
+		MaxStreamBytes:  envInt64("FETCH_MAX_STREAM_BYTES", 512<<20),
+		LargeFileAction: envFetchAction("FETCH_LARGE_FILE_POLICY", FetchSample),
+		LFSAction:       envFetchAction("FETCH_LFS_POLICY", FetchSkip),
+	}
+}
+
+// sibling ke size / lfs metadata se decide karta hai, skip ho to reason bhi
+func (p FetchPolicy) Decide(file models.SIBLING) (FetchAction, string) {
+	action := FetchFull
+	reason := SkipReasonSize
+	if file.LFSSha256 != "" {
+		action = p.LFSAction
+		reason = SkipReasonLFS
+	} else if file.Size > p.MaxFullBytes {
+		action = p.LargeFileAction
+	}
+
+	// itni badi file stream bhi nahi karni
+	if action == FetchStream && file.Size > p.MaxStreamBytes {
+		action = FetchSkip
+	}
+	// chhoti LFS file poori padh lo, sample / stream ka koi fayda nahi
+	if (action == FetchSample || action == FetchStream) && file.Size > 0 && file.Size <= p.MaxFullBytes {
+		action = FetchFull
+	}
+	if action == FetchSkip {
+		return action, reason
+	}
+	return action, ""
+}
+
+// body ko policy ke hisaab se padhta hai; content aur truncated flag deta hai
+func (p FetchPolicy) ReadBody(body io.Reader, action FetchAction) (string, bool, error) {
+	switch action {
+	case FetchSample:
+		return readLimited(body, p.SampleBytes)
+	case FetchStream:
+		return streamCandidateLines(body, p.MaxStreamBytes)
+	default:
+		return readLimited(body, p.MaxFullBytes)
+	}
+}
+
+// limit se zyada data ho to aakhri adhoori line hata ke truncated = true
+func readLimited(body io.Reader, limit int64) (string, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return "", false, err
+	}
+	if int64(len(data)) <= limit {
+		return string(data), false, nil
+	}
+	data = data[:limit]
+	if idx := bytes.LastIndexByte(data, '\n'); idx >= 0 {
+		data = data[:idx+1]
+	}
+	return string(data), true, nil
+}
+
+// puri file memory me rakhe bina line by line padhta hai,
+// sirf wahi lines rakhta hai jo kisi rule se match ho sakti hai, baaki khaali
+// (line numbers same rehte hai taaki findings sahi line pe point kare)
+func streamCandidateLines(body io.Reader, maxBytes int64) (string, bool, error) {
+	// chalu rule set ke regexes load pe compile ho chuke hai
+	patterns := patternRegexps(ActiveRuleSet().Patterns)
+	limited := io.LimitReader(body, maxBytes+1)
+	scanner := bufio.NewScanner(limited)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+
+	var out strings.Builder
+	var read int64
+	truncated := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		read += int64(len(line)) + 1
+		if read > maxBytes {
+			truncated = true
+			break
+		}
+		for _, re := range patterns {
+			if re.MatchString(line) {
+				out.WriteString(line)
+				break
+			}
+		}
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return out.String(), true, nil
+		}
+		return "", false, err
+	}
+	return out.String(), truncated, nil
+}
+
+// git-lfs pointer file:
+// version https://git-lfs.github.com/spec/v1
+// oid sha256:<hex>
+// size <bytes>
+func ParseLFSPointer(content string) (string, int64, bool) {
+	if len(content) > 1024 || !strings.HasPrefix(content, "version https://git-lfs.github.com/spec/") {
+		return "", 0, false
+	}
+	var oid string
+	var size int64 = -1
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "oid sha256:") {
+			oid = strings.TrimPrefix(line, "oid sha256:")
+		} else if strings.HasPrefix(line, "size ") {
+			if n, err := strconv.ParseInt(strings.TrimPrefix(line, "size "), 10, 64); err == nil {
+				size = n
+			}
+		}
+	}
+	if oid == "" || size < 0 {
+		return "", 0, false
+	}
+	return oid, size, true
+}
+
+// itne bytes dekh ke binary / text decide hota hai
+const binarySniffBytes = 8000
+
+// pehle 8KB me NUL byte ho to binary maan lete hai (git bhi yahi karta hai)
+func LooksBinary(content string) bool {
+	head := content
+	if len(head) > binarySniffBytes {
+		head = head[:binarySniffBytes]
+	}
+	return strings.IndexByte(head, 0) >= 0
+}
+
+func envInt64(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(GetEnv(key, fmt.Sprint(fallback)), 10, 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func envFetchAction(key string, fallback FetchAction) FetchAction {
+	switch action := FetchAction(strings.ToLower(GetEnv(key, string(fallback)))); action {
+	case FetchSkip, FetchSample, FetchStream, FetchFull:
+		return action
+	}
+	return fallback
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+func TestFetchPolicyDecide(t *testing.T) {
+	policy := FetchPolicy{MaxFullBytes: 100, SampleBytes: 10, MaxStreamBytes: 1000, LargeFileAction: FetchSample, LFSAction: FetchSkip}
+	tests := []struct {
+		name   string
+		policy func(FetchPolicy) FetchPolicy
+		file   models.SIBLING
+		action FetchAction
+		reason string
+	}{
+		{name: "small file", file: models.SIBLING{Size: 100}, action: FetchFull},
+		// size pata nahi to poori file, ReadBody MaxFullBytes pe rok deta hai
+		{name: "unknown size", file: models.SIBLING{}, action: FetchFull},
+		{name: "large file sampled", file: models.SIBLING{Size: 101}, action: FetchSample},
+		{name: "large file streamed", policy: func(p FetchPolicy) FetchPolicy { p.LargeFileAction = FetchStream; return p },
+			file: models.SIBLING{Size: 1000}, action: FetchStream},
+		{name: "too large to stream", policy: func(p FetchPolicy) FetchPolicy { p.LargeFileAction = FetchStream; return p },
+			file: models.SIBLING{Size: 1001}, action: FetchSkip, reason: SkipReasonSize},
+		{name: "large file skipped", policy: func(p FetchPolicy) FetchPolicy { p.LargeFileAction = FetchSkip; return p },
+			file: models.SIBLING{Size: 101}, action: FetchSkip, reason: SkipReasonSize},
+		{name: "lfs skipped", file: models.SIBLING{Size: 50, LFSSha256: "ab"}, action: FetchSkip, reason: SkipReasonLFS},
+		// chhoti LFS file sample / stream policy me bhi poori aati hai
+		{name: "small lfs sampled in full", policy: func(p FetchPolicy) FetchPolicy { p.LFSAction = FetchSample; return p },
+			file: models.SIBLING{Size: 50, LFSSha256: "ab"}, action: FetchFull},
+		{name: "large lfs sampled", policy: func(p FetchPolicy) FetchPolicy { p.LFSAction = FetchSample; return p },
+			file: models.SIBLING{Size: 5000, LFSSha256: "ab"}, action: FetchSample},
+		{name: "large lfs too big to stream", policy: func(p FetchPolicy) FetchPolicy { p.LFSAction = FetchStream; return p },
+			file: models.SIBLING{Size: 5000, LFSSha256: "ab"}, action: FetchSkip, reason: SkipReasonLFS},
+	}
+	for _, tt := range tests {
+		p := policy
+		if tt.policy != nil {
+			p = tt.policy(p)
+		}
+		action, reason := p.Decide(tt.file)
+		if action != tt.action || reason != tt.reason {
+			t.Errorf("%s: Decide = %s %q, want %s %q", tt.name, action, reason, tt.action, tt.reason)
+		}
+	}
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	oid := strings.Repeat("4d7a", 16)
+	tests := []struct {
+		name    string
+		content string
+		oid     string
+		size    int64
+		ok      bool
+	}{
+		{"pointer", "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n", oid, 12345, true},
+		{"crlf and empty file", "version https://git-lfs.github.com/spec/v1\r\noid sha256:" + oid + "\r\nsize 0\r\n", oid, 0, true},
+		{"missing size", "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\n", "", 0, false},
+		{"bad size", "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize -1\n", "", 0, false},
+		{"missing oid", "version https://git-lfs.github.com/spec/v1\nsize 10\n", "", 0, false},
+		{"not a pointer", "TOKEN=tok_abcdef123456\nversion https://git-lfs.github.com/spec/v1\n", "", 0, false},
+		// pointer 1KB se bada nahi hota, itni badi file asli content hai
+		{"too long", "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 10\n" + strings.Repeat("x", 1024), "", 0, false},
+	}
+	for _, tt := range tests {
+		oid, size, ok := ParseLFSPointer(tt.content)
+		if oid != tt.oid || size != tt.size || ok != tt.ok {
+			t.Errorf("%s: ParseLFSPointer = %q %d %t, want %q %d %t", tt.name, oid, size, ok, tt.oid, tt.size, tt.ok)
+		}
+	}
+}
+
+func TestByteBudgetReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int64
+		reserves []int64
+		want     []bool
+	}{
+		{"no limit", 0, []int64{1 << 40, 1}, []bool{true, true}},
+		{"fits exactly", 10, []int64{4, 6, 1}, []bool{true, true, false}},
+		{"too big for what is left", 10, []int64{8, 4, 2}, []bool{true, false, true}},
+		// size pata nahi (0) tab tak chalega jab tak budget bacha hai
+		{"unknown size", 10, []int64{0, 10, 0}, []bool{true, true, false}},
+	}
+	for _, tt := range tests {
+		budget := NewByteBudget(tt.limit)
+		for i, n := range tt.reserves {
+			if got := budget.Reserve(n); got != tt.want[i] {
+				t.Errorf("%s: reserve #%d (%d) = %v, want %v", tt.name, i, n, got, tt.want[i])
+			}
+		}
+	}
+
+	var none *ByteBudget
+	if !none.Reserve(1<<40) || none.Exhausted() {
+		t.Error("nil budget should never limit")
+	}
+}
+
+func TestFetchSiblingFilesStopsAtByteBudget(t *testing.T) {
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("x", 40))
+	}))
+	defer hub.Close()
+	t.Setenv("HF_BASE_URL", hub.URL)
+
+	siblings := []interface{}{}
+	for _, name := range []string{"a.json", "b.json", "c.json", "d.json"} {
+		siblings = append(siblings, map[string]interface{}{"rfilename": name, "size": float64(40)})
+	}
+	budget := NewByteBudget(100)
+	fetched, skipped, failed := FetchSiblingFiles("models", "org/repo", siblings, "", budget)
+
+	if len(fetched) != 2 || len(skipped) != 2 || len(failed) != 0 {
+		t.Fatalf("fetched=%d skipped=%d failed=%d, want 2/2/0", len(fetched), len(skipped), len(failed))
+	}
+	for _, file := range skipped {
+		if file.Reason != SkipReasonBudget {
+			t.Errorf("%s skipped for %q, want %q", file.RFilename, file.Reason, SkipReasonBudget)
+		}
+	}
+	if budget.Used() != 80 {
+		t.Errorf("used = %d, want 80", budget.Used())
+	}
+}
@@ -1,6 +1,9 @@
 package util
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/MishraShardendu22/Scanner/models"
 )
 
@@ -21,6 +24,14 @@ func GroupFindingsByResource(findings []models.Finding) []models.SCANNED_RESOURC
 			resourceType = "discussion"
 			resourceID = finding.DiscussionTitle
 			resourceKey = "discussion:" + finding.DiscussionTitle
+		case "pr":
+			resourceType = "pr"
+			resourceID = fmt.Sprintf("#%d", finding.PRNum)
+			resourceKey = "pr:" + resourceID
+		case "history":
+			resourceType = "history"
+			resourceID = finding.FileName
+			resourceKey = "history:" + finding.FileName
 		}
 
 		if _, exists := resourceMap[resourceKey]; !exists {
@@ -74,9 +85,30 @@ func FormatFindings(findings []models.Finding) []map[string]interface{} {
 		case "file":
 			item["file"] = finding.FileName
 			item["line"] = finding.Line
+			if finding.Cell > 0 {
+				item["cell"] = finding.Cell
+				item["cell_line"] = finding.CellLine
+			}
 		case "discussion":
 			item["discussion"] = finding.DiscussionTitle
 			item["discussion_num"] = finding.DiscussionNum
+			if finding.CommentID != "" {
+				item["comment_id"] = finding.CommentID
+				item["comment_author"] = finding.CommentAuthor
+				item["comment_created_at"] = finding.CommentCreated
+				item["line"] = finding.Line
+			}
+		case "pr":
+			item["pr_num"] = finding.PRNum
+			item["commit"] = finding.CommitSHA
+			item["file"] = finding.FileName
+			item["line"] = finding.Line
+		case "history":
+			item["file"] = finding.FileName
+			item["line"] = finding.Line
+			item["first_commit"] = finding.FirstCommit
+			item["last_commit"] = finding.LastCommit
+			item["still_at_head"] = finding.StillAtHead
 		}
 		formatted = append(formatted, item)
 	}
@@ -91,3 +123,39 @@ func CountTotalFindings(scannedResources []models.SCANNED_RESOURCE) int {
 	}
 	return total
 }
+
+// org scans me multiple resources hote hai, to files ko resource ke hisaab se group karte hai
+// warna do models ki config.json ek hi bucket me chali jaati
+func GroupFindingsByResourceID(findings []models.Finding) []models.SCANNED_RESOURCE {
+	resourceMap := make(map[string]*models.SCANNED_RESOURCE)
+	var order []string
+
+	for _, finding := range findings {
+		resourceKey := finding.Provider + ":" + finding.ResourceType + ":" + finding.ResourceID
+		if _, exists := resourceMap[resourceKey]; !exists {
+			resourceMap[resourceKey] = &models.SCANNED_RESOURCE{
+				Provider: finding.Provider,
+				Type:     finding.ResourceType,
+				ID:       finding.ResourceID,
+				Findings: []models.Finding{},
+			}
+			order = append(order, resourceKey)
+		}
+		resourceMap[resourceKey].Findings = append(resourceMap[resourceKey].Findings, finding)
+	}
+
+	scannedResources := []models.SCANNED_RESOURCE{}
+	for _, key := range order {
+		scannedResources = append(scannedResources, *resourceMap[key])
+	}
+
+	return scannedResources
+}
+
+// reports / exports me secret ke sirf pehle 4 characters, baaki mask
+func RedactSecret(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", 8)
+}
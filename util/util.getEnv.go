@@ -1,9 +1,25 @@
 package util
 
-import "os"
+import (
+	"os"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+var dotEnvOnce sync.Once
+
+// .env sirf ek baar, pehli GetEnv se hi; package level vars (shared http client) main ke init se pehle bante hai.
+// pehle se set env vars override nahi hote
+func LoadDotEnv() {
+	dotEnvOnce.Do(func() {
+		_ = godotenv.Load()
+	})
+}
 
 func GetEnv(key, fallback string) string {
 
+	LoadDotEnv()
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
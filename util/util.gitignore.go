@@ -0,0 +1,136 @@
+package util
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// .gitignore ki ek line; base us .gitignore ki directory hai (root ke relative, "/" separators)
+type ignoreRule struct {
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// directory walk ke saath bhare jaane wale rules; baad wala matching rule jeet-ta hai jaise git me
+type GitIgnore struct {
+	rules []ignoreRule
+}
+
+// dir ki .gitignore (ho to) padh ke uske rules jod deta hai
+func (g *GitIgnore) LoadFile(base, file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	g.Add(base, string(data))
+}
+
+func (g *GitIgnore) Add(base, content string) {
+	for _, line := range strings.Split(content, "\n") {
+		if rule, ok := parseIgnoreLine(base, line); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+}
+
+// rel root ke relative path hai; ignored dir ke andar ki files walk me pehle hi chhoot jaati hai
+func (g *GitIgnore) Ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			sub = rel[len(rule.base)+1:]
+		}
+		if rule.re.MatchString(sub) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func parseIgnoreLine(base, line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// trailing spaces tabhi rehte hai jab "\ " se escape kiye ho
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// beech me "/" ho to pattern .gitignore wali dir se anchored hai, warna kisi bhi depth pe naam match
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expr := globToRegex(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// gitignore glob -> regex: "**/" koi bhi dirs, aakhri "**" sab kuch, "*" aur "?" ek path segment ke andar
+func globToRegex(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "**":
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
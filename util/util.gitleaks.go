@@ -0,0 +1,274 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/MishraShardendu22/Scanner/models"
+	"gopkg.in/yaml.v3"
+)
+
+// gitleaks config (.gitleaks.toml) ki shakal; YAML file me bhi yahi keys
+type gitleaksConfig struct {
+	Title  string `toml:"title" yaml:"title"`
+	Extend struct {
+		UseDefault bool   `toml:"useDefault" yaml:"useDefault"`
+		Path       string `toml:"path" yaml:"path"`
+		URL        string `toml:"url" yaml:"url"`
+	} `toml:"extend" yaml:"extend"`
+	Rules []gitleaksRule `toml:"rules" yaml:"rules"`
+	// purana format ek global [allowlist], naya [[allowlists]] (targetRules ke saath)
+	Allowlist  *gitleaksAllowlist  `toml:"allowlist" yaml:"allowlist"`
+	Allowlists []gitleaksAllowlist `toml:"allowlists" yaml:"allowlists"`
+}
+
+type gitleaksRule struct {
+	ID          string              `toml:"id" yaml:"id"`
+	Description string              `toml:"description" yaml:"description"`
+	Regex       string              `toml:"regex" yaml:"regex"`
+	SecretGroup int                 `toml:"secretGroup" yaml:"secretGroup"`
+	Entropy     float64             `toml:"entropy" yaml:"entropy"`
+	Keywords    []string            `toml:"keywords" yaml:"keywords"`
+	Path        string              `toml:"path" yaml:"path"`
+	Tags        []string            `toml:"tags" yaml:"tags"`
+	Allowlist   *gitleaksAllowlist  `toml:"allowlist" yaml:"allowlist"`
+	Allowlists  []gitleaksAllowlist `toml:"allowlists" yaml:"allowlists"`
+}
+
+type gitleaksAllowlist struct {
+	Description string   `toml:"description" yaml:"description"`
+	Condition   string   `toml:"condition" yaml:"condition"`
+	Commits     []string `toml:"commits" yaml:"commits"`
+	Paths       []string `toml:"paths" yaml:"paths"`
+	Regexes     []string `toml:"regexes" yaml:"regexes"`
+	RegexTarget string   `toml:"regexTarget" yaml:"regexTarget"`
+	StopWords   []string `toml:"stopwords" yaml:"stopwords"`
+	// global allowlist sirf in rule ids pe; khaali to sab pe
+	TargetRules []string `toml:"targetRules" yaml:"targetRules"`
+}
+
+func (a gitleaksAllowlist) rule() models.RULE_ALLOWLIST {
+	return models.RULE_ALLOWLIST{
+		Description: a.Description,
+		Condition:   strings.ToUpper(a.Condition),
+		Commits:     a.Commits,
+		Paths:       a.Paths,
+		Regexes:     a.Regexes,
+		RegexTarget: a.RegexTarget,
+		StopWords:   a.StopWords,
+	}
+}
+
+// startup pe load hui rules file ke rules; BuiltinRules inhe SecretConfig pe merge karta hai
+var (
+	rulesFileRules []models.RULE
+	// file content ka hash, saved revision me yahi dekh ke pata chalta hai ki file merge ho chuki
+	rulesFileHash string
+)
+
+// "toml" / "yaml"; extension se pata na chale to khaali
+func RulesFileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return "toml"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return ""
+}
+
+// gitleaks TOML / YAML config -> RULE list. Gitleaks ke jo rule ids hamare secret type se map hote hai
+// wo built-in rule replace karte hai, baaki apne id ke naam se naye rules. Jo cheez map nahi hoti wo warnings me.
+func ParseGitleaksRules(data []byte, format, source string) ([]models.RULE, []string, error) {
+	var config gitleaksConfig
+	switch format {
+	case "toml":
+		if _, err := toml.Decode(string(data), &config); err != nil {
+			return nil, nil, fmt.Errorf("invalid gitleaks config: %v", err)
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, nil, fmt.Errorf("invalid gitleaks config: %v", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported rules format %q: use toml or yaml", format)
+	}
+
+	// [extend] follow nahi hota: gitleaks ke default rules yahan bundled nahi, aur doosri files / urls padhte nahi
+	var warnings []string
+	if config.Extend.UseDefault {
+		warnings = append(warnings, "extend.useDefault is ignored: gitleaks default rules are not bundled; rules are merged with the built-in rules instead")
+	}
+	if config.Extend.Path != "" {
+		warnings = append(warnings, fmt.Sprintf("extend.path %q is not followed; rules from that file are not loaded", config.Extend.Path))
+	}
+	if config.Extend.URL != "" {
+		warnings = append(warnings, fmt.Sprintf("extend.url %q is not followed; rules from that url are not loaded", config.Extend.URL))
+	}
+	globals := append([]gitleaksAllowlist(nil), config.Allowlists...)
+	if config.Allowlist != nil {
+		globals = append(globals, *config.Allowlist)
+	}
+
+	rules := []models.RULE{}
+	seen := map[string]bool{}
+	for i, entry := range config.Rules {
+		if entry.ID == "" {
+			return nil, warnings, fmt.Errorf("rules[%d]: id is required", i)
+		}
+		// sirf path wale rules (file hi secret hai) hamare line scanner me fit nahi hote
+		if entry.Regex == "" {
+			warnings = append(warnings, fmt.Sprintf("rule %q skipped: rules without a regex are not supported", entry.ID))
+			continue
+		}
+		name := entry.ID
+		if mapped, ok := gitleaksSecretTypes[entry.ID]; ok {
+			name = mapped
+		}
+		rule := models.RULE{
+			ID:          SARIFRuleID(name),
+			Name:        name,
+			Description: entry.Description,
+			Regex:       entry.Regex,
+			Keywords:    trimmedKeywords(entry.Keywords),
+			Tags:        entry.Tags,
+			Enabled:     true,
+			SecretGroup: entry.SecretGroup,
+			Entropy:     entry.Entropy,
+			Path:        entry.Path,
+			Source:      source,
+		}
+		if entry.Allowlist != nil {
+			rule.Allowlists = append(rule.Allowlists, entry.Allowlist.rule())
+		}
+		for _, allowlist := range entry.Allowlists {
+			rule.Allowlists = append(rule.Allowlists, allowlist.rule())
+		}
+		for _, allowlist := range globals {
+			if len(allowlist.TargetRules) == 0 || containsString(allowlist.TargetRules, entry.ID) {
+				rule.Allowlists = append(rule.Allowlists, allowlist.rule())
+			}
+		}
+		if seen[rule.ID] {
+			return nil, warnings, fmt.Errorf("rule %q: duplicate id", entry.ID)
+		}
+		seen[rule.ID] = true
+		if err := ValidateRule(rule); err != nil {
+			return nil, warnings, fmt.Errorf("rule %q: %w", entry.ID, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, warnings, nil
+}
+
+func trimmedKeywords(keywords []string) []string {
+	var out []string
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			out = append(out, keyword)
+		}
+	}
+	return out
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RULES_FILE ke rules built-in rules me; BuiltinRuleSet pehli baar banne se pehle hi chalna chahiye
+func LoadRulesFile(path string) error {
+	format := RulesFileFormat(path)
+	if format == "" {
+		return fmt.Errorf("rules file %s: extension must be .toml, .yaml or .yml", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rules, warnings, err := ParseGitleaksRules(data, format, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("rules file %s: %w", path, err)
+	}
+	for _, warning := range warnings {
+		log.Printf("op=LoadRulesFile stage=warning path=%s warning=%q", path, warning)
+	}
+	rulesFileRules = rules
+	rulesFileHash = sha256Hex(string(data))
+	log.Printf("op=LoadRulesFile stage=loaded path=%s format=%s rules=%d", path, format, len(rules))
+	return nil
+}
+
+var errRulesFileUnchanged = errors.New("rules file already applied")
+
+// load hui rules file ki state jaisi merge hoti hai; file na ho to nil
+func loadedRulesFileState() *models.RULES_FILE_STATE {
+	if len(rulesFileRules) == 0 {
+		return nil
+	}
+	state := &models.RULES_FILE_STATE{Source: rulesFileRules[0].Source, Hash: rulesFileHash}
+	for _, rule := range rulesFileRules {
+		state.Rules = append(state.Rules, models.RULES_FILE_RULE{ID: rule.ID, Hash: ruleHash(rule)})
+	}
+	return state
+}
+
+func ruleHash(rule models.RULE) string {
+	raw, _ := json.Marshal(rule)
+	return sha256Hex(string(raw))
+}
+
+// saved revision built-in rules se upar hai, isliye RULES_FILE ke rules latest revision me merge karke nayi revision.
+// Ek file content ek hi baar merge hota hai (hash revision pe rehta hai), taaki har restart pe revision na bane.
+// File badli ho to pichhli file ka jo rule API ne badla, disable ya delete kiya wo waisa hi rehta hai
+func MergeRulesFileIntoSaved() (*models.RULE_SET, error) {
+	state := loadedRulesFileState()
+	if state == nil {
+		return nil, nil
+	}
+	saved, err := saveRuleSet("rules file "+state.Source, func(latest *models.RULE_SET, rules []models.RULE) ([]models.RULE, *models.RULES_FILE_STATE, error) {
+		if latest == nil {
+			// koi saved revision nahi: built-in rules me file pehle se hai
+			return nil, nil, errRulesFileUnchanged
+		}
+		previous := map[string]string{}
+		if latest.RulesFile != nil {
+			if latest.RulesFile.Hash == state.Hash {
+				return nil, nil, errRulesFileUnchanged
+			}
+			for _, rule := range latest.RulesFile.Rules {
+				previous[rule.ID] = rule.Hash
+			}
+		}
+		current := map[string]models.RULE{}
+		for _, rule := range rules {
+			current[rule.ID] = rule
+		}
+
+		var apply []models.RULE
+		for _, rule := range rulesFileRules {
+			appliedHash, fromFile := previous[rule.ID]
+			saved, exists := current[rule.ID]
+			if fromFile && (!exists || ruleHash(saved) != appliedHash) {
+				log.Printf("op=MergeRulesFileIntoSaved stage=kept_api_change rule=%s deleted=%t", rule.ID, !exists)
+				continue
+			}
+			apply = append(apply, rule)
+		}
+		return MergeRules(rules, apply), state, nil
+	})
+	if errors.Is(err, errRulesFileUnchanged) {
+		return nil, nil
+	}
+	return saved, err
+}
@@ -0,0 +1,287 @@
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	"github.com/google/uuid"
+)
+
+// local HF hub cache ka ek repo:
+// <root>/models--org--name/{refs/main, snapshots/<sha>/..., blobs/<hash>}
+type HubCacheRepo struct {
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	Dir          string `json:"dir"`
+}
+
+var (
+	gitSHAPattern    = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	sha256HexPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	// branch / tag naam: refs/ ke neeche ka relative path, ".." ya absolute path nahi
+	hubRefPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*$`)
+)
+
+// dir ke andar hi rehna chahiye, symlink / ".." se bahar nahi
+func pathWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+func validHubRef(revision string) bool {
+	if !hubRefPattern.MatchString(revision) {
+		return false
+	}
+	for _, part := range strings.Split(revision, "/") {
+		if part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// HF_HUB_CACHE > HF_HOME/hub > ~/.cache/huggingface/hub (huggingface_hub jaisa hi order)
+func HubCacheDir() string {
+	if dir := GetEnv("HF_HUB_CACHE", ""); dir != "" {
+		return dir
+	}
+	if home := GetEnv("HF_HOME", ""); home != "" {
+		return filepath.Join(home, "hub")
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".cache", "huggingface", "hub")
+	}
+	return filepath.Join(userHome, ".cache", "huggingface", "hub")
+}
+
+// "models--org--name" -> ("models", "org/name")
+func ParseHubCacheDirName(name string) (string, string, bool) {
+	parts := strings.Split(name, "--")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	var resourceType string
+	switch parts[0] {
+	case "models", "datasets", "spaces":
+		resourceType = parts[0]
+	default:
+		return "", "", false
+	}
+	return resourceType, strings.Join(parts[1:], "/"), true
+}
+
+// "org/name" -> "models--org--name"
+func HubCacheDirName(resourceType, resourceID string) string {
+	return resourceType + "--" + strings.ReplaceAll(resourceID, "/", "--")
+}
+
+func ListHubCacheRepos(root string) ([]HubCacheRepo, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	repos := []HubCacheRepo{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		resourceType, resourceID, ok := ParseHubCacheDirName(entry.Name())
+		if !ok {
+			continue
+		}
+		repos = append(repos, HubCacheRepo{
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Dir:          filepath.Join(root, entry.Name()),
+		})
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Dir < repos[j].Dir })
+	return repos, nil
+}
+
+func FindHubCacheRepo(root, resourceType, resourceID string) (HubCacheRepo, error) {
+	switch resourceType {
+	case "models", "datasets", "spaces":
+	default:
+		return HubCacheRepo{}, fmt.Errorf("invalid resource type %q", resourceType)
+	}
+	dir := filepath.Join(root, HubCacheDirName(resourceType, resourceID))
+	if !pathWithin(root, dir) || filepath.Dir(dir) != filepath.Clean(root) {
+		return HubCacheRepo{}, fmt.Errorf("invalid repo id %q", resourceID)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return HubCacheRepo{}, fmt.Errorf("%s %s not found in hub cache %s", resourceType, resourceID, root)
+	}
+	return HubCacheRepo{ResourceType: resourceType, ResourceID: resourceID, Dir: dir}, nil
+}
+
+// revision branch / tag (refs/<name>) ho sakta hai ya seedha commit sha; khaali matlab main
+func ResolveHubCacheRevision(repo HubCacheRepo, revision string) (string, error) {
+	if revision == "" {
+		revision = "main"
+	}
+	if gitSHAPattern.MatchString(revision) {
+		if _, err := os.Stat(filepath.Join(repo.Dir, "snapshots", revision)); err == nil {
+			return revision, nil
+		}
+	}
+	// revision request body se aata hai, isliye refs/ ke bahar ka path kabhi nahi padhna
+	if !validHubRef(revision) {
+		return "", fmt.Errorf("invalid revision %q", revision)
+	}
+	refsDir := filepath.Join(repo.Dir, "refs")
+	refPath := filepath.Join(refsDir, filepath.FromSlash(revision))
+	if !pathWithin(refsDir, refPath) {
+		return "", fmt.Errorf("invalid revision %q", revision)
+	}
+	data, err := os.ReadFile(refPath)
+	if err == nil {
+		// ref file ka content bhi bharosemand nahi, sirf 40 hex sha chalega (error me content nahi daalte)
+		sha := strings.TrimSpace(string(data))
+		if !gitSHAPattern.MatchString(sha) {
+			return "", fmt.Errorf("ref %q for %s does not contain a commit sha", revision, repo.ResourceID)
+		}
+		return sha, nil
+	}
+
+	// refs nahi hai to bas ek hi snapshot ho tab usi ko le lo
+	snapshots, _ := os.ReadDir(filepath.Join(repo.Dir, "snapshots"))
+	if revision == "main" && len(snapshots) == 1 && gitSHAPattern.MatchString(snapshots[0].Name()) {
+		return snapshots[0].Name(), nil
+	}
+	return "", fmt.Errorf("revision %q not found for %s", revision, repo.ResourceID)
+}
+
+// snapshot ki files ko AI_REQUEST me padhta hai, network bilkul nahi;
+// snapshot ki file blobs/<hash> ka symlink hoti hai, hash 40 hex ho to git blob id, 64 hex ho to LFS sha256
+func LoadHubCacheRequest(repo HubCacheRepo, revision string) (*models.AI_REQUEST, error) {
+	sha, err := ResolveHubCacheRevision(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	snapshotDir := filepath.Join(repo.Dir, "snapshots", sha)
+	if _, err := os.Stat(snapshotDir); err != nil {
+		return nil, fmt.Errorf("snapshot %s not found for %s", sha, repo.ResourceID)
+	}
+
+	aiRequest := &models.AI_REQUEST{
+		RequestID:    uuid.New().String(),
+		Provider:     ProviderHuggingFace,
+		ResourceType: repo.ResourceType,
+		ResourceID:   repo.ResourceID,
+		CommitSHA:    sha,
+		Siblings:     []models.SIBLING{},
+		Discussions:  []models.DISCUSSION{},
+		Skipped:      []models.SKIPPED_FILE{},
+		Failed:       []models.FAILED_ITEM{},
+	}
+	policy := DefaultFetchPolicy()
+
+	err = filepath.WalkDir(snapshotDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(snapshotDir, path)
+		filename := filepath.ToSlash(rel)
+
+		sibling := models.SIBLING{RFilename: filename}
+		if target, err := os.Readlink(path); err == nil {
+			hash := filepath.Base(target)
+			if sha256HexPattern.MatchString(hash) {
+				sibling.LFSSha256 = hash
+			} else if gitSHAPattern.MatchString(hash) {
+				sibling.BlobID = hash
+			}
+		}
+		// symlink follow karke asli blob ka size
+		info, err := os.Stat(path)
+		if err != nil {
+			aiRequest.Failed = append(aiRequest.Failed, models.FAILED_ITEM{Name: filename, Error: err.Error()})
+			return nil
+		}
+		sibling.Size = info.Size()
+
+		if !TextExtensions[strings.ToLower(filepath.Ext(filename))] {
+			aiRequest.Skipped = append(aiRequest.Skipped, models.SKIPPED_FILE{RFilename: filename, Reason: SkipReasonExtension, Size: sibling.Size})
+			return nil
+		}
+		action, reason := policy.Decide(sibling)
+		if action == FetchSkip {
+			aiRequest.Skipped = append(aiRequest.Skipped, models.SKIPPED_FILE{RFilename: filename, Reason: reason, Size: sibling.Size})
+			return nil
+		}
+
+		loaded, reason, err := ReadLocalFile(path, sibling, policy, action)
+		if err != nil {
+			aiRequest.Failed = append(aiRequest.Failed, models.FAILED_ITEM{Name: filename, Error: err.Error()})
+			return nil
+		}
+		if reason != "" {
+			aiRequest.Skipped = append(aiRequest.Skipped, models.SKIPPED_FILE{RFilename: filename, Reason: reason, Size: loaded.Size})
+			return nil
+		}
+		aiRequest.Siblings = append(aiRequest.Siblings, loaded)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf(
+		"op=LoadHubCacheRequest stage=success resource_type=%s resource_id=%s sha=%s files=%d skipped=%d failed=%d",
+		repo.ResourceType, repo.ResourceID, sha, len(aiRequest.Siblings), len(aiRequest.Skipped), len(aiRequest.Failed),
+	)
+	return aiRequest, nil
+}
+
+// disk se file policy ke hisaab se padhta hai, LFS pointer / binary ho to skip reason
+func ReadLocalFile(path string, file models.SIBLING, policy FetchPolicy, action FetchAction) (models.SIBLING, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return file, "", err
+	}
+	defer f.Close()
+
+	content, truncated, err := policy.ReadBody(f, action)
+	if err != nil {
+		return file, "", err
+	}
+	if oid, size, ok := ParseLFSPointer(content); ok {
+		file.LFSSha256 = oid
+		file.Size = size
+		return file, SkipReasonLFSPointer, nil
+	}
+	if LooksBinary(content) {
+		return file, SkipReasonBinary, nil
+	}
+	file.FileContent = content
+	file.FetchMode = string(action)
+	file.Truncated = truncated
+	return file, "", nil
+}
+
+// findings ke file urls ko main ki jagah scan hui revision pe pin karta hai
+func PinFindingsToRevision(findings []models.Finding, revision string) []models.Finding {
+	if revision == "" {
+		return findings
+	}
+	for i := range findings {
+		if findings[i].SourceType != "file" {
+			continue
+		}
+		findings[i].CommitSHA = revision
+		findings[i].URL = BuildHuggingFaceRevisionFileURL(findings[i].ResourceType, findings[i].ResourceID, revision, findings[i].FileName, findings[i].Line)
+	}
+	return findings
+}
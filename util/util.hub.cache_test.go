@@ -0,0 +1,80 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveHubCacheRevision(t *testing.T) {
+	root := t.TempDir()
+	sha := strings.Repeat("a", 40)
+	repoDir := filepath.Join(root, "models--org--name")
+	for _, dir := range []string{"refs/pr", "snapshots/" + sha} {
+		if err := os.MkdirAll(filepath.Join(repoDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(repoDir, "refs", "main"):    sha + "\n",
+		filepath.Join(repoDir, "refs", "pr", "1"): sha,
+		filepath.Join(repoDir, "refs", "bad"):     "../../../../etc",
+		filepath.Join(root, "secret"):             sha,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := HubCacheRepo{ResourceType: "models", ResourceID: "org/name", Dir: repoDir}
+
+	tests := []struct {
+		revision string
+		want     string
+		wantErr  bool
+	}{
+		{"", sha, false},
+		{"main", sha, false},
+		{"pr/1", sha, false},
+		{sha, sha, false},
+		{"../../secret", "", true},
+		{"/etc/passwd", "", true},
+		{"pr/../../../secret", "", true},
+		{"main\x00", "", true},
+		// ref file ka content sha nahi hai
+		{"bad", "", true},
+		{"missing", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveHubCacheRevision(repo, tt.revision)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveHubCacheRevision(%q) = %q, %v; want %q, err=%v", tt.revision, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && strings.Contains(err.Error(), "etc") && !strings.Contains(tt.revision, "etc") {
+			t.Errorf("ResolveHubCacheRevision(%q) leaked ref contents: %v", tt.revision, err)
+		}
+	}
+}
+
+func TestFindHubCacheRepoStaysInRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "models--org--name"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		resourceType string
+		resourceID   string
+		wantErr      bool
+	}{
+		{"models", "org/name", false},
+		{"../models", "org/name", true},
+		{"models", "missing/repo", true},
+	}
+	for _, tt := range tests {
+		_, err := FindHubCacheRepo(root, tt.resourceType, tt.resourceID)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FindHubCacheRepo(%q, %q) err = %v, want error %v", tt.resourceType, tt.resourceID, err, tt.wantErr)
+		}
+	}
+}
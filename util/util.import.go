@@ -0,0 +1,683 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// dusre scanners ke results jinka resource pata nahi (aur request me bhi nahi diya) unka provider
+const ProviderImport = "import"
+
+// import ke time resource override; khaali field ho to row se (trufflehog repository, apne SARIF ki properties)
+type ImportOptions struct {
+	Provider     string
+	ResourceType string
+	ResourceID   string
+}
+
+// jo row finding me map nahi hui; Row 1 se (array index / NDJSON line / SARIF result)
+type ImportRejected struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+type ImportResult struct {
+	Tool     string
+	Findings []models.Finding
+	Rejected []ImportRejected
+	Total    int
+}
+
+func (r *ImportResult) reject(row int, format string, args ...any) {
+	r.Rejected = append(r.Rejected, ImportRejected{Row: row, Reason: fmt.Sprintf(format, args...)})
+}
+
+type importer func(body []byte, opts ImportOptions) (ImportResult, error)
+
+var importFormats = map[string]importer{
+	"gitleaks":   importGitleaks,
+	"trufflehog": importTrufflehog,
+	"sarif":      importSARIF,
+}
+
+func ImportFormatNames() []string {
+	names := make([]string, 0, len(importFormats))
+	for name := range importFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// poore document ka shape galat ho to error; ek row galat ho to sirf wo Rejected me, baaki import hoti hai
+func ImportFindings(format string, body []byte, opts ImportOptions) (ImportResult, error) {
+	parse, ok := importFormats[format]
+	if !ok {
+		return ImportResult{}, fmt.Errorf("invalid format %q: use %s", format, strings.Join(ImportFormatNames(), ", "))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ImportResult{}, fmt.Errorf("empty %s report", format)
+	}
+	return parse(body, opts)
+}
+
+// gitleaks rule id -> hamara secret type, taaki severity aur dashboard grouping same rahe
+var gitleaksSecretTypes = map[string]string{
+	"aws-access-token":         "AWS Access Key ID",
+	"github-pat":               "GitHub PAT",
+	"github-oauth":             "GitHub Actions Token",
+	"gitlab-pat":               "GitLab PAT",
+	"huggingface-access-token": "Hugging Face API Key",
+	"openai-api-key":           "OpenAI / LLM API Key",
+	"anthropic-api-key":        "Anthropic API Key",
+	"gcp-api-key":              "Google API Key",
+	"stripe-access-token":      "Stripe Secret Key",
+	"slack-app-token":          "Slack App Token",
+	"slack-bot-token":          "Slack Bot Token",
+	"generic-api-key":          "API Key",
+}
+
+// trufflehog detector -> hamara secret type
+var trufflehogSecretTypes = map[string]string{
+	"AWS":         "AWS Access Key ID",
+	"Github":      "GitHub PAT",
+	"Gitlab":      "GitLab PAT",
+	"HuggingFace": "Hugging Face API Key",
+	"OpenAI":      "OpenAI / LLM API Key",
+	"Anthropic":   "Anthropic API Key",
+	"Stripe":      "Stripe Secret Key",
+	"Postgres":    "PostgreSQL URI",
+	"MongoDB":     "MongoDB URI",
+}
+
+// gitleaks --redact secret ko "REDACTED" kar deta hai
+const gitleaksRedacted = "REDACTED"
+
+type gitleaksFinding struct {
+	RuleID      string
+	Description string
+	StartLine   int
+	Match       string
+	Secret      string
+	File        string
+	Commit      string
+	Link        string
+	Fingerprint string
+}
+
+// gitleaks --report-format json: findings ka array (clean scan pe "[]")
+func importGitleaks(body []byte, opts ImportOptions) (ImportResult, error) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return ImportResult{}, fmt.Errorf("gitleaks report must be a JSON array of findings: %v", err)
+	}
+
+	result := ImportResult{Tool: "gitleaks", Total: len(rows)}
+	for i, raw := range rows {
+		row := i + 1
+		var leak gitleaksFinding
+		if err := json.Unmarshal(raw, &leak); err != nil {
+			result.reject(row, "invalid finding: %v", err)
+			continue
+		}
+		if leak.RuleID == "" {
+			result.reject(row, "missing RuleID")
+			continue
+		}
+		if leak.File == "" {
+			result.reject(row, "missing File")
+			continue
+		}
+		if leak.StartLine < 0 {
+			result.reject(row, "invalid StartLine %d", leak.StartLine)
+			continue
+		}
+		secret := leak.Secret
+		if secret == gitleaksRedacted {
+			secret = ""
+		}
+		if secret == "" && leak.Fingerprint == "" {
+			result.reject(row, "missing Secret and Fingerprint")
+			continue
+		}
+
+		finding := models.Finding{
+			SecretType: importSecretType(gitleaksSecretTypes, leak.RuleID, leak.Description),
+			Pattern:    "gitleaks:" + leak.RuleID,
+			Secret:     secret,
+			SourceType: "file",
+			URL:        leak.Link,
+			FileName:   leak.File,
+			Line:       leak.StartLine,
+			Tool:       result.Tool,
+		}
+		// git mode me har finding kisi commit ki hoti hai
+		if leak.Commit != "" {
+			finding.SourceType = "history"
+			finding.CommitSHA = leak.Commit
+			finding.FirstCommit = leak.Commit
+		}
+		if problem := applyImportResource(&finding, opts); problem != "" {
+			result.reject(row, "%s", problem)
+			continue
+		}
+		finding.Fingerprint = importFingerprint(finding, result.Tool, leak.Fingerprint)
+		result.Findings = append(result.Findings, finding)
+	}
+	return result, nil
+}
+
+type trufflehogFinding struct {
+	SourceMetadata *struct {
+		Data map[string]json.RawMessage
+	}
+	DetectorName string
+	Verified     bool
+	Raw          string
+	Redacted     string
+}
+
+// SourceMetadata.Data ke andar har source ka apna object; jo fields hum use karte hai
+type trufflehogLocation struct {
+	Commit       string `json:"commit"`
+	File         string `json:"file"`
+	Line         int64  `json:"line"`
+	Repository   string `json:"repository"`
+	Link         string `json:"link"`
+	Bucket       string `json:"bucket"`
+	Image        string `json:"image"`
+	Layer        string `json:"layer"`
+	ResourceType string `json:"resource_type"`
+}
+
+// trufflehog --json har finding ek line pe likhta hai (NDJSON); JSON array bhi chalega
+func importTrufflehog(body []byte, opts ImportOptions) (ImportResult, error) {
+	var rows []json.RawMessage
+	trimmed := bytes.TrimSpace(body)
+	if trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return ImportResult{}, fmt.Errorf("trufflehog report must be NDJSON or a JSON array of findings: %v", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(trimmed)+1)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			rows = append(rows, json.RawMessage(append([]byte(nil), line...)))
+		}
+		if err := scanner.Err(); err != nil {
+			return ImportResult{}, fmt.Errorf("trufflehog report: %v", err)
+		}
+	}
+
+	result := ImportResult{Tool: "trufflehog", Total: len(rows)}
+	for i, raw := range rows {
+		row := i + 1
+		var hog trufflehogFinding
+		if err := json.Unmarshal(raw, &hog); err != nil {
+			result.reject(row, "invalid finding: %v", err)
+			continue
+		}
+		if hog.DetectorName == "" {
+			result.reject(row, "missing DetectorName")
+			continue
+		}
+		if hog.Raw == "" && hog.Redacted == "" {
+			result.reject(row, "missing Raw")
+			continue
+		}
+		if hog.SourceMetadata == nil || len(hog.SourceMetadata.Data) != 1 {
+			result.reject(row, "SourceMetadata.Data must have exactly one source")
+			continue
+		}
+
+		var source string
+		var location trufflehogLocation
+		for name, data := range hog.SourceMetadata.Data {
+			source = name
+			if err := json.Unmarshal(data, &location); err != nil {
+				result.reject(row, "invalid %s source metadata: %v", name, err)
+				source = ""
+			}
+		}
+		if source == "" {
+			continue
+		}
+
+		finding := models.Finding{
+			SecretType: importSecretType(trufflehogSecretTypes, hog.DetectorName, ""),
+			Pattern:    "trufflehog:" + hog.DetectorName,
+			Secret:     hog.Raw,
+			SourceType: "file",
+			URL:        location.Link,
+			FileName:   location.File,
+			Line:       int(location.Line),
+			Tool:       result.Tool,
+		}
+		// verify hua matlab key abhi bhi chalti hai
+		if hog.Verified {
+			finding.Severity = SeverityHigh
+		}
+
+		switch source {
+		case "Git", "Github", "Gitlab", "Huggingface":
+			if location.Commit != "" {
+				finding.SourceType = "history"
+				finding.CommitSHA = location.Commit
+				finding.FirstCommit = location.Commit
+			}
+			finding.Provider, finding.ResourceType, finding.ResourceID = repositoryResource(location.Repository, location.ResourceType)
+		case "Filesystem":
+		case "S3":
+			finding.Provider, finding.ResourceType, finding.ResourceID = ProviderS3, ResourceTypeBucket, location.Bucket
+		case "Docker":
+			finding.Provider, finding.ResourceType, finding.ResourceID = ProviderContainer, ResourceTypeImage, location.Image
+			finding.LayerDigest = location.Layer
+		default:
+			result.reject(row, "unsupported source %s", source)
+			continue
+		}
+		if finding.FileName == "" {
+			result.reject(row, "missing file in %s source metadata", source)
+			continue
+		}
+		if location.Line < 0 || location.Line > math.MaxInt32 {
+			result.reject(row, "invalid line %d", location.Line)
+			continue
+		}
+		if problem := applyImportResource(&finding, opts); problem != "" {
+			result.reject(row, "%s", problem)
+			continue
+		}
+		finding.Fingerprint = importFingerprint(finding, result.Tool, "")
+		result.Findings = append(result.Findings, finding)
+	}
+	return result, nil
+}
+
+// "https://github.com/owner/repo.git" -> github repos owner/repo; anjaan host ka poora url hi id
+func repositoryResource(repository, hubResourceType string) (string, string, string) {
+	if repository == "" {
+		return "", "", ""
+	}
+	parsed, err := url.Parse(repository)
+	if err != nil || parsed.Host == "" {
+		return ProviderImport, ResourceTypeRepo, repository
+	}
+	path := strings.TrimSuffix(strings.Trim(parsed.Path, "/"), ".git")
+	switch strings.ToLower(parsed.Host) {
+	case "github.com":
+		return "github", ResourceTypeRepo, path
+	case "gitlab.com":
+		return "gitlab", ResourceTypeRepo, path
+	case "huggingface.co":
+		resourceType := "models"
+		for _, prefix := range []string{"datasets", "spaces"} {
+			if strings.HasPrefix(path, prefix+"/") {
+				resourceType, path = prefix, strings.TrimPrefix(path, prefix+"/")
+			}
+		}
+		if hubResourceType != "" {
+			resourceType = strings.TrimSuffix(hubResourceType, "s") + "s"
+		}
+		return ProviderHuggingFace, resourceType, path
+	}
+	return ProviderImport, ResourceTypeRepo, strings.TrimSuffix(repository, ".git")
+}
+
+type sarifImportLog struct {
+	Version string            `json:"version"`
+	Runs    []json.RawMessage `json:"runs"`
+}
+
+type sarifImportRun struct {
+	Tool *struct {
+		Driver struct {
+			Name  string            `json:"name"`
+			Rules []sarifImportRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []json.RawMessage `json:"results"`
+}
+
+type sarifImportRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     SARIFMessage           `json:"shortDescription"`
+	DefaultConfiguration SARIFRuleConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]any         `json:"properties"`
+}
+
+type sarifImportResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Rule      *struct {
+		ID    string `json:"id"`
+		Index *int   `json:"index"`
+	} `json:"rule"`
+	Level               string            `json:"level"`
+	Locations           []SARIFLocation   `json:"locations"`
+	Fingerprints        map[string]string `json:"fingerprints"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties"`
+}
+
+// koi bhi SARIF 2.1.0 log (apna export bhi); har run ka driver tool hai, results rules se secret type aur severity lete hai
+func importSARIF(body []byte, opts ImportOptions) (ImportResult, error) {
+	var sarifLog sarifImportLog
+	if err := json.Unmarshal(body, &sarifLog); err != nil {
+		return ImportResult{}, fmt.Errorf("invalid SARIF log: %v", err)
+	}
+	if sarifLog.Version != SARIFVersion {
+		return ImportResult{}, fmt.Errorf("unsupported SARIF version %q: only %s is supported", sarifLog.Version, SARIFVersion)
+	}
+	if sarifLog.Runs == nil {
+		return ImportResult{}, fmt.Errorf("invalid SARIF log: missing runs")
+	}
+
+	runs := make([]sarifImportRun, len(sarifLog.Runs))
+	tools := []string{}
+	for i, raw := range sarifLog.Runs {
+		if err := json.Unmarshal(raw, &runs[i]); err != nil {
+			return ImportResult{}, fmt.Errorf("invalid SARIF run %d: %v", i+1, err)
+		}
+		if runs[i].Tool == nil || runs[i].Tool.Driver.Name == "" {
+			return ImportResult{}, fmt.Errorf("invalid SARIF run %d: missing tool.driver.name", i+1)
+		}
+		tools = append(tools, runs[i].Tool.Driver.Name)
+	}
+
+	result := ImportResult{Tool: strings.Join(tools, ", ")}
+	for r, run := range runs {
+		tool := run.Tool.Driver.Name
+		rules := map[string]sarifImportRule{}
+		for _, rule := range run.Tool.Driver.Rules {
+			rules[rule.ID] = rule
+		}
+
+		for _, raw := range run.Results {
+			result.Total++
+			row := result.Total
+			var sarif sarifImportResult
+			if err := json.Unmarshal(raw, &sarif); err != nil {
+				result.reject(row, "run %d: invalid result: %v", r+1, err)
+				continue
+			}
+
+			ruleID := sarif.RuleID
+			index := sarif.RuleIndex
+			if sarif.Rule != nil {
+				if ruleID == "" {
+					ruleID = sarif.Rule.ID
+				}
+				if index == nil {
+					index = sarif.Rule.Index
+				}
+			}
+			if ruleID == "" && index != nil && *index >= 0 && *index < len(run.Tool.Driver.Rules) {
+				ruleID = run.Tool.Driver.Rules[*index].ID
+			}
+			if ruleID == "" {
+				result.reject(row, "run %d: missing ruleId", r+1)
+				continue
+			}
+			rule, known := rules[ruleID]
+			if !known && len(run.Tool.Driver.Rules) > 0 {
+				result.reject(row, "run %d: rule %q not defined by %s", r+1, ruleID, tool)
+				continue
+			}
+
+			finding, problem := sarifFinding(sarif, rule, ruleID, tool)
+			if problem != "" {
+				result.reject(row, "run %d: %s", r+1, problem)
+				continue
+			}
+			if problem := applyImportResource(&finding, opts); problem != "" {
+				result.reject(row, "run %d: %s", r+1, problem)
+				continue
+			}
+			// apna export dobara import ho to wahi fingerprint, alert duplicate nahi banta
+			toolFingerprint := sarif.Fingerprints["scanner/v1"]
+			if toolFingerprint != "" {
+				finding.Fingerprint = toolFingerprint
+			} else {
+				finding.Fingerprint = importFingerprint(finding, tool, firstFingerprint(sarif.Fingerprints, sarif.PartialFingerprints))
+			}
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+	return result, nil
+}
+
+func sarifFinding(sarif sarifImportResult, rule sarifImportRule, ruleID, tool string) (models.Finding, string) {
+	if len(sarif.Locations) == 0 {
+		return models.Finding{}, "result has no location"
+	}
+	properties := sarif.Properties
+
+	secretType := rule.Name
+	if mapped, ok := gitleaksSecretTypes[ruleID]; ok {
+		secretType = mapped
+	}
+	if secretType == "" {
+		secretType = importSecretType(nil, ruleID, rule.ShortDescription.Text)
+	}
+	pattern, _ := rule.Properties["pattern"].(string)
+	if pattern == "" {
+		pattern = tool + ":" + ruleID
+	}
+
+	finding := models.Finding{
+		SecretType:   secretType,
+		Pattern:      pattern,
+		SourceType:   sarifString(properties, "source_type"),
+		Provider:     sarifString(properties, "provider"),
+		ResourceType: sarifString(properties, "resource_type"),
+		ResourceID:   sarifString(properties, "resource_id"),
+		URL:          sarifString(properties, "url"),
+		CommitSHA:    sarifString(properties, "commit_sha"),
+		VersionID:    sarifString(properties, "version_id"),
+		LayerDigest:  sarifString(properties, "layer_digest"),
+		FirstCommit:  sarifString(properties, "first_commit"),
+		LastCommit:   sarifString(properties, "last_commit"),
+		Severity:     sarifSeverity(sarif, rule),
+		Tool:         tool,
+	}
+	if stillAtHead, ok := properties["still_at_head"].(bool); ok {
+		finding.StillAtHead = &stillAtHead
+	}
+
+	location := sarif.Locations[0]
+	if physical := location.PhysicalLocation; physical != nil {
+		fileName, err := url.PathUnescape(physical.ArtifactLocation.URI)
+		if err != nil || fileName == "" {
+			return models.Finding{}, fmt.Sprintf("invalid artifact uri %q", physical.ArtifactLocation.URI)
+		}
+		finding.FileName = fileName
+		if region := physical.Region; region != nil {
+			if region.StartLine < 0 {
+				return models.Finding{}, fmt.Sprintf("invalid startLine %d", region.StartLine)
+			}
+			finding.Line = region.StartLine
+			finding.Cell = sarifInt(region.Properties, "cell")
+			finding.CellLine = sarifInt(region.Properties, "cell_line")
+		}
+		switch finding.SourceType {
+		case "file", "pr", "history":
+		case "":
+			finding.SourceType = "file"
+			if finding.CommitSHA != "" {
+				finding.SourceType = "history"
+			}
+		default:
+			return models.Finding{}, fmt.Sprintf("source_type %q does not match a file location", finding.SourceType)
+		}
+		return finding, ""
+	}
+
+	if len(location.LogicalLocations) == 0 {
+		return models.Finding{}, "result has no physical or logical location"
+	}
+	logical := location.LogicalLocations[0]
+	switch finding.SourceType {
+	case "discussion":
+		// apne export ka "discussion #N" / "pull request #N" (+ "/comment ID")
+		if _, err := fmt.Sscanf(logical.Name, "discussion #%d", &finding.DiscussionNum); err != nil {
+			if _, err := fmt.Sscanf(logical.Name, "pull request #%d", &finding.PRNum); err != nil {
+				return models.Finding{}, fmt.Sprintf("invalid discussion location %q", logical.Name)
+			}
+			finding.DiscussionNum = finding.PRNum
+		}
+		if _, comment, ok := strings.Cut(logical.FullyQualifiedName, "/comment "); ok {
+			finding.CommentID = comment
+		}
+	case "image_config", "image_history":
+		// "config.Env[3]"
+		finding.FileName = logical.Name
+		if _, index, ok := strings.Cut(strings.TrimSuffix(logical.FullyQualifiedName, "]"), "["); ok {
+			finding.Line, _ = strconv.Atoi(index)
+		}
+	case "":
+		finding.SourceType = "file"
+		finding.FileName = logical.FullyQualifiedName
+		if finding.FileName == "" {
+			finding.FileName = logical.Name
+		}
+		if finding.FileName == "" {
+			return models.Finding{}, "logical location has no name"
+		}
+	default:
+		return models.Finding{}, fmt.Sprintf("unsupported source_type %q", finding.SourceType)
+	}
+	return finding, ""
+}
+
+// result ki apni severity (apna export), phir rule ka security-severity score, phir level
+func sarifSeverity(sarif sarifImportResult, rule sarifImportRule) string {
+	if severity := sarifString(sarif.Properties, "severity"); SeverityRank(severity) > 0 {
+		return severity
+	}
+	for _, properties := range []map[string]any{sarif.Properties, rule.Properties} {
+		if score, ok := sarifScore(properties["security-severity"]); ok {
+			switch {
+			case score >= 7:
+				return SeverityHigh
+			case score >= 4:
+				return SeverityMedium
+			}
+			return SeverityLow
+		}
+	}
+	level := sarif.Level
+	if level == "" {
+		level = rule.DefaultConfiguration.Level
+	}
+	switch level {
+	case "error":
+		return SeverityHigh
+	case "warning":
+		return SeverityMedium
+	case "note":
+		return SeverityLow
+	}
+	// level nahi diya to secret type wali severity
+	return ""
+}
+
+// security-severity spec me string hai, kuch tools number likhte hai
+func sarifScore(value any) (float64, bool) {
+	switch score := value.(type) {
+	case float64:
+		return score, true
+	case string:
+		parsed, err := strconv.ParseFloat(score, 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+func sarifString(properties map[string]any, key string) string {
+	value, _ := properties[key].(string)
+	return value
+}
+
+func sarifInt(properties map[string]any, key string) int {
+	value, _ := properties[key].(float64)
+	return int(value)
+}
+
+// tool ke fingerprints me se key order me pehla, taaki same input pe same choice
+func firstFingerprint(sets ...map[string]string) string {
+	for _, set := range sets {
+		keys := make([]string, 0, len(set))
+		for key := range set {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if set[key] != "" {
+				return key + "=" + set[key]
+			}
+		}
+	}
+	return ""
+}
+
+// known rule / detector hamare secret type me; warna tool ka description ya id
+func importSecretType(known map[string]string, id, description string) string {
+	if secretType, ok := known[id]; ok {
+		return secretType
+	}
+	if description != "" {
+		return description
+	}
+	return id
+}
+
+// request ke options row se mili resource info ko override karte hai; resource ke bina finding dashboard pe kahin nahi dikhti
+func applyImportResource(finding *models.Finding, opts ImportOptions) string {
+	if opts.Provider != "" {
+		finding.Provider = opts.Provider
+	}
+	if opts.ResourceType != "" {
+		finding.ResourceType = opts.ResourceType
+	}
+	if opts.ResourceID != "" {
+		finding.ResourceID = opts.ResourceID
+	}
+	if finding.ResourceType == "" || finding.ResourceID == "" {
+		return "no resource for finding: pass resource_type and resource_id"
+	}
+	if finding.Provider == "" {
+		finding.Provider = ProviderImport
+	}
+	return ""
+}
+
+// secret mila ho to hamara fingerprint (hamare scan ki same finding se match karega);
+// redacted ho to tool ka fingerprint, wo bhi na ho to location se
+func importFingerprint(finding models.Finding, tool, toolFingerprint string) string {
+	if finding.Secret != "" {
+		return FindingFingerprint(finding)
+	}
+	if toolFingerprint != "" {
+		return sha256Hex(tool + "\x00" + toolFingerprint)
+	}
+	return sha256Hex(strings.Join([]string{
+		tool, finding.Provider, finding.ResourceType, finding.ResourceID,
+		finding.SourceType, finding.FileName, strconv.Itoa(finding.Line), finding.CommitSHA,
+		finding.SecretType,
+	}, "\x00"))
+}
@@ -0,0 +1,231 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportGitleaks(t *testing.T) {
+	body := `[
+ {"RuleID": "github-pat", "Description": "GitHub PAT", "StartLine": 3, "Secret": "ghp_abc", "File": "config.py", "Commit": "abc123", "Link": "https://github.com/o/r/blob/abc123/config.py#L3"},
+ {"RuleID": "custom-rule", "Description": "Custom Token", "StartLine": 1, "Secret": "REDACTED", "File": ".env", "Fingerprint": "abc123:.env:custom-rule:1"},
+ {"RuleID": "", "File": "a.py", "Secret": "x"},
+ {"RuleID": "github-pat", "Secret": "x"},
+ {"RuleID": "github-pat", "File": "a.py", "StartLine": -1, "Secret": "x"},
+ {"RuleID": "github-pat", "File": "a.py", "Secret": "REDACTED"},
+ "not an object"
+]`
+	result, err := ImportFindings("gitleaks", []byte(body), ImportOptions{ResourceType: "repos", ResourceID: "o/r"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tool != "gitleaks" || result.Total != 7 || len(result.Findings) != 2 {
+		t.Fatalf("tool=%s total=%d findings=%d, want gitleaks 7 2 (rejected %+v)", result.Tool, result.Total, len(result.Findings), result.Rejected)
+	}
+
+	history, redacted := result.Findings[0], result.Findings[1]
+	if history.SecretType != "GitHub PAT" || history.Pattern != "gitleaks:github-pat" || history.SourceType != "history" || history.CommitSHA != "abc123" || history.Line != 3 {
+		t.Errorf("history finding = %+v", history)
+	}
+	if history.Provider != ProviderImport || history.ResourceID != "o/r" {
+		t.Errorf("history resource = %s %s %s", history.Provider, history.ResourceType, history.ResourceID)
+	}
+	if redacted.Secret != "" || redacted.SecretType != "Custom Token" || redacted.SourceType != "file" || redacted.Fingerprint == "" {
+		t.Errorf("redacted finding = %+v", redacted)
+	}
+
+	wantRejected := []struct {
+		row    int
+		reason string
+	}{
+		{3, "missing RuleID"},
+		{4, "missing File"},
+		{5, "invalid StartLine -1"},
+		{6, "missing Secret and Fingerprint"},
+		{7, "invalid finding"},
+	}
+	if len(result.Rejected) != len(wantRejected) {
+		t.Fatalf("rejected = %+v", result.Rejected)
+	}
+	for i, want := range wantRejected {
+		got := result.Rejected[i]
+		if got.Row != want.row || !strings.HasPrefix(got.Reason, want.reason) {
+			t.Errorf("rejected[%d] = %+v, want row %d %q", i, got, want.row, want.reason)
+		}
+	}
+}
+
+func TestImportTrufflehog(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		opts         ImportOptions
+		reject       string
+		provider     string
+		resourceType string
+		resourceID   string
+		sourceType   string
+		severity     string
+	}{
+		{
+			name:     "github repo with commit",
+			line:     `{"SourceMetadata":{"Data":{"Git":{"commit":"abc","file":"a.py","line":2,"repository":"https://github.com/o/r.git"}}},"DetectorName":"Github","Verified":true,"Raw":"ghp_x"}`,
+			provider: "github", resourceType: ResourceTypeRepo, resourceID: "o/r", sourceType: "history", severity: SeverityHigh,
+		},
+		{
+			name:     "hugging face dataset",
+			line:     `{"SourceMetadata":{"Data":{"Huggingface":{"file":"README.md","line":1,"repository":"https://huggingface.co/datasets/o/d"}}},"DetectorName":"HuggingFace","Raw":"hf_x"}`,
+			provider: ProviderHuggingFace, resourceType: "datasets", resourceID: "o/d", sourceType: "file",
+		},
+		{
+			name:     "s3 bucket",
+			line:     `{"SourceMetadata":{"Data":{"S3":{"bucket":"b","file":"k.txt"}}},"DetectorName":"AWS","Raw":"AKIA"}`,
+			provider: ProviderS3, resourceType: ResourceTypeBucket, resourceID: "b", sourceType: "file",
+		},
+		{
+			name:     "filesystem needs options",
+			line:     `{"SourceMetadata":{"Data":{"Filesystem":{"file":"/src/a.env","line":4}}},"DetectorName":"Stripe","Raw":"sk_live_x"}`,
+			opts:     ImportOptions{ResourceType: "repos", ResourceID: "local/src"},
+			provider: ProviderImport, resourceType: "repos", resourceID: "local/src", sourceType: "file",
+		},
+		{name: "filesystem without resource", line: `{"SourceMetadata":{"Data":{"Filesystem":{"file":"a.env"}}},"DetectorName":"Stripe","Raw":"x"}`, reject: "no resource for finding"},
+		{name: "missing detector", line: `{"SourceMetadata":{"Data":{"Git":{"file":"a"}}},"Raw":"x"}`, reject: "missing DetectorName"},
+		{name: "missing raw", line: `{"SourceMetadata":{"Data":{"Git":{"file":"a"}}},"DetectorName":"AWS"}`, reject: "missing Raw"},
+		{name: "two sources", line: `{"SourceMetadata":{"Data":{"Git":{},"S3":{}}},"DetectorName":"AWS","Raw":"x"}`, reject: "SourceMetadata.Data must have exactly one source"},
+		{name: "unsupported source", line: `{"SourceMetadata":{"Data":{"Slack":{"file":"a"}}},"DetectorName":"AWS","Raw":"x"}`, reject: "unsupported source Slack"},
+		{name: "missing file", line: `{"SourceMetadata":{"Data":{"S3":{"bucket":"b"}}},"DetectorName":"AWS","Raw":"x"}`, reject: "missing file in S3 source metadata"},
+		{name: "negative line", line: `{"SourceMetadata":{"Data":{"S3":{"bucket":"b","file":"k","line":-2}}},"DetectorName":"AWS","Raw":"x"}`, reject: "invalid line -2"},
+	}
+	for _, tt := range tests {
+		// NDJSON aur JSON array dono shapes same result de
+		for _, body := range []string{"\n" + tt.line + "\n\n", "[" + tt.line + "]"} {
+			result, err := ImportFindings("trufflehog", []byte(body), tt.opts)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if result.Total != 1 {
+				t.Errorf("%s: total = %d, want 1", tt.name, result.Total)
+			}
+			if tt.reject != "" {
+				if len(result.Rejected) != 1 || !strings.HasPrefix(result.Rejected[0].Reason, tt.reject) {
+					t.Errorf("%s: rejected = %+v, want %q", tt.name, result.Rejected, tt.reject)
+				}
+				continue
+			}
+			if len(result.Findings) != 1 {
+				t.Fatalf("%s: findings = %d, rejected %+v", tt.name, len(result.Findings), result.Rejected)
+			}
+			got := result.Findings[0]
+			if got.Provider != tt.provider || got.ResourceType != tt.resourceType || got.ResourceID != tt.resourceID || got.SourceType != tt.sourceType || got.Severity != tt.severity {
+				t.Errorf("%s: finding = %s %s %s %s %q, want %s %s %s %s %q", tt.name,
+					got.Provider, got.ResourceType, got.ResourceID, got.SourceType, got.Severity,
+					tt.provider, tt.resourceType, tt.resourceID, tt.sourceType, tt.severity)
+			}
+		}
+	}
+
+	if _, err := ImportFindings("trufflehog", []byte(`[{"DetectorName":`), ImportOptions{}); err == nil {
+		t.Error("broken JSON array imported without error")
+	}
+}
+
+func TestImportSARIFDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"not json", `{`, "invalid SARIF log"},
+		{"old version", `{"version":"2.0.0","runs":[]}`, "unsupported SARIF version"},
+		{"no runs", `{"version":"2.1.0"}`, "missing runs"},
+		{"no driver name", `{"version":"2.1.0","runs":[{"tool":{"driver":{}},"results":[]}]}`, "missing tool.driver.name"},
+	}
+	for _, tt := range tests {
+		_, err := ImportFindings("sarif", []byte(tt.body), ImportOptions{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestImportSARIFResults(t *testing.T) {
+	body := `{"version":"2.1.0","runs":[{
+ "tool":{"driver":{"name":"semgrep","rules":[
+  {"id":"generic-api-key","name":"API Key","properties":{"security-severity":"8.5"}},
+  {"id":"note-rule","shortDescription":{"text":"Low Token"},"defaultConfiguration":{"level":"note"}}
+ ]}},
+ "results":[
+  {"ruleId":"generic-api-key","locations":[{"physicalLocation":{"artifactLocation":{"uri":"src/my%20app.py"},"region":{"startLine":7}}}],"partialFingerprints":{"b":"2","a":"1"}},
+  {"ruleIndex":1,"locations":[{"physicalLocation":{"artifactLocation":{"uri":"x.py"}}}],"properties":{"commit_sha":"abc"}},
+  {"ruleId":"generic-api-key","locations":[{"logicalLocations":[{"name":"discussion #4","fullyQualifiedName":"discussion #4/comment c9"}]}],"properties":{"source_type":"discussion"}},
+  {"ruleId":"generic-api-key","locations":[{"logicalLocations":[{"name":"config.Env[3]","fullyQualifiedName":"config.Env[3]"}]}],"properties":{"source_type":"image_config"}},
+  {"ruleId":"missing-rule","locations":[{"physicalLocation":{"artifactLocation":{"uri":"a.py"}}}]},
+  {"locations":[]},
+  {"ruleId":"generic-api-key","locations":[]},
+  {"ruleId":"generic-api-key","locations":[{"physicalLocation":{"artifactLocation":{"uri":"a.py"}}}],"properties":{"source_type":"discussion"}}
+ ]}]}`
+	result, err := ImportFindings("sarif", []byte(body), ImportOptions{ResourceType: "models", ResourceID: "o/m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tool != "semgrep" || result.Total != 8 || len(result.Findings) != 4 {
+		t.Fatalf("tool=%s total=%d findings=%d, rejected %+v", result.Tool, result.Total, len(result.Findings), result.Rejected)
+	}
+
+	findings := []struct {
+		fileName   string
+		line       int
+		sourceType string
+		secretType string
+		severity   string
+	}{
+		{"src/my app.py", 7, "file", "API Key", SeverityHigh},
+		{"x.py", 0, "history", "Low Token", SeverityLow},
+		{"", 0, "discussion", "API Key", SeverityHigh},
+		{"config.Env[3]", 3, "image_config", "API Key", SeverityHigh},
+	}
+	for i, want := range findings {
+		got := result.Findings[i]
+		if got.FileName != want.fileName || got.Line != want.line || got.SourceType != want.sourceType || got.SecretType != want.secretType || got.Severity != want.severity || got.Tool != "semgrep" {
+			t.Errorf("finding %d = %q %d %s %s %s, want %+v", i, got.FileName, got.Line, got.SourceType, got.SecretType, got.Severity, want)
+		}
+	}
+	if got := result.Findings[2]; got.DiscussionNum != 4 || got.CommentID != "c9" {
+		t.Errorf("discussion finding = #%d comment %q", got.DiscussionNum, got.CommentID)
+	}
+	// partial fingerprint key order se chuna jata hai, map order se nahi
+	if want := importFingerprint(result.Findings[0], "semgrep", "a=1"); result.Findings[0].Fingerprint != want {
+		t.Errorf("fingerprint = %s, want %s", result.Findings[0].Fingerprint, want)
+	}
+
+	wantRejected := []struct {
+		row    int
+		reason string
+	}{
+		{5, `run 1: rule "missing-rule" not defined by semgrep`},
+		{6, "run 1: missing ruleId"},
+		{7, "run 1: result has no location"},
+		{8, `run 1: source_type "discussion" does not match a file location`},
+	}
+	if len(result.Rejected) != len(wantRejected) {
+		t.Fatalf("rejected = %+v", result.Rejected)
+	}
+	for i, want := range wantRejected {
+		if got := result.Rejected[i]; got.Row != want.row || got.Reason != want.reason {
+			t.Errorf("rejected[%d] = %+v, want row %d %q", i, got, want.row, want.reason)
+		}
+	}
+}
+
+func TestImportFindingsFormat(t *testing.T) {
+	if _, err := ImportFindings("snyk", []byte(`[]`), ImportOptions{}); err == nil || !strings.Contains(err.Error(), "gitleaks, sarif, trufflehog") {
+		t.Errorf("unknown format err = %v", err)
+	}
+	if _, err := ImportFindings("gitleaks", []byte("  \n"), ImportOptions{}); err == nil {
+		t.Error("empty report imported without error")
+	}
+	result, err := ImportFindings("gitleaks", []byte(`[]`), ImportOptions{})
+	if err != nil || result.Total != 0 || len(result.Findings) != 0 {
+		t.Errorf("clean gitleaks scan = %+v, %v", result, err)
+	}
+}
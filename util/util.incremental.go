@@ -0,0 +1,365 @@
+package util
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	util_model "github.com/MishraShardendu22/Scanner/util/model"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RescanMode string
+
+const (
+	RescanFull        RescanMode = "full"
+	RescanIncremental RescanMode = "incremental"
+	RescanReused      RescanMode = "reused"
+)
+
+// ek resource ke rescan ka plan: kya dobara scan hoga aur pichle scan se kya as-is liya gaya
+type RescanPlan struct {
+	Mode           RescanMode `json:"mode"`
+	CommitSHA      string     `json:"commit_sha,omitempty"`
+	PreviousSHA    string     `json:"previous_sha,omitempty"`
+	LastModified   string     `json:"last_modified,omitempty"`
+	ChangedFiles   []string   `json:"changed_files"`
+	RemovedFiles   []string   `json:"removed_files"`
+	UnchangedFiles int        `json:"unchanged_files"`
+	// history findings pichle scan se mil gaye, commits dobara fetch nahi karne
+	SkipHistory bool `json:"-"`
+
+	scanHistory bool
+	carried     []models.Finding
+	// nil matlab saari files scan karni hai
+	scanFiles map[string]bool
+}
+
+func IncrementalRescanEnabled() bool {
+	return GetEnv("INCREMENTAL_RESCAN", "true") == "true"
+}
+
+// scan states aur unki snapshot request kahan rehti hai; default Mongo, tests memory wala store lagate hai
+type scanStateStore interface {
+	Load(provider, resourceType, resourceID string) *models.RESOURCE_SCAN_STATE
+	Request(requestID string) *models.AI_REQUEST
+	Save(state models.RESOURCE_SCAN_STATE) error
+}
+
+var scanStates scanStateStore = mongoScanStateStore{}
+
+type mongoScanStateStore struct{}
+
+func (mongoScanStateStore) Load(provider, resourceType, resourceID string) *models.RESOURCE_SCAN_STATE {
+	state := &models.RESOURCE_SCAN_STATE{}
+	err := mgm.Coll(state).First(scanStateFilter(provider, resourceType, resourceID), state)
+	if err != nil {
+		return nil
+	}
+	return state
+}
+
+func (mongoScanStateStore) Request(requestID string) *models.AI_REQUEST {
+	aiRequest := &models.AI_REQUEST{}
+	if err := mgm.Coll(aiRequest).First(bson.M{"request_id": requestID}, aiRequest); err != nil {
+		return nil
+	}
+	return aiRequest
+}
+
+func (mongoScanStateStore) Save(state models.RESOURCE_SCAN_STATE) error {
+	_, err := mgm.Coll(&state).UpdateOne(mgm.Ctx(),
+		scanStateFilter(state.Provider, state.ResourceType, state.ResourceID),
+		bson.M{"$set": bson.M{
+			"provider":         state.Provider,
+			"resource_type":    state.ResourceType,
+			"resource_id":      state.ResourceID,
+			"sha":              state.CommitSHA,
+			"last_modified":    state.LastModified,
+			"request_id":       state.RequestID,
+			"rule_set_version": state.RuleSetVersion,
+			"history_scanned":  state.HistoryScanned,
+			"findings":         state.Findings,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func LoadScanState(provider, resourceType, resourceID string) *models.RESOURCE_SCAN_STATE {
+	return scanStates.Load(provider, resourceType, resourceID)
+}
+
+// provider ke bina save hui purani states Hugging Face ki hi hai
+func scanStateFilter(provider, resourceType, resourceID string) bson.M {
+	filter := bson.M{
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+	}
+	if provider = ProviderOrDefault(provider); provider == ProviderHuggingFace {
+		filter["provider"] = bson.M{"$in": bson.A{nil, "", ProviderHuggingFace}}
+	} else {
+		filter["provider"] = provider
+	}
+	return filter
+}
+
+func loadRequestByID(requestID string) *models.AI_REQUEST {
+	if requestID == "" {
+		return nil
+	}
+	return scanStates.Request(requestID)
+}
+
+func NewRescanPlan(sha, lastModified string, opts FetchOptions) *RescanPlan {
+	return &RescanPlan{
+		Mode:         RescanFull,
+		CommitSHA:    sha,
+		LastModified: lastModified,
+		ChangedFiles: []string{},
+		RemovedFiles: []string{},
+		scanHistory:  opts.ScanHistory,
+	}
+}
+
+// isi rule set pe pichla scan aur uski AI_REQUEST (unchanged files ka content usi me hai)
+func loadPreviousScan(provider, resourceType, resourceID, sha string, opts FetchOptions) (*models.RESOURCE_SCAN_STATE, *models.AI_REQUEST) {
+	if opts.FullRescan || !IncrementalRescanEnabled() || sha == "" {
+		return nil, nil
+	}
+	state := LoadScanState(provider, resourceType, resourceID)
+	if state == nil || state.RuleSetVersion != RuleSetVersion() || state.CommitSHA == "" {
+		return nil, nil
+	}
+	previous := loadRequestByID(state.RequestID)
+	if previous == nil {
+		return nil, nil
+	}
+	return state, previous
+}
+
+// sha same hai to pichla snapshot aur findings as-is;
+// pichli baar kuch files fail hui thi to reuse nahi, incremental me wo dobara fetch hongi
+func reusePreviousScan(plan *RescanPlan, aiRequest *models.AI_REQUEST, state *models.RESOURCE_SCAN_STATE, previous *models.AI_REQUEST, opts FetchOptions) bool {
+	if state.CommitSHA != plan.CommitSHA || len(previous.Failed) > 0 {
+		return false
+	}
+	plan.Mode = RescanReused
+	plan.PreviousSHA = state.CommitSHA
+	plan.UnchangedFiles = len(previous.Siblings)
+	plan.scanFiles = map[string]bool{}
+	aiRequest.Siblings = previous.Siblings
+	aiRequest.Skipped = previous.Skipped
+	plan.SkipHistory = opts.ScanHistory && state.HistoryScanned
+	for _, finding := range state.Findings {
+		if finding.SourceType == "file" || (plan.SkipHistory && finding.SourceType == "history") {
+			plan.carried = append(plan.carried, finding)
+		}
+	}
+	return true
+}
+
+// non-HF sources ke liye: commit sha pichle scan jaisa hai to files fetch hi nahi karni,
+// warna full plan (unchanged blobs waise bhi blob cache se aate hai)
+func PlanForCommit(aiRequest *models.AI_REQUEST, opts FetchOptions) *RescanPlan {
+	plan := NewRescanPlan(aiRequest.CommitSHA, aiRequest.LastModified, opts)
+	state, previous := loadPreviousScan(aiRequest.Provider, aiRequest.ResourceType, aiRequest.ResourceID, aiRequest.CommitSHA, opts)
+	if previous != nil {
+		plan.PreviousSHA = state.CommitSHA
+		reusePreviousScan(plan, aiRequest, state, previous, opts)
+	}
+	return plan
+}
+
+// info endpoint ke data (sha, lastModified, siblings) ko pichle scan state se compare karke
+// aiRequest me files bharta hai: repo same hai to purana snapshot, badla hai to sirf changed files download
+func FetchSiblingsIncremental(aiRequest *models.AI_REQUEST, resourceType, resourceID string, resourceData map[string]interface{}, opts FetchOptions) *RescanPlan {
+	sha, _ := resourceData["sha"].(string)
+	lastModified, _ := resourceData["lastModified"].(string)
+	siblings, _ := resourceData["siblings"].([]interface{})
+
+	aiRequest.CommitSHA = sha
+	aiRequest.LastModified = lastModified
+
+	plan := NewRescanPlan(sha, lastModified, opts)
+	state, previous := loadPreviousScan(ProviderHuggingFace, resourceType, resourceID, sha, opts)
+
+	if previous == nil {
+		aiRequest.Siblings, aiRequest.Skipped, aiRequest.Failed = FetchSiblingFiles(resourceType, resourceID, siblings, opts.ScanKey, opts.ByteBudget)
+		log.Printf(
+			"op=FetchSiblingsIncremental stage=full resource_type=%s resource_id=%s sha=%s files=%d",
+			resourceType, resourceID, sha, len(aiRequest.Siblings),
+		)
+		return plan
+	}
+	plan.PreviousSHA = state.CommitSHA
+
+	if reusePreviousScan(plan, aiRequest, state, previous, opts) {
+		log.Printf(
+			"op=FetchSiblingsIncremental stage=reused resource_type=%s resource_id=%s sha=%s files=%d carried_findings=%d",
+			resourceType, resourceID, sha, len(aiRequest.Siblings), len(plan.carried),
+		)
+		return plan
+	}
+
+	// sha badla hai: jin files ka blob same hai unka content aur findings purane scan se,
+	// baaki (naye ya badle hue) blobs hi download + scan honge
+	previousByName := make(map[string]models.SIBLING)
+	for _, sibling := range previous.Siblings {
+		previousByName[sibling.RFilename] = sibling
+	}
+
+	unchanged := []models.SIBLING{}
+	skipped := []models.SKIPPED_FILE{}
+	unchangedNames := make(map[string]bool)
+	changedRaw := []interface{}{}
+	current := make(map[string]bool)
+	for _, sib := range siblings {
+		sibMap, ok := sib.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		meta := siblingMetadata(sibMap)
+		if meta.RFilename == "" {
+			continue
+		}
+		if !TextExtensions[strings.ToLower(filepath.Ext(meta.RFilename))] {
+			skipped = append(skipped, models.SKIPPED_FILE{RFilename: meta.RFilename, Reason: SkipReasonExtension, Size: meta.Size})
+			continue
+		}
+		current[meta.RFilename] = true
+
+		prev, ok := previousByName[meta.RFilename]
+		if ok && BlobCacheKey(prev) != "" && BlobCacheKey(prev) == BlobCacheKey(meta) {
+			unchanged = append(unchanged, prev)
+			unchangedNames[meta.RFilename] = true
+			continue
+		}
+		changedRaw = append(changedRaw, sib)
+		plan.ChangedFiles = append(plan.ChangedFiles, meta.RFilename)
+	}
+	for name := range previousByName {
+		if !current[name] {
+			plan.RemovedFiles = append(plan.RemovedFiles, name)
+		}
+	}
+
+	fetched, fetchSkipped, failed := FetchSiblingFiles(resourceType, resourceID, changedRaw, opts.ScanKey, opts.ByteBudget)
+	aiRequest.Siblings = append(unchanged, fetched...)
+	aiRequest.Skipped = append(skipped, fetchSkipped...)
+	aiRequest.Failed = failed
+
+	plan.Mode = RescanIncremental
+	plan.UnchangedFiles = len(unchanged)
+	plan.scanFiles = make(map[string]bool)
+	for _, name := range plan.ChangedFiles {
+		plan.scanFiles[name] = true
+	}
+	for _, finding := range state.Findings {
+		if finding.SourceType == "file" && unchangedNames[finding.FileName] {
+			plan.carried = append(plan.carried, finding)
+		}
+	}
+
+	log.Printf(
+		"op=FetchSiblingsIncremental stage=incremental resource_type=%s resource_id=%s previous_sha=%s sha=%s changed=%d removed=%d unchanged=%d carried_findings=%d",
+		resourceType, resourceID, state.CommitSHA, sha, len(plan.ChangedFiles), len(plan.RemovedFiles), len(unchanged), len(plan.carried),
+	)
+	return plan
+}
+
+// stored request (bina network ke) ke liye plan: agar pichla scan isi request pe hua tha to reused
+func PlanForStoredRequest(aiRequest *models.AI_REQUEST, scanHistory bool) *RescanPlan {
+	plan := &RescanPlan{
+		Mode:         RescanFull,
+		CommitSHA:    aiRequest.CommitSHA,
+		LastModified: aiRequest.LastModified,
+		ChangedFiles: []string{},
+		RemovedFiles: []string{},
+		scanHistory:  scanHistory,
+	}
+	if !IncrementalRescanEnabled() {
+		return plan
+	}
+	state := LoadScanState(aiRequest.Provider, aiRequest.ResourceType, aiRequest.ResourceID)
+	if state == nil || state.RequestID != aiRequest.RequestID || state.RuleSetVersion != RuleSetVersion() {
+		return plan
+	}
+
+	plan.Mode = RescanReused
+	plan.PreviousSHA = state.CommitSHA
+	plan.UnchangedFiles = len(aiRequest.Siblings)
+	plan.scanFiles = map[string]bool{}
+	plan.SkipHistory = scanHistory && state.HistoryScanned
+	for _, finding := range state.Findings {
+		if finding.SourceType == "file" || (plan.SkipHistory && finding.SourceType == "history") {
+			plan.carried = append(plan.carried, finding)
+		}
+	}
+	return plan
+}
+
+// plan ke hisaab se sirf zaroori files scan karta hai, pichle findings jodta hai
+// aur agle rescan ke liye state save karta hai
+func ScanWithPlan(req models.AI_REQUEST, plan *RescanPlan, patterns []util_model.SecretPattern, resourceType, resourceID string) []models.Finding {
+	if plan == nil {
+		return ScanAIRequest(req, patterns, resourceType, resourceID)
+	}
+
+	view := req
+	if plan.scanFiles != nil {
+		view.Siblings = []models.SIBLING{}
+		for _, sibling := range req.Siblings {
+			if plan.scanFiles[sibling.RFilename] {
+				view.Siblings = append(view.Siblings, sibling)
+			}
+		}
+	}
+	if plan.SkipHistory {
+		view.History = nil
+	}
+
+	findings := ScanAIRequest(view, patterns, resourceType, resourceID)
+	findings = append(findings, plan.carried...)
+
+	if samePatterns(patterns) {
+		SaveScanState(req, plan, findings)
+	}
+	return findings
+}
+
+func SaveScanState(req models.AI_REQUEST, plan *RescanPlan, findings []models.Finding) {
+	// incremental band ho to state padhi hi nahi jaati, likhne ka bhi kaam nahi
+	if req.CommitSHA == "" || req.ResourceType == "" || req.ResourceID == "" || !IncrementalRescanEnabled() {
+		return
+	}
+	historyScanned := plan.scanHistory && (len(req.History) > 0 || plan.SkipHistory)
+
+	stored := []models.Finding{}
+	for _, finding := range findings {
+		if finding.SourceType == "file" || (historyScanned && finding.SourceType == "history") {
+			stored = append(stored, finding)
+		}
+	}
+
+	err := scanStates.Save(models.RESOURCE_SCAN_STATE{
+		Provider:       ProviderOrDefault(req.Provider),
+		ResourceType:   req.ResourceType,
+		ResourceID:     req.ResourceID,
+		CommitSHA:      req.CommitSHA,
+		LastModified:   req.LastModified,
+		RequestID:      req.RequestID,
+		RuleSetVersion: RuleSetVersion(),
+		HistoryScanned: historyScanned,
+		Findings:       stored,
+	})
+	if err != nil {
+		log.Printf(
+			"op=SaveScanState stage=db_error resource_type=%s resource_id=%s error=%v",
+			req.ResourceType, req.ResourceID, err,
+		)
+	}
+}
@@ -0,0 +1,230 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+type memoryScanStateStore struct {
+	mu       sync.Mutex
+	states   map[string]models.RESOURCE_SCAN_STATE
+	requests map[string]models.AI_REQUEST
+}
+
+func (s *memoryScanStateStore) Load(provider, resourceType, resourceID string) *models.RESOURCE_SCAN_STATE {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[ProviderOrDefault(provider)+":"+resourceType+":"+resourceID]
+	if !ok {
+		return nil
+	}
+	return &state
+}
+
+func (s *memoryScanStateStore) Request(requestID string) *models.AI_REQUEST {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	request, ok := s.requests[requestID]
+	if !ok {
+		return nil
+	}
+	return &request
+}
+
+func (s *memoryScanStateStore) Save(state models.RESOURCE_SCAN_STATE) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.Provider+":"+state.ResourceType+":"+state.ResourceID] = state
+	return nil
+}
+
+func withMemoryScanStates(t *testing.T) *memoryScanStateStore {
+	t.Helper()
+	store := &memoryScanStateStore{states: map[string]models.RESOURCE_SCAN_STATE{}, requests: map[string]models.AI_REQUEST{}}
+	previous := scanStates
+	scanStates = store
+	t.Cleanup(func() { scanStates = previous })
+	return store
+}
+
+// org/repo ki teen files; har resolve call gini jaati hai taaki pata chale kaunsi file dobara download hui
+func incrementalHub(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	fetched := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutPrefix(r.URL.Path, "/org/repo/resolve/main/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		fetched = append(fetched, name)
+		mu.Unlock()
+		fmt.Fprintf(w, "GITHUB_TOKEN=ghp_%s\n", strings.Repeat(string(name[0]), 36))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(fetched)
+		out := fetched
+		fetched = []string{}
+		return out
+	}
+}
+
+func hubSiblings(blobs map[string]string) []interface{} {
+	names := []string{}
+	for name := range blobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	siblings := []interface{}{}
+	for _, name := range names {
+		siblings = append(siblings, map[string]interface{}{"rfilename": name, "blobId": blobs[name], "size": float64(20)})
+	}
+	return siblings
+}
+
+func TestFetchSiblingsIncrementalPlans(t *testing.T) {
+	t.Setenv("INCREMENTAL_RESCAN", "true")
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+	hub, fetched := incrementalHub(t)
+	t.Setenv("HF_BASE_URL", hub.URL)
+
+	// pichla scan sha s1 pe: a.env, b.env, c.env
+	previousFiles := []models.SIBLING{
+		{RFilename: "a.env", BlobID: "blob-a", FileContent: "TOKEN=tok_aaaaaaaaaaaa\n"},
+		{RFilename: "b.env", BlobID: "blob-b", FileContent: "TOKEN=tok_bbbbbbbbbbbb\n"},
+		{RFilename: "c.env", BlobID: "blob-c", FileContent: "TOKEN=tok_cccccccccccc\n"},
+	}
+	previousFindings := []models.Finding{
+		{SourceType: "file", FileName: "a.env", Secret: "tok_aaaaaaaaaaaa"},
+		{SourceType: "file", FileName: "b.env", Secret: "tok_bbbbbbbbbbbb"},
+		{SourceType: "history", FileName: "a.env", Secret: "tok_old000000000"},
+	}
+	sameBlobs := map[string]string{"a.env": "blob-a", "b.env": "blob-b", "c.env": "blob-c"}
+
+	tests := []struct {
+		name        string
+		sha         string
+		blobs       map[string]string
+		opts        FetchOptions
+		ruleSet     string
+		failed      bool
+		mode        RescanMode
+		fetched     string
+		changed     string
+		removed     string
+		carried     int
+		skipHistory bool
+	}{
+		{name: "same sha reused", sha: "s1", blobs: sameBlobs, opts: FetchOptions{ScanHistory: true},
+			mode: RescanReused, carried: 3, skipHistory: true},
+		{name: "same sha without history", sha: "s1", blobs: sameBlobs,
+			mode: RescanReused, carried: 2},
+		{name: "previous scan had failures", sha: "s1", blobs: sameBlobs, failed: true,
+			mode: RescanIncremental, carried: 2},
+		{name: "new sha only changed blobs", sha: "s2", blobs: map[string]string{"a.env": "blob-a", "b.env": "blob-b2", "d.env": "blob-d"},
+			mode: RescanIncremental, fetched: "b.env,d.env", changed: "b.env,d.env", removed: "c.env", carried: 1},
+		{name: "full rescan requested", sha: "s1", blobs: sameBlobs, opts: FetchOptions{FullRescan: true},
+			mode: RescanFull, fetched: "a.env,b.env,c.env"},
+		{name: "rule set changed", sha: "s1", blobs: sameBlobs, ruleSet: "old-rules",
+			mode: RescanFull, fetched: "a.env,b.env,c.env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := withMemoryScanStates(t)
+			ruleSetVersion := RuleSetVersion()
+			if tt.ruleSet != "" {
+				ruleSetVersion = tt.ruleSet
+			}
+			previous := models.AI_REQUEST{RequestID: "req-1", Siblings: previousFiles}
+			if tt.failed {
+				previous.Failed = []models.FAILED_ITEM{{Name: "d.env", Error: "boom"}}
+			}
+			store.requests["req-1"] = previous
+			store.states[ProviderHuggingFace+":models:org/repo"] = models.RESOURCE_SCAN_STATE{
+				Provider: ProviderHuggingFace, ResourceType: "models", ResourceID: "org/repo",
+				CommitSHA: "s1", RequestID: "req-1", RuleSetVersion: ruleSetVersion, HistoryScanned: true, Findings: previousFindings,
+			}
+			fetched()
+
+			req := models.AI_REQUEST{}
+			plan := FetchSiblingsIncremental(&req, "models", "org/repo", map[string]interface{}{
+				"sha": tt.sha, "siblings": hubSiblings(tt.blobs),
+			}, tt.opts)
+
+			if plan.Mode != tt.mode {
+				t.Fatalf("mode = %s, want %s", plan.Mode, tt.mode)
+			}
+			if got := strings.Join(fetched(), ","); got != tt.fetched {
+				t.Errorf("downloaded %q, want %q", got, tt.fetched)
+			}
+			if got := strings.Join(plan.ChangedFiles, ","); got != tt.changed {
+				t.Errorf("changed = %q, want %q", got, tt.changed)
+			}
+			if got := strings.Join(plan.RemovedFiles, ","); got != tt.removed {
+				t.Errorf("removed = %q, want %q", got, tt.removed)
+			}
+			if len(plan.carried) != tt.carried || plan.SkipHistory != tt.skipHistory {
+				t.Errorf("carried = %d skip_history = %t, want %d %t", len(plan.carried), plan.SkipHistory, tt.carried, tt.skipHistory)
+			}
+			if tt.mode != RescanFull && plan.PreviousSHA != "s1" {
+				t.Errorf("previous sha = %q, want s1", plan.PreviousSHA)
+			}
+		})
+	}
+}
+
+// incremental plan me sirf badli files scan hoti hai, unchanged files ke findings pichle scan se,
+// aur agle rescan ke liye nayi state save hoti hai
+func TestScanWithPlanIncremental(t *testing.T) {
+	t.Setenv("INCREMENTAL_RESCAN", "true")
+	t.Setenv("BLOB_CACHE_ENABLED", "false")
+	hub, _ := incrementalHub(t)
+	t.Setenv("HF_BASE_URL", hub.URL)
+	store := withMemoryScanStates(t)
+	store.requests["req-1"] = models.AI_REQUEST{RequestID: "req-1", Siblings: []models.SIBLING{
+		{RFilename: "a.env", BlobID: "blob-a", FileContent: "GITHUB_TOKEN=ghp_" + strings.Repeat("a", 36) + "\n"},
+	}}
+	store.states[ProviderHuggingFace+":models:org/repo"] = models.RESOURCE_SCAN_STATE{
+		Provider: ProviderHuggingFace, ResourceType: "models", ResourceID: "org/repo",
+		CommitSHA: "s1", RequestID: "req-1", RuleSetVersion: RuleSetVersion(),
+		Findings: []models.Finding{{SourceType: "file", FileName: "a.env", Secret: "carried-from-s1"}},
+	}
+
+	req := models.AI_REQUEST{RequestID: "req-2", Provider: ProviderHuggingFace, ResourceType: "models", ResourceID: "org/repo"}
+	plan := FetchSiblingsIncremental(&req, "models", "org/repo", map[string]interface{}{
+		"sha": "s2", "siblings": hubSiblings(map[string]string{"a.env": "blob-a", "b.env": "blob-b"}),
+	}, FetchOptions{})
+	findings := ScanWithPlan(req, plan, ActiveRuleSet().Patterns, "models", "org/repo")
+
+	secrets := []string{}
+	for _, finding := range findings {
+		secrets = append(secrets, finding.FileName+"="+finding.Secret)
+	}
+	sort.Strings(secrets)
+	// a.env dobara scan nahi hui (warna ghp_aaaa... bhi aata), b.env nayi scan hui
+	if len(secrets) < 2 || secrets[0] != "a.env=carried-from-s1" || secrets[1] != "b.env=ghp_"+strings.Repeat("b", 36) {
+		t.Fatalf("findings = %v, want carried a.env and scanned b.env", secrets)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(secret, "ghp_aaaa") {
+			t.Errorf("unchanged a.env was scanned again: %v", secrets)
+		}
+	}
+
+	state := store.Load(ProviderHuggingFace, "models", "org/repo")
+	if state == nil || state.CommitSHA != "s2" || state.RequestID != "req-2" || len(state.Findings) != len(findings) {
+		t.Errorf("saved state = %+v, want s2 / req-2 with %d findings", state, len(findings))
+	}
+}
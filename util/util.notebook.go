@@ -0,0 +1,103 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// .ipynb ka ek cell: raw JSON me kaunsi lines uski hai aur uska source (lines me)
+type NotebookCell struct {
+	Index     int
+	CellType  string
+	StartLine int
+	EndLine   int
+	Source    []string
+}
+
+// notebook JSON ko stream karke har cell ki line range; invalid notebook pe nil
+// (tab findings sirf JSON line ke saath reh jaati hai)
+func NotebookCells(content string) []NotebookCell {
+	dec := json.NewDecoder(strings.NewReader(content))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	// offsets aage hi badhte hai, isliye newlines ek hi baar gine jaate hai
+	line, counted := 1, 0
+	lineAt := func(offset int) int {
+		line += strings.Count(content[counted:offset], "\n")
+		counted = offset
+		return line
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if key != "cells" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+			continue
+		}
+		if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+			return nil
+		}
+
+		cells := []NotebookCell{}
+		for dec.More() {
+			// InputOffset pichle token ke baad ka hai, comma / whitespace chhod ke cell ka '{'
+			start := int(dec.InputOffset())
+			for start < len(content) && strings.IndexByte(" \t\r\n,", content[start]) >= 0 {
+				start++
+			}
+			var raw struct {
+				CellType string          `json:"cell_type"`
+				Source   json.RawMessage `json:"source"`
+			}
+			if err := dec.Decode(&raw); err != nil {
+				return nil
+			}
+			cells = append(cells, NotebookCell{
+				Index:     len(cells) + 1,
+				CellType:  raw.CellType,
+				StartLine: lineAt(start),
+				EndLine:   lineAt(int(dec.InputOffset())),
+				Source:    notebookSourceLines(raw.Source),
+			})
+		}
+		return cells
+	}
+	return nil
+}
+
+// source nbformat me string ya strings ki list hota hai
+func notebookSourceLines(raw json.RawMessage) []string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		var parts []string
+		if err := json.Unmarshal(raw, &parts); err != nil {
+			return nil
+		}
+		text = strings.Join(parts, "")
+	}
+	return strings.Split(text, "\n")
+}
+
+// JSON line wali finding kis cell me hai aur us cell ke source ki kaunsi line; cell na mile to 0, 0
+func NotebookLocation(cells []NotebookCell, line int, secret string) (int, int) {
+	for _, cell := range cells {
+		if line < cell.StartLine || line > cell.EndLine {
+			continue
+		}
+		for i, sourceLine := range cell.Source {
+			if strings.Contains(sourceLine, secret) {
+				return cell.Index, i + 1
+			}
+		}
+		return cell.Index, 0
+	}
+	return 0, 0
+}
@@ -0,0 +1,320 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// model owners ko bhejne wali standalone report (HTML / Markdown) ka data; secrets hamesha masked
+type ScanReportView struct {
+	RequestID     string
+	ScanID        string
+	ScannedAt     time.Time
+	GeneratedAt   time.Time
+	TotalFindings int
+	BySeverity    []ReportSeverityCount
+	Resources     []ReportResource
+	Remediation   []ReportRemediation
+	Coverage      *models.SCAN_COVERAGE
+}
+
+type ReportSeverityCount struct {
+	Severity string
+	Count    int
+}
+
+type ReportResource struct {
+	Provider string
+	Type     string
+	ID       string
+	ScanMode string
+	Findings []ReportFinding
+}
+
+type ReportFinding struct {
+	models.Finding
+	Severity string
+	Location string
+}
+
+type ReportRemediation struct {
+	SecretType string
+	Severity   string
+	Count      int
+	Steps      []string
+}
+
+// issuer pe key kaise revoke ho; baaki types ke liye generic step
+var issuerRemediation = map[string]string{
+	"AWS Access Key ID":       "Deactivate and delete the key in IAM (Users > Security credentials), then review CloudTrail for calls made with it.",
+	"GitHub PAT":              "Revoke the token at https://github.com/settings/tokens and review the account's security log.",
+	"GitHub Actions Token":    "Revoke the OAuth token in the GitHub settings of the owning account or app.",
+	"GitLab PAT":              "Revoke the token under GitLab > Preferences > Access tokens.",
+	"Hugging Face API Key":    "Invalidate the token at https://huggingface.co/settings/tokens and create a new fine-grained token.",
+	"OpenAI / LLM API Key":    "Revoke the key in the provider's API key settings and check usage for unexpected spend.",
+	"Google API Key":          "Regenerate the key in Google Cloud console (APIs & Services > Credentials) and add API / referrer restrictions.",
+	"Stripe Secret Key":       "Roll the key in the Stripe dashboard (Developers > API keys) and review recent API activity.",
+	"Slack App Token":         "Regenerate the token in the Slack app settings.",
+	"Database URI with creds": "Change the database user's password and restrict network access to the database.",
+	"PostgreSQL URI":          "Change the database user's password and restrict network access to the database.",
+	"MySQL URI":               "Change the database user's password and restrict network access to the database.",
+	"MongoDB URI":             "Change the database user's password and restrict network access to the database.",
+	"Kubernetes Bearer Token": "Delete the service account token (or the secret backing it) and review the cluster audit log.",
+}
+
+// source ke hisaab se secret kahan kahan se hatana padega; rotation ke bina in sab se bhi purani copies reh jaati hai
+func sourceRemediation(finding models.Finding) (string, string) {
+	switch {
+	case finding.SourceType == "history" || (finding.StillAtHead != nil && !*finding.StillAtHead && finding.LayerDigest == ""):
+		return "history", "The value is in commit history: removing it from the latest revision is not enough. Rewrite history (for example with git filter-repo) or assume it stays public."
+	case finding.SourceType == "discussion":
+		return "discussion", "Edit or delete the comment. Earlier edits of a comment can stay visible, so rotation is the real fix."
+	case finding.SourceType == "pr":
+		return "pr", "Remove the value from the pull request branch; diffs of closed pull requests stay readable."
+	case finding.SourceType == "image_config" || finding.SourceType == "image_history" || finding.LayerDigest != "":
+		return "image", "Rebuild the image without the value (use build secrets or runtime environment) and delete the old tags from every registry."
+	case finding.VersionID != "":
+		return "version", "Delete the older object versions that contain the value; bucket versioning keeps them readable."
+	}
+	return "file", "Remove the value from the file and load it at runtime from an environment variable or a secret manager."
+}
+
+func BuildScanReport(result models.SCAN_RESULT) ScanReportView {
+	// report dashboard ke bahar jaati hai, full policy pe bhi masked
+	display := SecretDisplayPolicy()
+	if display == SecretDisplayFull {
+		display = SecretDisplayRedacted
+	}
+
+	view := ScanReportView{
+		RequestID:   result.RequestID,
+		ScanID:      result.ID.Hex(),
+		ScannedAt:   result.CreatedAt,
+		GeneratedAt: time.Now().UTC(),
+		Coverage:    result.Coverage,
+	}
+	bySeverity := map[string]int{}
+	remediation := map[string]*ReportRemediation{}
+	remediationSources := map[string]map[string]bool{}
+
+	for _, resource := range result.ScannedResources {
+		reportResource := ReportResource{Provider: resource.Provider, Type: resource.Type, ID: resource.ID, ScanMode: resource.ScanMode}
+		for _, finding := range resource.Findings {
+			severity := SeverityOf(finding)
+			bySeverity[severity]++
+			view.TotalFindings++
+
+			item, ok := remediation[finding.SecretType]
+			if !ok {
+				item = &ReportRemediation{SecretType: finding.SecretType, Severity: severity}
+				step, known := issuerRemediation[finding.SecretType]
+				if !known {
+					step = fmt.Sprintf("Revoke or rotate the %s with its issuer and treat the old value as compromised.", finding.SecretType)
+				}
+				item.Steps = append(item.Steps, step)
+				remediation[finding.SecretType] = item
+				remediationSources[finding.SecretType] = map[string]bool{}
+			}
+			item.Count++
+			if source, step := sourceRemediation(finding); !remediationSources[finding.SecretType][source] {
+				remediationSources[finding.SecretType][source] = true
+				item.Steps = append(item.Steps, step)
+			}
+
+			location := FindingLocationText(finding)
+			finding.Secret = DisplaySecret(finding.Secret, display)
+			reportResource.Findings = append(reportResource.Findings, ReportFinding{Finding: finding, Severity: severity, Location: location})
+		}
+		sort.SliceStable(reportResource.Findings, func(i, j int) bool {
+			return SeverityRank(reportResource.Findings[i].Severity) > SeverityRank(reportResource.Findings[j].Severity)
+		})
+		view.Resources = append(view.Resources, reportResource)
+	}
+
+	for _, severity := range []string{SeverityHigh, SeverityMedium, SeverityLow} {
+		view.BySeverity = append(view.BySeverity, ReportSeverityCount{Severity: severity, Count: bySeverity[severity]})
+	}
+	for _, item := range remediation {
+		view.Remediation = append(view.Remediation, *item)
+	}
+	sort.Slice(view.Remediation, func(i, j int) bool {
+		a, b := view.Remediation[i], view.Remediation[j]
+		if SeverityRank(a.Severity) != SeverityRank(b.Severity) {
+			return SeverityRank(a.Severity) > SeverityRank(b.Severity)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.SecretType < b.SecretType
+	})
+	return view
+}
+
+func (r ReportResource) Name() string {
+	return strings.TrimSpace(r.Provider + " " + r.Type + "/" + r.ID)
+}
+
+// skipped / truncated / failed ek list me
+type ReportCoverageItem struct {
+	Kind   string
+	Name   string
+	Reason string
+}
+
+// coverage ki lists lambi ho sakti hai, report me pehle 20 aur baaki ki ginti
+const reportCoverageListLimit = 20
+
+func ReportCoverageItems(coverage *models.SCAN_COVERAGE) ([]ReportCoverageItem, int) {
+	if coverage == nil {
+		return nil, 0
+	}
+	items := []ReportCoverageItem{}
+	name := func(resourceID, file string) string {
+		return strings.TrimPrefix(resourceID+"/"+file, "/")
+	}
+	for _, file := range coverage.Skipped {
+		items = append(items, ReportCoverageItem{Kind: "skipped", Name: name(file.ResourceID, file.RFilename), Reason: file.Reason})
+	}
+	for _, file := range coverage.Truncated {
+		items = append(items, ReportCoverageItem{Kind: "truncated", Name: name(file.ResourceID, file.RFilename), Reason: file.Reason})
+	}
+	failed := append(append([]models.FAILED_ITEM{}, coverage.Failed...), coverage.DiscussionsFailed...)
+	failed = append(append(failed, coverage.PullRequestsFailed...), coverage.HistoryFailed...)
+	for _, item := range failed {
+		items = append(items, ReportCoverageItem{Kind: "failed", Name: name(item.ResourceID, item.Name), Reason: item.Error})
+	}
+	if len(items) > reportCoverageListLimit {
+		return items[:reportCoverageListLimit], len(items) - reportCoverageListLimit
+	}
+	return items, 0
+}
+
+// dashboard me result page ka link; request id path me escape hoke jaata hai
+func ResultURL(requestID, format string) string {
+	link := "/results/" + url.PathEscape(requestID)
+	if format != "" {
+		link += "?format=" + url.QueryEscape(format)
+	}
+	return link
+}
+
+func (v ScanReportView) FileName(extension string) string {
+	name := v.RequestID
+	if name == "" {
+		name = v.ScanID
+	}
+	return "scan-report-" + name + "." + extension
+}
+
+// ---- Markdown ----
+
+func RenderMarkdownReport(view ScanReportView) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Secret scan report\n\n")
+	fmt.Fprintf(&b, "- **Request ID:** %s\n", markdownText(view.RequestID))
+	if !view.ScannedAt.IsZero() {
+		fmt.Fprintf(&b, "- **Scanned at:** %s\n", view.ScannedAt.UTC().Format(time.RFC1123))
+	}
+	fmt.Fprintf(&b, "- **Report generated:** %s\n", view.GeneratedAt.Format(time.RFC1123))
+	fmt.Fprintf(&b, "- **Resources scanned:** %d\n", len(view.Resources))
+	fmt.Fprintf(&b, "- **Findings:** %d\n\n", view.TotalFindings)
+	b.WriteString("Secrets in this report are masked.\n\n")
+
+	b.WriteString("## Summary by severity\n\n| Severity | Findings |\n| --- | ---: |\n")
+	for _, count := range view.BySeverity {
+		fmt.Fprintf(&b, "| %s | %d |\n", strings.ToUpper(count.Severity), count.Count)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Findings by resource\n\n")
+	for _, resource := range view.Resources {
+		fmt.Fprintf(&b, "### %s\n\n", markdownText(resource.Name()))
+		if len(resource.Findings) == 0 {
+			b.WriteString("No secrets found.\n\n")
+			continue
+		}
+		b.WriteString("| Severity | Secret type | Location | Secret | Link |\n| --- | --- | --- | --- | --- |\n")
+		for _, finding := range resource.Findings {
+			link := ""
+			if finding.URL != "" {
+				link = "[open](<" + markdownURLEscaper.Replace(finding.URL) + ">)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				strings.ToUpper(finding.Severity), markdownText(finding.SecretType), markdownText(finding.Location), markdownCode(finding.Secret), link)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(view.Remediation) > 0 {
+		b.WriteString("## Remediation\n\n")
+		for _, item := range view.Remediation {
+			fmt.Fprintf(&b, "### %s (%s, %d finding", markdownText(item.SecretType), item.Severity, item.Count)
+			if item.Count != 1 {
+				b.WriteString("s")
+			}
+			b.WriteString(")\n\n")
+			for i, step := range item.Steps {
+				fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("## Scan coverage\n\n")
+	coverage := view.Coverage
+	if coverage == nil {
+		b.WriteString("Coverage was not recorded for this scan.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "- **Status:** %s\n", coverage.Status)
+	fmt.Fprintf(&b, "- **Files scanned:** %d of %d considered\n", coverage.FilesScanned, coverage.FilesConsidered)
+	fmt.Fprintf(&b, "- **Skipped:** %d, **truncated:** %d, **failed:** %d\n", len(coverage.Skipped), len(coverage.Truncated), len(coverage.Failed))
+	fmt.Fprintf(&b, "- **Discussions fetched:** %d, **failed:** %d\n", coverage.DiscussionsFetched, len(coverage.DiscussionsFailed))
+	if len(coverage.PullRequestsFailed) > 0 || len(coverage.HistoryFailed) > 0 {
+		fmt.Fprintf(&b, "- **PR diffs failed:** %d, **history failed:** %d\n", len(coverage.PullRequestsFailed), len(coverage.HistoryFailed))
+	}
+	items, more := ReportCoverageItems(coverage)
+	if len(items) > 0 {
+		b.WriteString("\n| Kind | Item | Reason |\n| --- | --- | --- |\n")
+		for _, item := range items {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", item.Kind, markdownCode(item.Name), markdownText(item.Reason))
+		}
+		if more > 0 {
+			fmt.Fprintf(&b, "\n... and %d more.\n", more)
+		}
+	}
+	return b.String()
+}
+
+// repo / file names me markdown ke special characters ho sakte hai
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;", "#", `\#`, "|", `\|`,
+)
+
+var markdownURLEscaper = strings.NewReplacer("<", "%3C", ">", "%3E", " ", "%20", "\n", "")
+
+func markdownText(value string) string {
+	return markdownEscaper.Replace(strings.ReplaceAll(value, "\n", " "))
+}
+
+// inline code me backtick ho to code span ke delimiters lambe
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+	value = strings.ReplaceAll(strings.ReplaceAll(value, "\n", " "), "|", `\|`)
+	fence := "`"
+	for strings.Contains(value, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(value, "`") || strings.HasSuffix(value, "`") {
+		value = " " + value + " "
+	}
+	return fence + value + fence
+}
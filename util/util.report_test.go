@@ -0,0 +1,20 @@
+package util
+
+import "testing"
+
+func TestResultURL(t *testing.T) {
+	tests := []struct {
+		requestID string
+		format    string
+		want      string
+	}{
+		{"SG-2025-0101-abcd", "", "/results/SG-2025-0101-abcd"},
+		{"SG-1", "html", "/results/SG-1?format=html"},
+		{"../admin?x=1", "markdown", "/results/..%2Fadmin%3Fx=1?format=markdown"},
+	}
+	for _, tt := range tests {
+		if got := ResultURL(tt.requestID, tt.format); got != tt.want {
+			t.Errorf("ResultURL(%q, %q) = %s, want %s", tt.requestID, tt.format, got, tt.want)
+		}
+	}
+}
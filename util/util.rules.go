@@ -0,0 +1,447 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	util_model "github.com/MishraShardendu22/Scanner/util/model"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scanner jis rule set se chal raha hai: Mongo ki latest RULE_SET revision, ya koi saved na ho to built-in SecretConfig (revision 0)
+type RuleSet struct {
+	Revision int
+	Version  string
+	Rules    []models.RULE
+	// sirf enabled rules, scan functions yahi lete hai
+	Patterns      []util_model.SecretPattern
+	severities    map[string]string
+	pathDependent bool
+}
+
+var (
+	activeRuleSet  atomic.Pointer[RuleSet]
+	builtinOnce    sync.Once
+	builtinRuleSet *RuleSet
+	// ek process me saves ek ke baad ek; processes ke beech revision ka unique index
+	ruleSetSaveMu sync.Mutex
+)
+
+var (
+	ErrRuleNotFound   = errors.New("rule not found")
+	ErrRuleExists     = errors.New("rule already exists")
+	ErrInvalidRuleSet = errors.New("invalid rule set")
+)
+
+// scan shuru hote hi ek baar lo aur poore scan me wahi use karo, beech me reload ho to bhi scan consistent rahe
+func ActiveRuleSet() *RuleSet {
+	if ruleSet := activeRuleSet.Load(); ruleSet != nil {
+		return ruleSet
+	}
+	return BuiltinRuleSet()
+}
+
+func BuiltinRuleSet() *RuleSet {
+	builtinOnce.Do(func() {
+		builtinRuleSet = NewRuleSet(0, BuiltinRules())
+	})
+	return builtinRuleSet
+}
+
+// stored scan jis rule set pe hua tha (SARIF rules usi se bante hai); purane / imported scans ya revision na mile to chalu set
+func ScanRuleSet(scan models.SCAN_RESULT) *RuleSet {
+	active := ActiveRuleSet()
+	switch {
+	case scan.RuleSetVersion == "" || scan.RuleSetVersion == active.Version:
+		return active
+	case scan.RuleSetRevision == 0:
+		return BuiltinRuleSet()
+	}
+	saved, err := FindRuleSet(scan.RuleSetRevision)
+	if err != nil {
+		return active
+	}
+	return NewRuleSet(saved.Revision, saved.Rules)
+}
+
+// rules badle to purane cached outcomes kaam ke nahi, isliye cache key me rule set ka hash bhi jaata hai
+func RuleSetVersion() string {
+	return ActiveRuleSet().Version
+}
+
+// SecretConfig ke patterns RULE ki shakal me, startup pe load hui rules file ke rules merge karke
+// (pehli saved revision inhi se shuru hoti hai)
+func BuiltinRules() []models.RULE {
+	rules := make([]models.RULE, 0, len(SecretConfig))
+	for _, pattern := range SecretConfig {
+		rules = append(rules, models.RULE{
+			ID:       SARIFRuleID(pattern.Name),
+			Name:     pattern.Name,
+			Regex:    pattern.Regex,
+			Keywords: pattern.Keywords,
+			Severity: pattern.Severity,
+			Enabled:  true,
+		})
+	}
+	return MergeRules(rules, rulesFileRules)
+}
+
+// same id wala rule replace, naya end me; base order waisa hi rehta hai
+func MergeRules(base, extra []models.RULE) []models.RULE {
+	merged := append([]models.RULE(nil), base...)
+	index := map[string]int{}
+	for i, rule := range merged {
+		index[rule.ID] = i
+	}
+	for _, rule := range extra {
+		if i, ok := index[rule.ID]; ok {
+			merged[i] = rule
+			continue
+		}
+		index[rule.ID] = len(merged)
+		merged = append(merged, rule)
+	}
+	return merged
+}
+
+func rulePattern(rule models.RULE) util_model.SecretPattern {
+	pattern := util_model.SecretPattern{
+		Name:        rule.Name,
+		Regex:       rule.Regex,
+		Keywords:    rule.Keywords,
+		Severity:    rule.Severity,
+		SecretGroup: rule.SecretGroup,
+		Entropy:     rule.Entropy,
+		Path:        rule.Path,
+	}
+	for _, allowlist := range rule.Allowlists {
+		pattern.Allowlists = append(pattern.Allowlists, util_model.PatternAllowlist{
+			Condition:   allowlist.Condition,
+			Commits:     allowlist.Commits,
+			Paths:       allowlist.Paths,
+			Regexes:     allowlist.Regexes,
+			RegexTarget: allowlist.RegexTarget,
+			StopWords:   allowlist.StopWords,
+		})
+	}
+	return pattern
+}
+
+func NewRuleSet(revision int, rules []models.RULE) *RuleSet {
+	ruleSet := &RuleSet{Revision: revision, Rules: rules, Patterns: []util_model.SecretPattern{}, severities: map[string]string{}}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		pattern := rulePattern(rule)
+		compilePattern(&pattern)
+		ruleSet.Patterns = append(ruleSet.Patterns, pattern)
+		if rule.Severity != "" {
+			ruleSet.severities[rule.Name] = rule.Severity
+		}
+	}
+	ruleSet.Version = HashRuleSet(ruleSet.Patterns)
+	ruleSet.pathDependent = patternsPathDependent(ruleSet.Patterns)
+	return ruleSet
+}
+
+// rule ne apni severity di ho to wahi, warna secret type wala default
+func (r *RuleSet) Severity(secretType string) string {
+	return r.severities[secretType]
+}
+
+func (r *RuleSet) Rule(id string) (models.RULE, bool) {
+	for _, rule := range r.Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return models.RULE{}, false
+}
+
+// Mongo se latest revision; koi revision nahi to built-in rules
+func LoadActiveRuleSet() error {
+	latest, err := latestRuleSet()
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		activeRuleSet.Store(nil)
+		return nil
+	}
+	current := ActiveRuleSet()
+	if current.Revision == latest.Revision && current.Version == latest.Version {
+		return nil
+	}
+	ruleSet := NewRuleSet(latest.Revision, latest.Rules)
+	activeRuleSet.Store(ruleSet)
+	log.Printf("op=LoadActiveRuleSet stage=reloaded revision=%d version=%s rules=%d enabled=%d", ruleSet.Revision, ruleSet.Version, len(ruleSet.Rules), len(ruleSet.Patterns))
+	return nil
+}
+
+// dusre instances pe saved revision bhi kuch der me yahan aa jaaye
+func WatchRuleSets() {
+	interval, err := time.ParseDuration(GetEnv("RULES_RELOAD_INTERVAL", "30s"))
+	if err != nil || interval <= 0 {
+		log.Printf("op=WatchRuleSets stage=disabled interval=%q", GetEnv("RULES_RELOAD_INTERVAL", "30s"))
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := LoadActiveRuleSet(); err != nil {
+			log.Printf("op=WatchRuleSets stage=reload_error error=%v", err)
+		}
+	}
+}
+
+// saved revisions kahan rehti hai; default Mongo, tests memory wala store lagate hai
+type ruleSetStore interface {
+	Latest() (*models.RULE_SET, error)
+	Find(revision int) (*models.RULE_SET, error)
+	Create(ruleSet *models.RULE_SET) error
+}
+
+var ruleSets ruleSetStore = mongoRuleSetStore{}
+
+type mongoRuleSetStore struct{}
+
+func (mongoRuleSetStore) Latest() (*models.RULE_SET, error) {
+	latest := &models.RULE_SET{}
+	opts := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}})
+	err := mgm.Coll(latest).FindOne(mgm.Ctx(), bson.M{}, opts).Decode(latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+func (mongoRuleSetStore) Find(revision int) (*models.RULE_SET, error) {
+	ruleSet := &models.RULE_SET{}
+	if err := mgm.Coll(ruleSet).First(bson.M{"revision": revision}, ruleSet); err != nil {
+		return nil, err
+	}
+	return ruleSet, nil
+}
+
+func (mongoRuleSetStore) Create(ruleSet *models.RULE_SET) error {
+	return mgm.Coll(ruleSet).Create(ruleSet)
+}
+
+func latestRuleSet() (*models.RULE_SET, error) {
+	return ruleSets.Latest()
+}
+
+func FindRuleSet(revision int) (*models.RULE_SET, error) {
+	return ruleSets.Find(revision)
+}
+
+// do instances ek hi revision likhne ki koshish kare to ek hi jeete, doosra latest dobara padh ke retry kare
+const ruleSetSaveAttempts = 5
+
+// revision pe unique index; iske bina alag processes ke saves ek hi revision number le sakte hai
+func EnsureRuleSetIndexes() error {
+	_, err := mgm.Coll(&models.RULE_SET{}).Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// latest revision ke rules pe change lagao, poora set validate karo, nayi revision save karke turant active karo;
+// mutex sirf is process ke saves serialize karta hai, dusre instance se takraav unique index pakadta hai
+func SaveRuleSet(change string, apply func(rules []models.RULE) ([]models.RULE, error)) (*models.RULE_SET, error) {
+	return saveRuleSet(change, func(_ *models.RULE_SET, rules []models.RULE) ([]models.RULE, *models.RULES_FILE_STATE, error) {
+		rules, err := apply(rules)
+		return rules, nil, err
+	})
+}
+
+// apply nayi rules file state de to wahi save hoti hai, warna latest wali aage chalti hai
+func saveRuleSet(change string, apply func(latest *models.RULE_SET, rules []models.RULE) ([]models.RULE, *models.RULES_FILE_STATE, error)) (*models.RULE_SET, error) {
+	ruleSetSaveMu.Lock()
+	defer ruleSetSaveMu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		latest, err := latestRuleSet()
+		if err != nil {
+			return nil, err
+		}
+		revision := 0
+		rules := BuiltinRules()
+		// built-in rules me rules file pehle se merged hai, pehli revision usi state se shuru hoti hai
+		rulesFile := loadedRulesFileState()
+		if latest != nil {
+			revision = latest.Revision
+			rules = latest.Rules
+			rulesFile = latest.RulesFile
+		}
+
+		rules, appliedFile, err := apply(latest, append([]models.RULE(nil), rules...))
+		if err != nil {
+			return nil, err
+		}
+		if appliedFile != nil {
+			rulesFile = appliedFile
+		}
+		if err := ValidateRuleSet(rules); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRuleSet, err)
+		}
+
+		active := NewRuleSet(revision+1, rules)
+		saved := &models.RULE_SET{Revision: active.Revision, Version: active.Version, Rules: rules, Change: change, RulesFile: rulesFile}
+		if err := ruleSets.Create(saved); err != nil {
+			// kisi aur instance ne ye revision abhi save kiya; change uske rules pe dobara lagega
+			if mongo.IsDuplicateKeyError(err) && attempt < ruleSetSaveAttempts {
+				log.Printf("op=SaveRuleSet stage=revision_conflict revision=%d attempt=%d", active.Revision, attempt)
+				continue
+			}
+			return nil, err
+		}
+		activeRuleSet.Store(active)
+		log.Printf("op=SaveRuleSet stage=activated revision=%d version=%s change=%q rules=%d enabled=%d", active.Revision, active.Version, change, len(rules), len(active.Patterns))
+		return saved, nil
+	}
+}
+
+// save ke time poore set pe: ids unique, har rule valid
+func ValidateRuleSet(rules []models.RULE) error {
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if seen[rule.ID] {
+			return fmt.Errorf("duplicate rule id %q", rule.ID)
+		}
+		seen[rule.ID] = true
+		if err := ValidateRule(rule); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+// regex compile ho, severity sahi ho, aur tests pass ho: match wale samples me secret mile, no_match wale me na mile
+func ValidateRule(rule models.RULE) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if rule.ID != SARIFRuleID(rule.Name) {
+		return fmt.Errorf("id must be %q for name %q", SARIFRuleID(rule.Name), rule.Name)
+	}
+	if rule.Regex == "" {
+		return fmt.Errorf("regex is required")
+	}
+	re, err := regexp.Compile(rule.Regex)
+	if err != nil {
+		return fmt.Errorf("regex does not compile: %v", err)
+	}
+	// khaali match wala regex har jagah "secret" dhoondh lega
+	if re.MatchString("") {
+		return fmt.Errorf("regex matches the empty string")
+	}
+	if rule.Severity != "" && SeverityRank(rule.Severity) == 0 {
+		return fmt.Errorf("invalid severity %q: use %s, %s or %s", rule.Severity, SeverityLow, SeverityMedium, SeverityHigh)
+	}
+	for _, keyword := range rule.Keywords {
+		if strings.TrimSpace(keyword) == "" {
+			return fmt.Errorf("keywords must not be empty")
+		}
+	}
+	if rule.SecretGroup < 0 || rule.SecretGroup > re.NumSubexp() {
+		return fmt.Errorf("secret_group %d out of range: regex has %d groups", rule.SecretGroup, re.NumSubexp())
+	}
+	// Shannon entropy (bits per character) 8 se upar ho hi nahi sakti
+	if rule.Entropy < 0 || rule.Entropy > 8 {
+		return fmt.Errorf("entropy %v out of range 0-8", rule.Entropy)
+	}
+	if rule.Path != "" {
+		if _, err := regexp.Compile(rule.Path); err != nil {
+			return fmt.Errorf("path does not compile: %v", err)
+		}
+	}
+	for i, allowlist := range rule.Allowlists {
+		if err := validateAllowlist(allowlist); err != nil {
+			return fmt.Errorf("allowlists[%d]: %w", i, err)
+		}
+	}
+
+	// samples file path ke bina hai, isliye tests me path filter nahi lagta
+	pattern := rulePattern(rule)
+	pattern.Path = ""
+	for i, sample := range rule.Tests.Match {
+		if len(findSecrets(pattern, re, sample, matchTarget{})) == 0 {
+			return fmt.Errorf("tests.match[%d] did not match", i)
+		}
+	}
+	for i, sample := range rule.Tests.NoMatch {
+		if len(findSecrets(pattern, re, sample, matchTarget{})) > 0 {
+			return fmt.Errorf("tests.no_match[%d] matched", i)
+		}
+	}
+	return nil
+}
+
+func validateAllowlist(allowlist models.RULE_ALLOWLIST) error {
+	switch strings.ToUpper(allowlist.Condition) {
+	case "", "OR", "AND":
+	default:
+		return fmt.Errorf("invalid condition %q: use OR or AND", allowlist.Condition)
+	}
+	switch allowlist.RegexTarget {
+	case "", "secret", "match", "line":
+	default:
+		return fmt.Errorf("invalid regex_target %q: use secret, match or line", allowlist.RegexTarget)
+	}
+	if len(allowlist.Commits)+len(allowlist.Paths)+len(allowlist.Regexes)+len(allowlist.StopWords) == 0 {
+		return fmt.Errorf("needs at least one of commits, paths, regexes or stopwords")
+	}
+	for _, expr := range append(append([]string(nil), allowlist.Paths...), allowlist.Regexes...) {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("%q does not compile: %v", expr, err)
+		}
+	}
+	return nil
+}
+
+// kisi enabled rule me path wali condition ho to same content ka outcome file path pe bhi depend karta hai
+func (r *RuleSet) PathDependent() bool {
+	return r.pathDependent
+}
+
+// commit allowlist ho to same blob ka outcome commit pe bhi depend karta hai
+func patternsCommitDependent(patterns []util_model.SecretPattern) bool {
+	for _, pattern := range patterns {
+		for _, allowlist := range pattern.Allowlists {
+			if len(allowlist.Commits) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func patternsPathDependent(patterns []util_model.SecretPattern) bool {
+	for _, pattern := range patterns {
+		if pattern.Path != "" {
+			return true
+		}
+		for _, allowlist := range pattern.Allowlists {
+			if len(allowlist.Paths) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	util_model "github.com/MishraShardendu22/Scanner/util/model"
+)
+
+func TestNewRuleSetCompilesPatterns(t *testing.T) {
+	rules := []models.RULE{{
+		ID: "test-token", Name: "Test Token", Regex: `tok_[a-z0-9]{12}`, Path: `\.env$`, Enabled: true,
+		Allowlists: []models.RULE_ALLOWLIST{{Paths: []string{`^fixtures/`}, Regexes: []string{`^tok_0+$`, `example`}}},
+	}, {
+		ID: "off", Name: "Off", Regex: `off_[a-z]+`,
+	}}
+	ruleSet := NewRuleSet(1, rules)
+	if len(ruleSet.Patterns) != 1 {
+		t.Fatalf("patterns = %d, want only the enabled rule", len(ruleSet.Patterns))
+	}
+	pattern := ruleSet.Patterns[0]
+	if pattern.Compiled == nil || pattern.PathCompiled == nil {
+		t.Fatalf("pattern not compiled: %+v", pattern)
+	}
+	allowlist := pattern.Allowlists[0]
+	if len(allowlist.PathsCompiled) != 1 || len(allowlist.RegexesCompiled) != 2 {
+		t.Fatalf("allowlist not compiled: %+v", allowlist)
+	}
+
+	// compiled regexes version hash me nahi jaate
+	uncompiled := rulePattern(rules[0])
+	if ruleSet.Version != HashRuleSet([]util_model.SecretPattern{uncompiled}) {
+		t.Error("compiling patterns changed the rule set version")
+	}
+
+	tests := []struct {
+		name string
+		file string
+		line string
+		want int
+	}{
+		{"match", "app/.env", "TOKEN=tok_abcdef123456", 1},
+		{"path filter", "app/config.py", "TOKEN=tok_abcdef123456", 0},
+		{"allowlisted path", "fixtures/.env", "TOKEN=tok_abcdef123456", 0},
+		{"allowlisted secret", "app/.env", "TOKEN=tok_000000000000", 0},
+	}
+	for _, tt := range tests {
+		findings := ScanFile(models.SIBLING{RFilename: tt.file, FileContent: tt.line}, ruleSet.Patterns, "models", "org/repo")
+		if len(findings) != tt.want {
+			t.Errorf("%s: findings = %d, want %d", tt.name, len(findings), tt.want)
+		}
+	}
+}
+
+func TestCachedRegexpBounded(t *testing.T) {
+	for i := 0; i < regexpCacheMax*2; i++ {
+		cachedRegexp(fmt.Sprintf("bounded_%d", i))
+	}
+	regexpCacheMu.Lock()
+	size := len(regexpCache)
+	regexpCacheMu.Unlock()
+	if size > regexpCacheMax {
+		t.Errorf("regexp cache has %d entries, cap %d", size, regexpCacheMax)
+	}
+	if re := cachedRegexp(`(`); re.MatchString("(") || re.MatchString("") {
+		t.Error("invalid regex should never match")
+	}
+}
+
+// Mongo ke bina SaveRuleSet chalane ke liye
+type memoryRuleSetStore struct {
+	revisions []*models.RULE_SET
+}
+
+func (s *memoryRuleSetStore) Latest() (*models.RULE_SET, error) {
+	if len(s.revisions) == 0 {
+		return nil, nil
+	}
+	return s.revisions[len(s.revisions)-1], nil
+}
+
+func (s *memoryRuleSetStore) Find(revision int) (*models.RULE_SET, error) {
+	for _, ruleSet := range s.revisions {
+		if ruleSet.Revision == revision {
+			return ruleSet, nil
+		}
+	}
+	return nil, ErrRuleNotFound
+}
+
+func (s *memoryRuleSetStore) Create(ruleSet *models.RULE_SET) error {
+	s.revisions = append(s.revisions, ruleSet)
+	return nil
+}
+
+func withMemoryRuleSets(t *testing.T) *memoryRuleSetStore {
+	t.Helper()
+	store := &memoryRuleSetStore{}
+	previousStore, previousRules, previousHash := ruleSets, rulesFileRules, rulesFileHash
+	ruleSets = store
+	t.Cleanup(func() {
+		ruleSets, rulesFileRules, rulesFileHash = previousStore, previousRules, previousHash
+		activeRuleSet.Store(nil)
+	})
+	return store
+}
+
+func TestMergeRulesFileKeepsAPIChanges(t *testing.T) {
+	store := withMemoryRuleSets(t)
+	// file aane se pehle ki saved revision
+	if _, err := SaveRuleSet("seed", func(rules []models.RULE) ([]models.RULE, error) { return rules, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	loadFile := func(content string) {
+		rulesFileRules = []models.RULE{
+			{ID: "acme-token", Name: "Acme Token", Regex: `acme_[a-z0-9]{` + content + `}`, Enabled: true, Source: "rules.toml"},
+		}
+		rulesFileHash = sha256Hex(content)
+	}
+	acmeRule := func() models.RULE {
+		latest, _ := store.Latest()
+		for _, rule := range latest.Rules {
+			if rule.ID == "acme-token" {
+				return rule
+			}
+		}
+		t.Fatalf("acme-token missing from revision %d", latest.Revision)
+		return models.RULE{}
+	}
+
+	loadFile("16")
+	if saved, err := MergeRulesFileIntoSaved(); err != nil || saved == nil {
+		t.Fatalf("first merge = %v, %v; want a new revision", saved, err)
+	}
+	if _, err := SaveRuleSet("update acme-token", func(rules []models.RULE) ([]models.RULE, error) {
+		for i := range rules {
+			if rules[i].ID == "acme-token" {
+				rules[i].Enabled = false
+			}
+		}
+		return rules, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	revisions := len(store.revisions)
+
+	// restart, same file: kuch nahi badalta
+	saved, err := MergeRulesFileIntoSaved()
+	if err != nil || saved != nil {
+		t.Fatalf("merge of the same file = %v, %v; want no new revision", saved, err)
+	}
+	if len(store.revisions) != revisions || acmeRule().Enabled {
+		t.Fatalf("same file re-applied: revisions %d -> %d, enabled=%t", revisions, len(store.revisions), acmeRule().Enabled)
+	}
+
+	// file badli: nayi revision banti hai par API se disabled rule waisa hi rehta hai
+	loadFile("20")
+	if saved, err := MergeRulesFileIntoSaved(); err != nil || saved == nil {
+		t.Fatalf("merge of a changed file = %v, %v; want a new revision", saved, err)
+	}
+	if rule := acmeRule(); rule.Enabled || rule.Regex != `acme_[a-z0-9]{16}` {
+		t.Errorf("rule after changed file = %+v, want the disabled API version", rule)
+	}
+}
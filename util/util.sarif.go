@@ -0,0 +1,380 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	util_model "github.com/MishraShardendu22/Scanner/util/model"
+)
+
+// SARIF 2.1.0 (security tooling / code scanning isi ko ingest karta hai); sirf wahi fields jo hum bharte hai
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// ek hi resource ho to files repo root ke relative, jaisa code scanning expect karta hai
+	sarifSourceRoot = "%SRCROOT%"
+)
+
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool               SARIFTool                        `json:"tool"`
+	AutomationDetails  *SARIFAutomationDetails          `json:"automationDetails,omitempty"`
+	OriginalURIBaseIDs map[string]SARIFArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []SARIFResult                    `json:"results"`
+	Properties         map[string]any                   `json:"properties,omitempty"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     SARIFMessage           `json:"shortDescription"`
+	FullDescription      SARIFMessage           `json:"fullDescription"`
+	Help                 SARIFMessage           `json:"help"`
+	DefaultConfiguration SARIFRuleConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]any         `json:"properties,omitempty"`
+}
+
+type SARIFRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type SARIFAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type SARIFResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             SARIFMessage      `json:"message"`
+	Locations           []SARIFLocation   `json:"locations"`
+	Fingerprints        map[string]string `json:"fingerprints"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+type SARIFArtifactLocation struct {
+	URI         string        `json:"uri,omitempty"`
+	URIBaseID   string        `json:"uriBaseId,omitempty"`
+	Description *SARIFMessage `json:"description,omitempty"`
+}
+
+type SARIFRegion struct {
+	StartLine  int            `json:"startLine"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+type SARIFLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+var sarifRuleIDCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// "AWS Access Key ID" -> "aws-access-key-id"; rule id stable rehni chahiye, code scanning alerts isi pe track hote hai
+func SARIFRuleID(secretType string) string {
+	return strings.Trim(sarifRuleIDCleaner.ReplaceAllString(strings.ToLower(secretType), "-"), "-")
+}
+
+// collide hui id + naam ke hash ka hissa; naam wahi to id wahi, export ke order pe nirbhar nahi
+func sarifDisambiguatedRuleID(id, secretType string) string {
+	return id + "-" + sha256Hex(secretType)[:8]
+}
+
+// high -> error, medium -> warning, low -> note
+func SARIFLevel(severity string) string {
+	switch severity {
+	case SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
+
+// code scanning "security-severity" 0-10 ke score se alert ki severity dikhata hai
+func sarifSecuritySeverity(severity string) string {
+	switch severity {
+	case SeverityHigh:
+		return "8.9"
+	case SeverityMedium:
+		return "6.5"
+	}
+	return "3.0"
+}
+
+func sarifRule(secretType, regex string) SARIFRule {
+	severity := FindingSeverity(secretType)
+	return SARIFRule{
+		ID:               SARIFRuleID(secretType),
+		Name:             secretType,
+		ShortDescription: SARIFMessage{Text: secretType + " detected"},
+		FullDescription:  SARIFMessage{Text: fmt.Sprintf("A value matching the %s pattern was found. Leaked credentials can be used by anyone who can read the resource.", secretType)},
+		Help: SARIFMessage{
+			Text:     fmt.Sprintf("Revoke and rotate the %s, then remove it from the file and from history (older commits, layers or object versions still expose it). Pattern: %s", secretType, regex),
+			Markdown: fmt.Sprintf("Revoke and rotate the **%s**, then remove it from the file and from history (older commits, layers or object versions still expose it).\n\nPattern: `%s`", secretType, regex),
+		},
+		DefaultConfiguration: SARIFRuleConfiguration{Level: SARIFLevel(severity)},
+		Properties: map[string]any{
+			"tags":              []string{"security", "secret"},
+			"precision":         "medium",
+			"severity":          severity,
+			"security-severity": sarifSecuritySeverity(severity),
+			"pattern":           regex,
+		},
+	}
+}
+
+// findings -> ek SARIF run; har pattern ek rule, pattern set me na ho aisi finding (purane / custom rules) ke liye rule finding se banta hai.
+// automationID (jaise "scan/<scan_id>/") code scanning me ek scan ko alag category deta hai; message me secret display policy ke hisaab se
+func BuildSARIF(findings []models.Finding, patterns []util_model.SecretPattern, automationID, secretDisplay string) SARIFLog {
+	rules := []SARIFRule{}
+	// rule secret type ke naam se; "AWS Key" aur "aws-key" jaise do naam ek hi id pe aaye to doosre ko naam ke hash wala suffix,
+	// warna dono ke results ek rule (aur ek naam / pattern) ke neeche chale jaate
+	ruleIndex := map[string]int{}
+	ruleNames := map[string]string{}
+	addRule := func(secretType, regex string) int {
+		if index, ok := ruleIndex[secretType]; ok {
+			return index
+		}
+		rule := sarifRule(secretType, regex)
+		if other, taken := ruleNames[rule.ID]; taken {
+			id := sarifDisambiguatedRuleID(rule.ID, secretType)
+			log.Printf("op=BuildSARIF stage=rule_id_collision rule_id=%s secret_type=%q other=%q id=%s", rule.ID, secretType, other, id)
+			rule.ID = id
+		}
+		ruleNames[rule.ID] = secretType
+		ruleIndex[secretType] = len(rules)
+		rules = append(rules, rule)
+		return ruleIndex[secretType]
+	}
+	for _, pattern := range patterns {
+		addRule(pattern.Name, pattern.Regex)
+	}
+
+	// har resource ka apna uri base, files unke relative
+	baseIDs := map[string]string{}
+	originalBases := map[string]SARIFArtifactLocation{}
+	for _, finding := range findings {
+		key := sarifResourceKey(finding)
+		if _, ok := baseIDs[key]; !ok {
+			baseIDs[key] = fmt.Sprintf("RESOURCE_%d", len(baseIDs)+1)
+			originalBases[baseIDs[key]] = SARIFArtifactLocation{
+				URI:         sarifResourceBaseURI(finding),
+				Description: &SARIFMessage{Text: strings.TrimSpace(finding.Provider + " " + finding.ResourceType + " " + finding.ResourceID)},
+			}
+		}
+	}
+	if len(baseIDs) == 1 {
+		for key, id := range baseIDs {
+			originalBases = map[string]SARIFArtifactLocation{sarifSourceRoot: originalBases[id]}
+			baseIDs[key] = sarifSourceRoot
+		}
+	}
+
+	results := []SARIFResult{}
+	for _, finding := range findings {
+		index := addRule(finding.SecretType, finding.Pattern)
+		severity := SeverityOf(finding)
+
+		message := fmt.Sprintf("%s found in %s", finding.SecretType, FindingLocationText(finding))
+		if secret := DisplaySecret(finding.Secret, secretDisplay); secret != "" {
+			message += ": " + secret
+		}
+		results = append(results, SARIFResult{
+			RuleID:              rules[index].ID,
+			RuleIndex:           index,
+			Level:               SARIFLevel(severity),
+			Message:             SARIFMessage{Text: message},
+			Locations:           []SARIFLocation{sarifLocation(finding, baseIDs[sarifResourceKey(finding)])},
+			Fingerprints:        map[string]string{"scanner/v1": FindingFingerprint(finding)},
+			PartialFingerprints: map[string]string{"secretHash/v1": sha256Hex(finding.SecretType + "\x00" + finding.Secret)},
+			Properties:          sarifResultProperties(finding, severity),
+		})
+	}
+
+	run := SARIFRun{
+		Tool: SARIFTool{Driver: SARIFDriver{
+			Name:           "Scanner",
+			InformationURI: "https://github.com/MishraShardendu22/Scanner",
+			Version:        HashRuleSet(patterns),
+			Rules:          rules,
+		}},
+		Results: results,
+	}
+	if len(originalBases) > 0 {
+		run.OriginalURIBaseIDs = originalBases
+	}
+	if automationID != "" {
+		run.AutomationDetails = &SARIFAutomationDetails{ID: automationID}
+	}
+	return SARIFLog{Version: SARIFVersion, Schema: SARIFSchema, Runs: []SARIFRun{run}}
+}
+
+// same secret same jagah pe -> same fingerprint, line badalne se alert dobara nahi khulta;
+// import me tool ka diya (ya uske hisaab se bana) fingerprint pehle se set hota hai
+func FindingFingerprint(finding models.Finding) string {
+	if finding.Fingerprint != "" {
+		return finding.Fingerprint
+	}
+	return sha256Hex(strings.Join([]string{
+		finding.Provider, finding.ResourceType, finding.ResourceID,
+		finding.SourceType, finding.FileName,
+		fmt.Sprint(finding.DiscussionNum), finding.CommentID, fmt.Sprint(finding.PRNum),
+		finding.SecretType, finding.Secret,
+	}, "\x00"))
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func sarifResourceKey(finding models.Finding) string {
+	return finding.Provider + ":" + finding.ResourceType + ":" + finding.ResourceID
+}
+
+// local directory ka file:// base; remote resources ke liye base sirf description se (file urls properties me)
+func sarifResourceBaseURI(finding models.Finding) string {
+	if finding.Provider == ProviderLocal {
+		return (&url.URL{Scheme: "file", Path: strings.TrimSuffix(finding.ResourceID, "/") + "/"}).String()
+	}
+	return ""
+}
+
+// files (HEAD, PR diff, history) physical location; discussions aur image config / history logical
+func sarifLocation(finding models.Finding, baseID string) SARIFLocation {
+	switch finding.SourceType {
+	case "file", "pr", "history":
+		location := SARIFLocation{PhysicalLocation: &SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: sarifPathURI(finding.FileName), URIBaseID: baseID},
+		}}
+		if finding.Line > 0 {
+			location.PhysicalLocation.Region = &SARIFRegion{StartLine: finding.Line}
+			if finding.Cell > 0 {
+				location.PhysicalLocation.Region.Properties = map[string]any{"cell": finding.Cell, "cell_line": finding.CellLine}
+			}
+		}
+		if finding.Cell > 0 {
+			location.LogicalLocations = []SARIFLogicalLocation{{
+				Name:               fmt.Sprintf("cell %d", finding.Cell),
+				FullyQualifiedName: fmt.Sprintf("%s/cell %d", finding.FileName, finding.Cell),
+			}}
+		}
+		return location
+	case "discussion":
+		name := fmt.Sprintf("discussion #%d", finding.DiscussionNum)
+		if finding.PRNum != 0 {
+			name = fmt.Sprintf("pull request #%d", finding.PRNum)
+		}
+		fullName := name
+		if finding.CommentID != "" {
+			fullName = fmt.Sprintf("%s/comment %s", name, finding.CommentID)
+		}
+		return SARIFLocation{LogicalLocations: []SARIFLogicalLocation{{Name: name, FullyQualifiedName: fullName, Kind: "object"}}}
+	}
+	// image_config / image_history: "config.Env" ki n-th value
+	return SARIFLocation{LogicalLocations: []SARIFLogicalLocation{{
+		Name:               finding.FileName,
+		FullyQualifiedName: fmt.Sprintf("%s[%d]", finding.FileName, finding.Line),
+		Kind:               "member",
+	}}}
+}
+
+// relative path ke segments escape, "/" waise hi
+func sarifPathURI(fileName string) string {
+	return (&url.URL{Path: strings.TrimPrefix(fileName, "/")}).EscapedPath()
+}
+
+// finding kahan mili, ek line me (SARIF message, JUnit testcase, reports)
+func FindingLocationText(finding models.Finding) string {
+	switch finding.SourceType {
+	case "discussion":
+		if finding.PRNum != 0 {
+			return fmt.Sprintf("pull request #%d", finding.PRNum)
+		}
+		return fmt.Sprintf("discussion #%d", finding.DiscussionNum)
+	case "pr":
+		return fmt.Sprintf("%s:%d (pull request #%d)", finding.FileName, finding.Line, finding.PRNum)
+	case "history":
+		return fmt.Sprintf("%s:%d (commit %.12s)", finding.FileName, finding.Line, finding.CommitSHA)
+	case "image_config", "image_history":
+		return fmt.Sprintf("%s[%d]", finding.FileName, finding.Line)
+	}
+	if finding.Cell > 0 && finding.CellLine > 0 {
+		return fmt.Sprintf("%s cell %d line %d", finding.FileName, finding.Cell, finding.CellLine)
+	}
+	if finding.Cell > 0 {
+		return fmt.Sprintf("%s cell %d", finding.FileName, finding.Cell)
+	}
+	if finding.Line == 0 {
+		return finding.FileName
+	}
+	return fmt.Sprintf("%s:%d", finding.FileName, finding.Line)
+}
+
+// resource / commit / layer jaisi cheezein jinke liye SARIF me field nahi hai
+func sarifResultProperties(finding models.Finding, severity string) map[string]any {
+	properties := map[string]any{
+		"severity":    severity,
+		"source_type": finding.SourceType,
+	}
+	values := map[string]string{
+		"provider":      finding.Provider,
+		"resource_type": finding.ResourceType,
+		"resource_id":   finding.ResourceID,
+		"url":           finding.URL,
+		"commit_sha":    finding.CommitSHA,
+		"version_id":    finding.VersionID,
+		"layer_digest":  finding.LayerDigest,
+		"first_commit":  finding.FirstCommit,
+		"last_commit":   finding.LastCommit,
+	}
+	for key, value := range values {
+		if value != "" {
+			properties[key] = value
+		}
+	}
+	if finding.StillAtHead != nil {
+		properties["still_at_head"] = *finding.StillAtHead
+	}
+	return properties
+}
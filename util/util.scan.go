@@ -2,6 +2,7 @@ package util
 
 import (
 	"log"
+	"math"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -12,6 +13,234 @@ import (
 	util_model "github.com/MishraShardendu22/Scanner/util/model"
 )
 
+// match kis jagah ka hai: path rules aur allowlists isi pe chalte hai
+type matchTarget struct {
+	Path   string
+	Commit string
+}
+
+// rule ke keywords me se koi text me ho (ya keywords hi na ho) tabhi regex chalta hai;
+// secret group, entropy aur allowlists ke baad jo bache wahi secrets
+func findSecrets(pattern util_model.SecretPattern, re *regexp.Regexp, text string, target matchTarget) []string {
+	if pattern.Path != "" && (target.Path == "" || !patternPathRegexp(pattern).MatchString(target.Path)) {
+		return nil
+	}
+	if len(pattern.Keywords) > 0 {
+		lower := strings.ToLower(text)
+		found := false
+		for _, keyword := range pattern.Keywords {
+			if strings.Contains(lower, strings.ToLower(keyword)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	var secrets []string
+	for _, groups := range re.FindAllStringSubmatch(text, -1) {
+		match := groups[0]
+		secret := match
+		// gitleaks jaisa: secret group diya ho to wo, warna pehla non-empty group
+		if len(groups) > 1 {
+			if pattern.SecretGroup > 0 && pattern.SecretGroup < len(groups) {
+				secret = groups[pattern.SecretGroup]
+			} else {
+				for _, group := range groups[1:] {
+					if group != "" {
+						secret = group
+						break
+					}
+				}
+			}
+		}
+		if secret == "" {
+			continue
+		}
+		if pattern.Entropy > 0 && ShannonEntropy(secret) < pattern.Entropy {
+			continue
+		}
+		if allowlisted(pattern.Allowlists, secret, match, text, target) {
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+// har character kitne bits ka hai; random keys ~4-6, normal words / placeholders kam
+func ShannonEntropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	total := 0
+	for _, char := range value {
+		counts[char]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func allowlisted(allowlists []util_model.PatternAllowlist, secret, match, line string, target matchTarget) bool {
+	for _, allowlist := range allowlists {
+		var checks []bool
+		if len(allowlist.Commits) > 0 {
+			hit := false
+			for _, commit := range allowlist.Commits {
+				if target.Commit != "" && strings.EqualFold(commit, target.Commit) {
+					hit = true
+					break
+				}
+			}
+			checks = append(checks, hit)
+		}
+		if len(allowlist.Paths) > 0 {
+			hit := false
+			for i := range allowlist.Paths {
+				if target.Path != "" && allowlistRegexp(allowlist.PathsCompiled, allowlist.Paths, i).MatchString(target.Path) {
+					hit = true
+					break
+				}
+			}
+			checks = append(checks, hit)
+		}
+		if len(allowlist.Regexes) > 0 {
+			subject := secret
+			switch allowlist.RegexTarget {
+			case "match":
+				subject = match
+			case "line":
+				subject = line
+			}
+			hit := false
+			for i := range allowlist.Regexes {
+				if allowlistRegexp(allowlist.RegexesCompiled, allowlist.Regexes, i).MatchString(subject) {
+					hit = true
+					break
+				}
+			}
+			checks = append(checks, hit)
+		}
+		if len(allowlist.StopWords) > 0 {
+			hit := false
+			lower := strings.ToLower(secret)
+			for _, word := range allowlist.StopWords {
+				if strings.Contains(lower, strings.ToLower(word)) {
+					hit = true
+					break
+				}
+			}
+			checks = append(checks, hit)
+		}
+		if len(checks) == 0 {
+			continue
+		}
+
+		all, any := true, false
+		for _, hit := range checks {
+			all = all && hit
+			any = any || hit
+		}
+		if (strings.EqualFold(allowlist.Condition, "AND") && all) || (!strings.EqualFold(allowlist.Condition, "AND") && any) {
+			return true
+		}
+	}
+	return false
+}
+
+// sirf fallback ke liye (SecretConfig / tests ke patterns); bhar jaaye to reset, taaki
+// bahut saare alag regexes se memory na badhti rahe
+const regexpCacheMax = 1024
+
+var (
+	regexpCacheMu sync.Mutex
+	regexpCache   = map[string]*regexp.Regexp{}
+	neverMatch    = regexp.MustCompile(`[^\s\S]`)
+)
+
+// save ke time validate ho chuke hai, phir bhi galat regex kuch match nahi karta
+func compileOrNever(expr string) *regexp.Regexp {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return neverMatch
+	}
+	return re
+}
+
+func cachedRegexp(expr string) *regexp.Regexp {
+	regexpCacheMu.Lock()
+	re, ok := regexpCache[expr]
+	regexpCacheMu.Unlock()
+	if ok {
+		return re
+	}
+	re = compileOrNever(expr)
+	regexpCacheMu.Lock()
+	if len(regexpCache) >= regexpCacheMax {
+		regexpCache = map[string]*regexp.Regexp{}
+	}
+	regexpCache[expr] = re
+	regexpCacheMu.Unlock()
+	return re
+}
+
+// rule set load pe pattern ke saare regexes (secret, path, allowlists) ek baar compile
+func compilePattern(pattern *util_model.SecretPattern) {
+	pattern.Compiled = compileOrNever(pattern.Regex)
+	if pattern.Path != "" {
+		pattern.PathCompiled = compileOrNever(pattern.Path)
+	}
+	for i := range pattern.Allowlists {
+		allowlist := &pattern.Allowlists[i]
+		allowlist.PathsCompiled = make([]*regexp.Regexp, len(allowlist.Paths))
+		for j, expr := range allowlist.Paths {
+			allowlist.PathsCompiled[j] = compileOrNever(expr)
+		}
+		allowlist.RegexesCompiled = make([]*regexp.Regexp, len(allowlist.Regexes))
+		for j, expr := range allowlist.Regexes {
+			allowlist.RegexesCompiled[j] = compileOrNever(expr)
+		}
+	}
+}
+
+func patternRegexp(pattern util_model.SecretPattern) *regexp.Regexp {
+	if pattern.Compiled != nil {
+		return pattern.Compiled
+	}
+	return cachedRegexp(pattern.Regex)
+}
+
+func patternPathRegexp(pattern util_model.SecretPattern) *regexp.Regexp {
+	if pattern.PathCompiled != nil {
+		return pattern.PathCompiled
+	}
+	return cachedRegexp(pattern.Path)
+}
+
+func allowlistRegexp(compiled []*regexp.Regexp, exprs []string, i int) *regexp.Regexp {
+	if len(compiled) == len(exprs) {
+		return compiled[i]
+	}
+	return cachedRegexp(exprs[i])
+}
+
+// scan loops ke bahar ek baar, har line pe lookup na ho
+func patternRegexps(patterns []util_model.SecretPattern) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = patternRegexp(pattern)
+	}
+	return compiled
+}
+
 func ScanFile(file models.SIBLING, patterns []util_model.SecretPattern, resourceType, resourceID string) []models.Finding {
 
 	ext := strings.ToLower(filepath.Ext(file.RFilename))
@@ -25,12 +254,26 @@ func ScanFile(file models.SIBLING, patterns []util_model.SecretPattern, resource
 
 	organization := ExtractOrgFromResourceID(resourceID)
 
+	// image layer ki file: baad ki layer ne hata di ho to bhi secret image me (purani layer me) pada hai
+	var stillAtHead *bool
+	if file.LayerDigest != "" {
+		present := !file.Shadowed
+		stillAtHead = &present
+	}
+
+	// notebook me JSON ki line se zyada kaam ka cell number hai
+	var cells []NotebookCell
+	if ext == ".ipynb" {
+		cells = NotebookCells(file.FileContent)
+	}
+
+	compiled := patternRegexps(patterns)
 	for i, line := range lines {
-		for _, pattern := range patterns {
-			re := regexp.MustCompile(pattern.Regex)
-			matches := re.FindAllString(line, -1)
+		for p, pattern := range patterns {
+			matches := findSecrets(pattern, compiled[p], line, matchTarget{Path: file.RFilename})
 			for _, match := range matches {
 				lineNum := i + 1
+				cell, cellLine := NotebookLocation(cells, lineNum, match)
 				findings = append(findings, models.Finding{
 					SecretType:   pattern.Name,
 					Pattern:      pattern.Regex,
@@ -41,6 +284,11 @@ func ScanFile(file models.SIBLING, patterns []util_model.SecretPattern, resource
 					ResourceType: resourceType,
 					FileName:     file.RFilename,
 					Line:         lineNum,
+					Cell:         cell,
+					CellLine:     cellLine,
+					VersionID:    file.VersionID,
+					LayerDigest:  file.LayerDigest,
+					StillAtHead:  stillAtHead,
 					URL:          BuildHuggingFaceFileURL(resourceType, resourceID, file.RFilename, lineNum),
 				})
 			}
@@ -58,9 +306,15 @@ func ScanDiscussion(disc models.DISCUSSION, patterns []util_model.SecretPattern,
 
 	organization := ExtractOrgFromResourceID(resourceID)
 
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern.Regex)
-		matches := re.FindAllString(text, -1)
+	// PR wali discussion ka url provider pe alag ho sakta hai (GitHub /pull, GitLab merge_requests)
+	var prNum int64
+	if disc.IsPullRequest {
+		prNum = disc.Num
+	}
+
+	compiled := patternRegexps(patterns)
+	for p, pattern := range patterns {
+		matches := findSecrets(pattern, compiled[p], text, matchTarget{})
 		for _, match := range matches {
 			findings = append(findings, models.Finding{
 				SecretType:      pattern.Name,
@@ -73,11 +327,306 @@ func ScanDiscussion(disc models.DISCUSSION, patterns []util_model.SecretPattern,
 				DiscussionNum:   disc.Num,
 				DiscussionTitle: disc.Title,
 				DiscussionRepo:  disc.RepoName,
+				PRNum:           prNum,
 				URL:             BuildHuggingFaceDiscussionURL(resourceType, resourceID, disc.Num),
 			})
 		}
 	}
 
+	// comment bodies aur unke purane edits, yahi sabse zyada keys leak hoti hai
+	for _, event := range disc.Events {
+		lines := strings.Split(event.Content, "\n")
+		for i, line := range lines {
+			for p, pattern := range patterns {
+				matches := findSecrets(pattern, compiled[p], line, matchTarget{})
+				for _, match := range matches {
+					findings = append(findings, models.Finding{
+						SecretType:      pattern.Name,
+						Pattern:         pattern.Regex,
+						Secret:          match,
+						SourceType:      "discussion",
+						Organization:    organization,
+						ResourceID:      resourceID,
+						ResourceType:    resourceType,
+						Line:            i + 1,
+						DiscussionNum:   disc.Num,
+						DiscussionTitle: disc.Title,
+						DiscussionRepo:  disc.RepoName,
+						PRNum:           prNum,
+						CommentID:       event.ID,
+						CommentAuthor:   event.AuthorName,
+						CommentCreated:  event.CreatedAt,
+						URL:             BuildHuggingFaceCommentURL(resourceType, resourceID, disc.Num, event.ID),
+					})
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+// PR ki diff me sirf added lines scan hoti hai; commit wise diff mili ho to usse,
+// aur kisi commit ki diff na mili ho to poore PR ki diff se bhi (tab commit merge commit ya last commit
+// maan lete hai); jo secret commit diff me mil chuka wo PR diff se dobara nahi aata
+func ScanPullRequest(pr models.PULL_REQUEST, patterns []util_model.SecretPattern, resourceType, resourceID string) []models.Finding {
+
+	var findings []models.Finding
+
+	organization := ExtractOrgFromResourceID(resourceID)
+
+	type diffSource struct {
+		commit   string
+		diff     string
+		fallback bool
+	}
+	var sources []diffSource
+	missingCommitDiff := len(pr.Commits) == 0
+	for _, commit := range pr.Commits {
+		if commit.Diff != "" {
+			sources = append(sources, diffSource{commit: commit.OID, diff: commit.Diff})
+		} else {
+			missingCommitDiff = true
+		}
+	}
+	if missingCommitDiff && pr.Diff != "" {
+		commit := pr.MergeCommitOID
+		if commit == "" && len(pr.Commits) > 0 {
+			commit = pr.Commits[len(pr.Commits)-1].OID
+		}
+		sources = append(sources, diffSource{commit: commit, diff: pr.Diff, fallback: true})
+	}
+
+	// commit diffs me mile secrets; PR diff wali finding inme ho to commit wali attribution rehti hai
+	seen := make(map[string]bool)
+	compiled := patternRegexps(patterns)
+	for _, source := range sources {
+		for _, file := range ParseUnifiedDiff(source.diff) {
+			for _, added := range file.Added {
+				for p, pattern := range patterns {
+					matches := findSecrets(pattern, compiled[p], added.Content, matchTarget{Path: file.Path, Commit: source.commit})
+					for _, match := range matches {
+						finding := models.Finding{
+							SecretType:      pattern.Name,
+							Pattern:         pattern.Regex,
+							Secret:          match,
+							SourceType:      "pr",
+							Organization:    organization,
+							ResourceID:      resourceID,
+							ResourceType:    resourceType,
+							FileName:        file.Path,
+							Line:            added.Line,
+							PRNum:           pr.Num,
+							DiscussionTitle: pr.Title,
+							CommitSHA:       source.commit,
+							URL:             BuildHuggingFacePRFileURL(resourceType, resourceID, pr.Num, source.commit, file.Path, added.Line),
+						}
+						fingerprint := FindingFingerprint(finding)
+						if source.fallback && seen[fingerprint] {
+							continue
+						}
+						seen[fingerprint] = true
+						findings = append(findings, finding)
+					}
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+// commit history (oldest first) walk karta hai: har distinct blob ki added lines ek hi baar scan hoti hai,
+// aur har secret ke liye first commit, last commit aur HEAD pe abhi bhi hai ya nahi track hota hai
+func ScanCommitHistory(history []models.COMMIT_DIFF, patterns []util_model.SecretPattern, resourceType, resourceID string) []models.Finding {
+
+	type trackedSecret struct {
+		finding models.Finding
+		first   string
+		last    string
+		removed bool
+		// secret ke rehte hue koi commit fetch nahi hua, wahan hata ho sakta hai
+		unknown bool
+	}
+
+	// ek blob ke matches; same blob dubara aaye (revert / copy) to regex dubara nahi chalta
+	type blobMatch struct {
+		pattern int
+		secret  string
+		line    int
+	}
+
+	organization := ExtractOrgFromResourceID(resourceID)
+	tracked := make(map[string]*trackedSecret)
+	var order []string
+	scannedBlobs := make(map[string][]blobMatch)
+	// path / commit wale rules me same blob ka outcome path ya commit pe bhi depend karta hai
+	pathDependent := patternsPathDependent(patterns)
+	commitDependent := patternsCommitDependent(patterns)
+
+	compiled := patternRegexps(patterns)
+
+	for _, commit := range history {
+		if commit.Failed {
+			for _, key := range order {
+				if !tracked[key].removed {
+					tracked[key].unknown = true
+				}
+			}
+			continue
+		}
+		if commit.Diff == "" {
+			continue
+		}
+		for _, file := range ParseUnifiedDiff(commit.Diff) {
+			added := strings.Builder{}
+			for _, line := range file.Added {
+				added.WriteString(line.Content)
+				added.WriteString("\n")
+			}
+
+			// jo secret is commit me hata, aur wapas add nahi hua, wo yahi khatam
+			for _, key := range order {
+				t := tracked[key]
+				if t.removed || (t.finding.FileName != file.Path && t.finding.FileName != file.OldPath) {
+					continue
+				}
+				removedHere := file.Deleted
+				for _, line := range file.Removed {
+					if strings.Contains(line.Content, t.finding.Secret) {
+						removedHere = true
+						break
+					}
+				}
+				if removedHere && !strings.Contains(added.String(), t.finding.Secret) {
+					t.removed = true
+					t.unknown = false
+				}
+			}
+
+			blobKey := ""
+			if file.BlobID != "" && !commitDependent {
+				blobKey = file.BlobID
+				if pathDependent {
+					blobKey += "\x00" + file.Path
+				}
+			}
+			matches, seen := scannedBlobs[blobKey]
+			if blobKey == "" || !seen {
+				matches = nil
+				for _, line := range file.Added {
+					for i, pattern := range patterns {
+						for _, match := range findSecrets(pattern, compiled[i], line.Content, matchTarget{Path: file.Path, Commit: commit.OID}) {
+							matches = append(matches, blobMatch{pattern: i, secret: match, line: line.Line})
+						}
+					}
+				}
+				if blobKey != "" {
+					scannedBlobs[blobKey] = matches
+				}
+			}
+
+			// seen blob pe bhi tracking chalti hai, warna revert se wapas aaya secret "removed" hi reh jaata
+			for _, match := range matches {
+				pattern := patterns[match.pattern]
+				key := file.Path + "\x00" + pattern.Name + "\x00" + match.secret
+				if t, exists := tracked[key]; exists {
+					// revert ke baad (ya missing commit ke baad dobara add) secret wapas aa gaya
+					t.removed, t.unknown = false, false
+					continue
+				}
+				tracked[key] = &trackedSecret{
+					finding: models.Finding{
+						SecretType:   pattern.Name,
+						Pattern:      pattern.Regex,
+						Secret:       match.secret,
+						SourceType:   "history",
+						Organization: organization,
+						ResourceID:   resourceID,
+						ResourceType: resourceType,
+						FileName:     file.Path,
+						Line:         match.line,
+						CommitSHA:    commit.OID,
+						URL:          BuildHuggingFaceRevisionFileURL(resourceType, resourceID, commit.OID, file.Path, match.line),
+					},
+					first: commit.OID,
+				}
+				order = append(order, key)
+			}
+		}
+
+		for _, key := range order {
+			if !tracked[key].removed {
+				tracked[key].last = commit.OID
+			}
+		}
+	}
+
+	var findings []models.Finding
+	for _, key := range order {
+		t := tracked[key]
+		stillAtHead := !t.removed
+		finding := t.finding
+		finding.FirstCommit = t.first
+		finding.LastCommit = t.last
+		// beech ka commit missing ho to pata nahi, true bolna galat hoga
+		if !t.unknown {
+			finding.StillAtHead = &stillAtHead
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// image config ki har value (ENV, LABEL, ENTRYPOINT, CMD) aur build history ke commands;
+// line us list me index hai, history finding us step ki layer pe point karti hai
+func ScanImageConfig(config models.IMAGE_CONFIG, patterns []util_model.SecretPattern, resourceType, resourceID string) []models.Finding {
+
+	var findings []models.Finding
+
+	organization := ExtractOrgFromResourceID(resourceID)
+
+	compiled := patternRegexps(patterns)
+	scanValues := func(sourceType, field string, values []string, layerDigest func(int) string) {
+		for i, value := range values {
+			for p, pattern := range patterns {
+				matches := findSecrets(pattern, compiled[p], value, matchTarget{Path: field})
+				for _, match := range matches {
+					findings = append(findings, models.Finding{
+						SecretType:   pattern.Name,
+						Pattern:      pattern.Regex,
+						Secret:       match,
+						SourceType:   sourceType,
+						Organization: organization,
+						ResourceID:   resourceID,
+						ResourceType: resourceType,
+						FileName:     field,
+						Line:         i + 1,
+						LayerDigest:  layerDigest(i),
+					})
+				}
+			}
+		}
+	}
+
+	configDigest := func(int) string { return config.Digest }
+	scanValues("image_config", "config.Env", config.Env, configDigest)
+	scanValues("image_config", "config.Labels", config.Labels, configDigest)
+	scanValues("image_config", "config.Entrypoint", config.Entrypoint, configDigest)
+	scanValues("image_config", "config.Cmd", config.Cmd, configDigest)
+
+	commands := make([]string, len(config.History))
+	for i, step := range config.History {
+		commands[i] = step.CreatedBy
+	}
+	scanValues("image_history", "history", commands, func(i int) string {
+		if config.History[i].LayerDigest != "" {
+			return config.History[i].LayerDigest
+		}
+		return config.Digest
+	})
+
 	return findings
 }
 
@@ -89,7 +638,13 @@ func ScanAIRequest(req models.AI_REQUEST, patterns []util_model.SecretPattern, r
 
 	results := []models.Finding{}
 
-	totalItems := len(req.Siblings) + len(req.Discussions)
+	totalItems := len(req.Siblings) + len(req.Discussions) + len(req.PullRequests)
+	if len(req.History) > 0 {
+		totalItems++
+	}
+	if req.ImageConfig != nil {
+		totalItems++
+	}
 	var scannedCount int32
 
 	log.Printf("  🔍 Scanning %d files and %d discussions...\n", len(req.Siblings), len(req.Discussions))
@@ -103,7 +658,7 @@ func ScanAIRequest(req models.AI_REQUEST, patterns []util_model.SecretPattern, r
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
-			findings := ScanFile(file, patterns, resourceType, resourceID)
+			findings := ScanFileCached(file, patterns, resourceType, resourceID)
 			ch <- findings
 			count := atomic.AddInt32(&scannedCount, 1)
 			if len(findings) > 0 {
@@ -129,6 +684,51 @@ func ScanAIRequest(req models.AI_REQUEST, patterns []util_model.SecretPattern, r
 			}
 		}(d)
 	}
+	for _, p := range req.PullRequests {
+		wg.Add(1)
+		go func(pr models.PULL_REQUEST) {
+
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			findings := ScanPullRequest(pr, patterns, resourceType, resourceID)
+			ch <- findings
+			count := atomic.AddInt32(&scannedCount, 1)
+			if len(findings) > 0 {
+				log.Printf("    [%d/%d] ⚠️  PR #%d: Found %d secrets\n", count, totalItems, pr.Num, len(findings))
+			}
+		}(p)
+	}
+	if len(req.History) > 0 {
+		wg.Add(1)
+		go func(history []models.COMMIT_DIFF) {
+
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			findings := ScanCommitHistory(history, patterns, resourceType, resourceID)
+			ch <- findings
+			count := atomic.AddInt32(&scannedCount, 1)
+			if len(findings) > 0 {
+				log.Printf("    [%d/%d] ⚠️  History (%d commits): Found %d secrets\n", count, totalItems, len(history), len(findings))
+			}
+		}(req.History)
+	}
+	if req.ImageConfig != nil {
+		wg.Add(1)
+		go func(config models.IMAGE_CONFIG) {
+
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			findings := ScanImageConfig(config, patterns, resourceType, resourceID)
+			ch <- findings
+			count := atomic.AddInt32(&scannedCount, 1)
+			if len(findings) > 0 {
+				log.Printf("    [%d/%d] ⚠️  Image config %s: Found %d secrets\n", count, totalItems, config.Digest, len(findings))
+			}
+		}(*req.ImageConfig)
+	}
 	go func() {
 
 		wg.Wait()
@@ -139,5 +739,5 @@ func ScanAIRequest(req models.AI_REQUEST, patterns []util_model.SecretPattern, r
 		results = append(results, f...)
 	}
 
-	return results
+	return LinkFindings(req.Provider, req.CommitSHA, results)
 }
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MishraShardendu22/Scanner/models"
+	util_model "github.com/MishraShardendu22/Scanner/util/model"
+)
+
+var testPatterns = []util_model.SecretPattern{{Name: "Test Token", Regex: `tok_[a-z0-9]{12}`}}
+
+func historyDiff(blob, removed, added string) string {
+	diff := "diff --git a/.env b/.env\nindex 000000..." + blob + " 100644\n--- a/.env\n+++ b/.env\n"
+	switch {
+	case removed != "" && added != "":
+		diff += "@@ -1 +1 @@\n-" + removed + "\n+" + added + "\n"
+	case removed != "":
+		diff += "@@ -1 +1,0 @@\n-" + removed + "\n"
+	default:
+		diff += "@@ -0,0 +1 @@\n+" + added + "\n"
+	}
+	return diff
+}
+
+func TestScanCommitHistoryRevertedSecret(t *testing.T) {
+	secret := "TOKEN=tok_abcdef123456"
+	history := []models.COMMIT_DIFF{
+		{OID: "c1", Diff: historyDiff("aaaaaa", "", secret)},
+		{OID: "c2", Diff: historyDiff("bbbbbb", secret, "TOKEN=")},
+		// revert: same blob as c1, regex dubara nahi chalta par secret wapas head pe hai
+		{OID: "c3", Diff: historyDiff("aaaaaa", "TOKEN=", secret)},
+	}
+
+	findings := ScanCommitHistory(history, testPatterns, "models", "org/repo")
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	finding := findings[0]
+	if finding.FirstCommit != "c1" || finding.LastCommit != "c3" {
+		t.Errorf("first/last = %s/%s, want c1/c3", finding.FirstCommit, finding.LastCommit)
+	}
+	if finding.StillAtHead == nil || !*finding.StillAtHead {
+		t.Errorf("still_at_head = %v, want true", finding.StillAtHead)
+	}
+}
+
+func TestScanCommitHistoryRemovedSecret(t *testing.T) {
+	secret := "TOKEN=tok_abcdef123456"
+	history := []models.COMMIT_DIFF{
+		{OID: "c1", Diff: historyDiff("aaaaaa", "", secret)},
+		{OID: "c2", Diff: historyDiff("bbbbbb", secret, "TOKEN=")},
+	}
+
+	findings := ScanCommitHistory(history, testPatterns, "models", "org/repo")
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	if findings[0].LastCommit != "c1" || findings[0].StillAtHead == nil || *findings[0].StillAtHead {
+		t.Errorf("last=%s still_at_head=%v, want c1 false", findings[0].LastCommit, *findings[0].StillAtHead)
+	}
+}
+
+func TestScanCommitHistoryFailedCommit(t *testing.T) {
+	secret := "TOKEN=tok_abcdef123456"
+	tests := []struct {
+		name    string
+		history []models.COMMIT_DIFF
+		want    string
+	}{
+		{"failed commit while present", []models.COMMIT_DIFF{
+			{OID: "c1", Diff: historyDiff("aaaaaa", "", secret)},
+			{OID: "c2", Failed: true},
+		}, "unknown"},
+		{"failed commit before the secret", []models.COMMIT_DIFF{
+			{OID: "c0", Failed: true},
+			{OID: "c1", Diff: historyDiff("aaaaaa", "", secret)},
+		}, "true"},
+		{"removed after the failed commit", []models.COMMIT_DIFF{
+			{OID: "c1", Diff: historyDiff("aaaaaa", "", secret)},
+			{OID: "c2", Failed: true},
+			{OID: "c3", Diff: historyDiff("bbbbbb", secret, "TOKEN=")},
+		}, "false"},
+		{"empty commit is not a failure", []models.COMMIT_DIFF{
+			{OID: "c1", Diff: historyDiff("aaaaaa", "", secret)},
+			{OID: "c2"},
+		}, "true"},
+	}
+	for _, tt := range tests {
+		findings := ScanCommitHistory(tt.history, testPatterns, "models", "org/repo")
+		if len(findings) != 1 {
+			t.Fatalf("%s: got %d findings, want 1", tt.name, len(findings))
+		}
+		got := "unknown"
+		if findings[0].StillAtHead != nil {
+			got = fmt.Sprint(*findings[0].StillAtHead)
+		}
+		if got != tt.want || findings[0].LastCommit != "c1" {
+			t.Errorf("%s: still_at_head = %s last = %s, want %s c1", tt.name, got, findings[0].LastCommit, tt.want)
+		}
+	}
+}
+
+func TestScanPullRequestFallsBackForMissingCommitDiff(t *testing.T) {
+	first := "TOKEN=tok_aaaaaa111111"
+	second := "TOKEN=tok_bbbbbb222222"
+	pr := models.PULL_REQUEST{
+		Num: 7,
+		// PR ki poori diff me dono commits ke added lines hai
+		Diff: "diff --git a/.env b/.env\n--- a/.env\n+++ b/.env\n@@ -0,0 +1,2 @@\n+" + first + "\n+" + second + "\n",
+		Commits: []models.PR_COMMIT{
+			{OID: "c1", Diff: historyDiff("aaaaaa", "", first)},
+			// c2 ki diff fetch nahi hui, uska secret sirf PR diff me hai
+			{OID: "c2"},
+		},
+	}
+
+	findings := ScanPullRequest(pr, testPatterns, "models", "org/repo")
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	commits := map[string]string{}
+	for _, finding := range findings {
+		commits[finding.Secret] = finding.CommitSHA
+	}
+	if commits["tok_aaaaaa111111"] != "c1" {
+		t.Errorf("commit diff secret attributed to %q, want c1", commits["tok_aaaaaa111111"])
+	}
+	if commits["tok_bbbbbb222222"] != "c2" {
+		t.Errorf("missing commit secret attributed to %q, want c2 (last commit)", commits["tok_bbbbbb222222"])
+	}
+}
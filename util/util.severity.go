@@ -0,0 +1,73 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+// dashboard aur CLI ka ek hi severity model: secret type se high / medium / low
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// ye leak hue to seedha paise / data ka nuksaan
+var highRiskSecretTypes = map[string]bool{
+	"AWS Access Key ID":       true,
+	"GitHub PAT":              true,
+	"OpenAI / LLM API Key":    true,
+	"Stripe Secret Key":       true,
+	"Database URI with creds": true,
+	"PostgreSQL URI":          true,
+	"MySQL URI":               true,
+	"MongoDB URI":             true,
+	"Google API Key":          true,
+	"Kubernetes Bearer Token": true,
+	"GitHub Actions Token":    true,
+}
+
+// rule set me rule ne apni severity di ho to wahi, warna built-in list
+func FindingSeverity(secretType string) string {
+	if severity := ActiveRuleSet().Severity(secretType); severity != "" {
+		return severity
+	}
+	if highRiskSecretTypes[secretType] {
+		return SeverityHigh
+	}
+	if secretType == "API Key" || secretType == "Access Token" {
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// imported finding apni severity ke saath aati hai, baaki secret type se
+func SeverityOf(finding models.Finding) string {
+	if SeverityRank(finding.Severity) > 0 {
+		return finding.Severity
+	}
+	return FindingSeverity(finding.SecretType)
+}
+
+// low < medium < high, threshold compare karne ke liye
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+func ParseSeverity(value string) (string, error) {
+	severity := strings.ToLower(strings.TrimSpace(value))
+	if SeverityRank(severity) == 0 {
+		return "", fmt.Errorf("invalid severity %q: use %s, %s or %s", value, SeverityLow, SeverityMedium, SeverityHigh)
+	}
+	return severity, nil
+}
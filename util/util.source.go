@@ -0,0 +1,130 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MishraShardendu22/Scanner/models"
+)
+
+const ProviderHuggingFace = "huggingface"
+
+// kisi bhi provider ka ek scan karne layak item (HF model, git repo, bucket ...)
+type SourceItem struct {
+	Provider     string `json:"provider"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	CommitSHA    string `json:"sha,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ek content provider: items list karna, unka content AI_REQUEST me laana aur findings ke urls banana.
+// UnifiedScan, scan state aur storage sirf isi interface se baat karte hai, HF bhi bas ek implementation hai
+type Source interface {
+	Name() string
+	ResourceTypes() []string
+	// owner (org / user / group / bucket) ke items
+	ListItems(resourceType, owner string) ([]SourceItem, error)
+	// files (+ provider support kare to discussions / PRs / history) aiRequest me bharta hai,
+	// plan batata hai ki full, incremental ya reused scan karna hai
+	Fetch(aiRequest *models.AI_REQUEST, resourceType, resourceID string, opts FetchOptions) (*RescanPlan, error)
+	// file finding ka url; revision khaali ho to default branch
+	FileURL(resourceType, resourceID, revision, fileName string, line int) string
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = map[string]Source{}
+)
+
+// naye provider (internal artifact store etc.) init() me yahi call karte hai
+func RegisterSource(source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources[strings.ToLower(source.Name())] = source
+}
+
+// khaali provider matlab Hugging Face, purani requests / stored data me provider tha hi nahi
+func GetSource(provider string) (Source, error) {
+	if provider == "" {
+		provider = ProviderHuggingFace
+	}
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	source, ok := sources[strings.ToLower(provider)]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q: use one of %s", provider, strings.Join(sourceNamesLocked(), ", "))
+	}
+	return source, nil
+}
+
+func SourceNames() []string {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	return sourceNamesLocked()
+}
+
+func sourceNamesLocked() []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func SupportsResourceType(source Source, resourceType string) bool {
+	for _, rt := range source.ResourceTypes() {
+		if rt == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+func ProviderOrDefault(provider string) string {
+	if provider == "" {
+		return ProviderHuggingFace
+	}
+	return strings.ToLower(provider)
+}
+
+// optional: jo provider issues / PRs bhi deta hai wo discussion findings ke urls khud banata hai
+type DiscussionLinker interface {
+	DiscussionURL(resourceType, resourceID string, num int64, isPullRequest bool, commentID string) string
+}
+
+// findings pe provider lagata hai aur file / discussion findings ka url provider se banwata hai;
+// non-HF file urls scan hue commit (revision) pe pin hote hai, HF ke main pe hi rehte hai jaise pehle the
+func LinkFindings(provider, revision string, findings []models.Finding) []models.Finding {
+	provider = ProviderOrDefault(provider)
+	source, err := GetSource(provider)
+	if provider == ProviderHuggingFace {
+		revision = ""
+	}
+	for i := range findings {
+		findings[i].Provider = provider
+		if err != nil {
+			continue
+		}
+		switch findings[i].SourceType {
+		case "file":
+			// object store me file ka version hi uska revision hai
+			rev := findings[i].CommitSHA
+			if findings[i].VersionID != "" {
+				rev = findings[i].VersionID
+			}
+			if rev == "" {
+				rev = revision
+			}
+			findings[i].URL = source.FileURL(findings[i].ResourceType, findings[i].ResourceID, rev, findings[i].FileName, findings[i].Line)
+		case "discussion":
+			if linker, ok := source.(DiscussionLinker); ok {
+				findings[i].URL = linker.DiscussionURL(findings[i].ResourceType, findings[i].ResourceID, findings[i].DiscussionNum, findings[i].PRNum != 0, findings[i].CommentID)
+			}
+		}
+	}
+	return findings
+}
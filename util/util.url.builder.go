@@ -1,9 +1,12 @@
 package util
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 func BuildHuggingFaceFileURL(resourceType, resourceID, fileName string, lineNumber int) string {
-	baseURL := fmt.Sprintf("https://huggingface.co/%s/blob/main/%s", resourceID, fileName)
+	baseURL := fmt.Sprintf("%s/%s/blob/main/%s", HFBaseURL(), HuggingFaceRepoPath(resourceType, resourceID), fileName)
 	if lineNumber > 0 {
 		return fmt.Sprintf("%s?line=%d", baseURL, lineNumber)
 	}
@@ -11,7 +14,40 @@ func BuildHuggingFaceFileURL(resourceType, resourceID, fileName string, lineNumb
 }
 
 func BuildHuggingFaceDiscussionURL(resourceType, resourceID string, discussionNum int64) string {
-	return fmt.Sprintf("https://huggingface.co/%s/%s/discussions/%d", resourceType, resourceID, discussionNum)
+	return fmt.Sprintf("%s/%s/discussions/%d", HFBaseURL(), HuggingFaceRepoPath(resourceType, resourceID), discussionNum)
+}
+
+func BuildHuggingFaceCommentURL(resourceType, resourceID string, discussionNum int64, commentID string) string {
+	baseURL := BuildHuggingFaceDiscussionURL(resourceType, resourceID, discussionNum)
+	if commentID == "" {
+		return baseURL
+	}
+	return fmt.Sprintf("%s#%s", baseURL, commentID)
+}
+
+// web urls me models ka koi prefix nahi hota, datasets / spaces ka hota hai
+func HuggingFaceRepoPath(resourceType, resourceID string) string {
+	switch resourceType {
+	case "datasets", "spaces":
+		return resourceType + "/" + resourceID
+	}
+	return resourceID
+}
+
+func BuildHuggingFacePRFileURL(resourceType, resourceID string, prNum int64, commitSHA, fileName string, lineNumber int) string {
+	if commitSHA == "" {
+		return fmt.Sprintf("%s/files", BuildHuggingFaceDiscussionURL(resourceType, resourceID, prNum))
+	}
+	return BuildHuggingFaceRevisionFileURL(resourceType, resourceID, commitSHA, fileName, lineNumber)
+}
+
+// kisi specific commit / revision pe file ka url
+func BuildHuggingFaceRevisionFileURL(resourceType, resourceID, revision, fileName string, lineNumber int) string {
+	baseURL := fmt.Sprintf("%s/%s/blob/%s/%s", HFBaseURL(), HuggingFaceRepoPath(resourceType, resourceID), revision, fileName)
+	if lineNumber > 0 {
+		return fmt.Sprintf("%s?line=%d", baseURL, lineNumber)
+	}
+	return baseURL
 }
 
 func ExtractOrgFromResourceID(resourceID string) string {
@@ -22,3 +58,8 @@ func ExtractOrgFromResourceID(resourceID string) string {
 	}
 	return resourceID
 }
+
+// HF_BASE_URL se local fake hub / mirror pe point kar sakte hai (tests, discovery), default asli hub
+func HFBaseURL() string {
+	return strings.TrimRight(GetEnv("HF_BASE_URL", "https://huggingface.co"), "/")
+}